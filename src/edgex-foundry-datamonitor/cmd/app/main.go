@@ -15,6 +15,7 @@
 package main
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"strings"
@@ -44,7 +45,6 @@ func main() {
 
 	a := app.NewWithID("edgex-datamonitor")
 	a.SetIcon(bundled.ResourceBgxPng)
-	logLifecycle(a)
 	w := a.NewWindow("EdgeX Data Monitor")
 	topWindow = w
 	w.SetMaster()
@@ -60,28 +60,126 @@ func main() {
 
 	events := make(chan *dtos.Event, config.MaxBufferSize)
 
+	ep := services.NewEventProcessor(events)
+	ep.SetLastEventsCount(cfg.GetLastEventsCount())
+
+	db := services.NewDB(config.DefaultFilteringUpdateCadenceMs)
+	db.SetDeduplicationWindow(cfg.GetDeduplicationWindow())
+
+	metrics := services.NewMetricsServer(ep, db)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	logLifecycle(a, func() {
+		stopCtx, stopCancel := context.WithTimeout(context.Background(), config.ShutdownTimeoutSeconds*time.Second)
+		defer stopCancel()
+		if err := metrics.Stop(stopCtx); err != nil {
+			log.Warnf("cannot stop the metrics server: %v", err)
+		}
+		if err := ep.Stop(stopCtx); err != nil {
+			log.Warnf("events still pending on shutdown were discarded: %v", err)
+		}
+		cancel()
+	})
+
 	go func() {
-		for range time.Tick(time.Second * 5) {
+		ticker := time.NewTicker(time.Second * 5)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
 			// 0 = good, high means that we are overwhelmed
 			log.Infof("events channel usage %v/%v", len(events), cap(events))
+			for _, s := range ep.ListenerStats() {
+				log.Infof("listener %v queue usage %v/%v, dropped %v, lag %v (max %v)", s.Name, s.QueueDepth, s.QueueCapacity, s.Dropped, s.LastLag, s.MaxLag)
+			}
 		}
 	}()
 
-	ep := services.NewEventProcessor(events)
-	db := services.NewDB(config.DefaultFilteringUpdateCadenceMs)
-	ep.AttachListener(db)
+	// the buffer must not lose events, the pages only need to know that something changed
+	ep.AttachListenerWithOptions(db, services.ListenerOptions{
+		QueueSize: config.MaxBufferSize,
+		Overflow:  services.BlockOnOverflow,
+	})
+	uiListenerOptions := services.ListenerOptions{
+		QueueSize: 1,
+		Overflow:  services.DropOldestOnOverflow,
+	}
 
 	AppManager := services.NewAppManager(client, cfg, ep, db)
+	AppManager.SetMetricsServer(metrics)
 
 	homePageHandler := pages.NewHomePageHandler(AppManager)
 	AppManager.SetPageHandler(pages.HomePageKey, homePageHandler)
-	ep.AttachListener(homePageHandler)
+	ep.AttachListenerWithOptions(homePageHandler, uiListenerOptions)
+	go homePageHandler.RunLastValuesClock(ctx)
+	go homePageHandler.RunRatesClock(ctx)
 
 	dataPageHandler := pages.NewDataPageHandler(AppManager)
 	AppManager.SetPageHandler(pages.DataPageKey, dataPageHandler)
-	ep.AttachListener(dataPageHandler)
+	pages.LoadSavedSearches(AppManager)
+	ep.AttachListenerWithOptions(dataPageHandler, uiListenerOptions)
+
+	series := services.NewSeriesStore(config.MaxSeriesPoints, config.MaxSeries)
+	ep.AttachListener(series)
+	AppManager.SetSeriesStore(series)
+
+	inventory := services.NewDeviceInventory()
+	ep.AttachListener(inventory)
+	AppManager.SetDeviceInventory(inventory)
 
-	go ep.Run()
+	devicesPageHandler := pages.NewDevicesPageHandler(AppManager)
+	AppManager.SetPageHandler(pages.DevicesPageKey, devicesPageHandler)
+	ep.AttachListenerWithOptions(devicesPageHandler, uiListenerOptions)
+
+	chartsPageHandler := pages.NewChartsPageHandler(AppManager)
+	AppManager.SetPageHandler(pages.ChartsPageKey, chartsPageHandler)
+	ep.AttachListenerWithOptions(chartsPageHandler, uiListenerOptions)
+
+	rules := services.NewRulesEngine(ep.Alerts())
+	if stored, err := services.ParseRules(a.Preferences().String(config.PrefAlertRules)); err != nil {
+		log.Errorf("cannot load the alert rules: %v", err)
+	} else {
+		rules.SetRules(stored, time.Now())
+	}
+	ep.AttachListener(rules)
+	AppManager.SetRulesEngine(rules)
+	go rules.Run(ctx)
+
+	anomalies := services.NewAnomalyDetector(cfg.GetAnomalySigma(), config.MaxBufferSize)
+	ep.AttachListener(anomalies)
+	AppManager.SetAnomalyDetector(anomalies)
+
+	alertsPageHandler := pages.NewAlertsPageHandler(AppManager)
+	AppManager.SetPageHandler(pages.AlertsPageKey, alertsPageHandler)
+	pages.LoadAlerts(AppManager)
+	ep.Alerts().Subscribe(func(services.Alert) {
+		pages.SaveAlerts(ep.Alerts())
+	})
+	ep.Alerts().Subscribe(alertsPageHandler.OnAlert)
+	ep.Alerts().Subscribe(func(alert services.Alert) {
+		title := alert.Kind.String()
+		if !alert.Active() {
+			title = title + " recovered"
+		}
+		a.SendNotification(&fyne.Notification{
+			Title:   title,
+			Content: alert.Message,
+		})
+	})
+	ep.SetStaleDetection(float64(cfg.GetStaleDeviceFactor()), cfg.GetDeviceExpectedIntervals())
+
+	if err := ep.Start(ctx); err != nil {
+		log.Fatal(err)
+	}
+
+	if port := cfg.GetMetricsPort(); port != 0 {
+		if err := metrics.Start(port); err != nil {
+			log.Errorf("cannot serve the metrics on port %d: %v", port, err)
+		}
+	}
 
 	client.OnConnect = func() bool {
 		messages, errs := client.Subscribe(config.DefaultEventsTopic)
@@ -106,7 +204,12 @@ func main() {
 						break LOOP
 					}
 				case msgEnvelope := <-messages:
-					event, _ := messaging.ParseEvent(msgEnvelope.Payload)
+					event, err := messaging.ParseEvent(msgEnvelope.Payload)
+					if err != nil {
+						log.Errorf("cannot parse event: %v", err)
+						ep.CountParseFailure()
+						continue
+					}
 					events <- event
 					select {
 					case ok <- true:
@@ -139,7 +242,7 @@ func main() {
 	intro := widget.NewLabel("An introduction would probably go\nhere, as well as a")
 	intro.Wrapping = fyne.TextWrapWord
 	setPage := func(uid widget.TreeNodeID, t pages.Page, appMgr *services.AppManager) {
-		appMgr.CurrentPage = uid
+		appMgr.SetCurrentPage(uid)
 		if fyne.CurrentDevice().IsMobile() {
 			child := a.NewWindow(t.Title)
 			topWindow = child
@@ -178,12 +281,13 @@ func main() {
 	w.ShowAndRun()
 }
 
-func logLifecycle(a fyne.App) {
+func logLifecycle(a fyne.App, onStopped func()) {
 	a.Lifecycle().SetOnStarted(func() {
 		log.Info("Lifecycle: Started")
 	})
 	a.Lifecycle().SetOnStopped(func() {
 		log.Info("Lifecycle: Stopped")
+		onStopped()
 	})
 	a.Lifecycle().SetOnEnteredForeground(func() {
 		log.Info("Lifecycle: Entered Foreground")
@@ -196,7 +300,9 @@ func logLifecycle(a fyne.App) {
 func makeNav(setPage func(_ widget.TreeNodeID, page pages.Page, appMgr *services.AppManager), appMgr *services.AppManager) fyne.CanvasObject {
 	a := fyne.CurrentApp()
 
-	tree := &widget.Tree{
+	// the saved searches select the Data page once applied
+	var tree *widget.Tree
+	tree = &widget.Tree{
 		ChildUIDs: func(uid widget.TreeNodeID) []widget.TreeNodeID {
 			return pages.PageIndex[uid]
 		},
@@ -217,6 +323,11 @@ func makeNav(setPage func(_ widget.TreeNodeID, page pages.Page, appMgr *services
 			obj.(*widget.Label).SetText(t.Title)
 		},
 		OnSelected: func(uid widget.TreeNodeID) {
+			// saved searches are shortcuts to the Data page
+			if pages.ApplySavedSearch(uid, appMgr) {
+				tree.Select(pages.DataPageKey)
+				return
+			}
 			if t, ok := pages.Pages[uid]; ok {
 				setPage(uid, t, appMgr)
 			}
@@ -224,6 +335,8 @@ func makeNav(setPage func(_ widget.TreeNodeID, page pages.Page, appMgr *services
 	}
 
 	tree.Select("home")
+	tree.OpenBranch(pages.DataPageKey)
+	appMgr.SetNavigate(tree.Select)
 
 	themes := container.New(layout.NewGridLayout(2),
 		widget.NewButton("Dark", func() {
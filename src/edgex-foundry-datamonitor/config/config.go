@@ -14,7 +14,14 @@
 //
 package config
 
-import "fyne.io/fyne/v2"
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"fyne.io/fyne/v2"
+	log "github.com/sirupsen/logrus"
+)
 
 type Config struct {
 	app         fyne.App
@@ -45,6 +52,84 @@ func (c *Config) GetEventsTableSortOrderAscending() bool {
 	return c.app.Preferences().BoolWithFallback(PrefEventsTableSortOrderAscending, DefaultEventsTableSortOrderAscending)
 }
 
+// GetDeduplicationWindow returns zero when events should not be deduplicated
+func (c *Config) GetDeduplicationWindow() time.Duration {
+	if !c.app.Preferences().BoolWithFallback(PrefDeduplicateEvents, DefaultDeduplicateEvents) {
+		return 0
+	}
+	seconds := c.app.Preferences().IntWithFallback(PrefDeduplicationWindowSeconds, DefaultDeduplicationWindowSeconds)
+	return time.Duration(seconds) * time.Second
+}
+
+func (c *Config) GetStaleDeviceFactor() int {
+	return c.app.Preferences().IntWithFallback(PrefStaleDeviceFactor, DefaultStaleDeviceFactor)
+}
+
+// GetAnomalySigma returns how many standard deviations away from the mean a reading is an anomaly
+func (c *Config) GetAnomalySigma() float64 {
+	return c.app.Preferences().FloatWithFallback(PrefAnomalySigma, DefaultAnomalySigma)
+}
+
+// GetLastEventsCount returns how many of the latest events are shown on the Home page
+func (c *Config) GetLastEventsCount() int {
+	return c.app.Preferences().IntWithFallback(PrefLastEventsCount, DefaultLastEventsCount)
+}
+
+// GetMetricsPort returns zero when the metrics should not be served
+func (c *Config) GetMetricsPort() int {
+	if !c.app.Preferences().BoolWithFallback(PrefMetricsEnabled, DefaultMetricsEnabled) {
+		return 0
+	}
+	return c.app.Preferences().IntWithFallback(PrefMetricsPort, DefaultMetricsPort)
+}
+
+// GetDataTableColumns returns the columns of the Data page table of the data type, empty for the default ones
+func (c *Config) GetDataTableColumns(dataType string) string {
+	return c.app.Preferences().String(dataTableColumnsPref(dataType))
+}
+
+func (c *Config) SetDataTableColumns(dataType string, columns string) {
+	c.app.Preferences().SetString(dataTableColumnsPref(dataType), columns)
+}
+
+func dataTableColumnsPref(dataType string) string {
+	if dataType == DataTypeReadings {
+		return PrefReadingsTableColumns
+	}
+	return PrefEventsTableColumns
+}
+
+// GetDeviceExpectedIntervals returns the intervals configured for the devices whose cadence shouldn't be learned
+func (c *Config) GetDeviceExpectedIntervals() map[string]time.Duration {
+	intervals, err := ParseExpectedIntervals(c.app.Preferences().String(PrefDeviceExpectedIntervals))
+	if err != nil {
+		log.Warnf("ignoring the expected intervals of the devices: %v", err)
+		return map[string]time.Duration{}
+	}
+	return intervals
+}
+
+// ParseExpectedIntervals parses a comma separated list of device=duration, e.g. "Random-Integer-Device=10s, thermostat=5m"
+func ParseExpectedIntervals(s string) (map[string]time.Duration, error) {
+	intervals := map[string]time.Duration{}
+	for _, item := range strings.Split(s, ",") {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+		parts := strings.SplitN(item, "=", 2)
+		if len(parts) != 2 || strings.TrimSpace(parts[0]) == "" {
+			return nil, fmt.Errorf("%q is not in the form device=duration", item)
+		}
+		interval, err := time.ParseDuration(strings.TrimSpace(parts[1]))
+		if err != nil || interval <= 0 {
+			return nil, fmt.Errorf("%q is not a valid duration", strings.TrimSpace(parts[1]))
+		}
+		intervals[strings.TrimSpace(parts[0])] = interval
+	}
+	return intervals, nil
+}
+
 // String returns a pointer to the given string.
 func String(s string) *string {
 	return &s
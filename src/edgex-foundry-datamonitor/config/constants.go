@@ -21,6 +21,20 @@ const (
 	PrefShouldConnectAtStartup        = "_ShouldConnectAtStartup"
 	PrefEventsTableSortOrderAscending = "_EventsTableSortOrderAscending"
 	PrefBufferSizeInDataPage          = "_BufferSizeInDataPage"
+	PrefDeduplicateEvents             = "_DeduplicateEvents"
+	PrefDeduplicationWindowSeconds    = "_DeduplicationWindowSeconds"
+	PrefStaleDeviceFactor             = "_StaleDeviceFactor"
+	PrefDeviceExpectedIntervals       = "_DeviceExpectedIntervals"
+	PrefAlertRules                    = "_AlertRules"
+	PrefAlerts                        = "_Alerts"
+	PrefAnomalySigma                  = "_AnomalySigma"
+	PrefMetricsEnabled                = "_MetricsEnabled"
+	PrefMetricsPort                   = "_MetricsPort"
+	PrefLastEventsCount               = "_LastEventsCount"
+	PrefEventsTableColumns            = "_EventsTableColumns"
+	PrefReadingsTableColumns          = "_ReadingsTableColumns"
+	PrefSavedSearches                 = "_SavedSearches"
+	PrefFilterHistory                 = "_FilterHistory"
 
 	SessionDataPageDataType   = "Session_DataPageDataType"
 	SessionDataPageBufferSize = "Session_DataPage_BufferSize"
@@ -37,11 +51,47 @@ const (
 	DefaultEventsTableSortOrderAscending = false
 	DefaultBufferSizeInDataPage          = 100
 	DefaultFilteringUpdateCadenceMs      = 1000
+	DefaultListenerQueueSize             = 1024
+	DefaultDeduplicateEvents             = false
+	DefaultDeduplicationWindowSeconds    = 60
+	DefaultStaleDeviceFactor             = 3
+	DefaultAnomalySigma                  = 3.0
+	DefaultMetricsEnabled                = false
+	DefaultMetricsPort                   = 2112
+	DefaultLastEventsCount               = 5
+
+	ShutdownTimeoutSeconds = 5
 )
 
 const (
 	MinBufferSize = 1
 	MaxBufferSize = 100000
+
+	MaxAlerts = 1000
+
+	MaxFilterHistory = 20
+
+	// a point per second of the last hour, for as many series
+	MaxSeriesPoints = 3600
+	MaxSeries       = 256
+
+	MinDeduplicationWindowSeconds = 1
+	MaxDeduplicationWindowSeconds = 3600
+
+	MinStaleDeviceFactor = 2
+	MaxStaleDeviceFactor = 100
+
+	MinAnomalySigma = 1.0
+	MaxAnomalySigma = 10.0
+
+	MinLastEventsCount = 1
+	MaxLastEventsCount = 100
+
+	MinMetricsPort = 1024
+	MaxMetricsPort = 65535
+
+	MinColumnWidth = 40
+	MaxColumnWidth = 1000
 )
 
 const (
@@ -32,7 +32,7 @@ func StringNotEmptyValidator(s string) error {
 
 func MinMaxValidator(min, max int, validationError error) func(s string) error {
 	return func(s string) error {
-		log.Debug("validating %v", s)
+		log.Debugf("validating %v", s)
 		n, err := strconv.Atoi(s)
 		if err != nil || n < min || n > max {
 			return validationError
@@ -41,6 +41,27 @@ func MinMaxValidator(min, max int, validationError error) func(s string) error {
 	}
 }
 
+func MinMaxFloatValidator(min, max float64, validationError error) func(s string) error {
+	return func(s string) error {
+		n, err := strconv.ParseFloat(s, 64)
+		if err != nil || n < min || n > max {
+			return validationError
+		}
+		return nil
+	}
+}
+
+func ExpectedIntervalsValidator(s string) error {
+	_, err := config.ParseExpectedIntervals(s)
+	return err
+}
+
 var (
-	ErrInvalidBufferSize = fmt.Errorf("Must be a number between %d - %d", config.MinBufferSize, config.MaxBufferSize)
+	ErrInvalidBufferSize          = fmt.Errorf("Must be a number between %d - %d", config.MinBufferSize, config.MaxBufferSize)
+	ErrInvalidDeduplicationWindow = fmt.Errorf("Must be a number of seconds between %d - %d", config.MinDeduplicationWindowSeconds, config.MaxDeduplicationWindowSeconds)
+	ErrInvalidStaleDeviceFactor   = fmt.Errorf("Must be a number between %d - %d", config.MinStaleDeviceFactor, config.MaxStaleDeviceFactor)
+	ErrInvalidAnomalySigma        = fmt.Errorf("Must be a number between %v - %v", config.MinAnomalySigma, config.MaxAnomalySigma)
+	ErrInvalidLastEventsCount     = fmt.Errorf("Must be a number between %d - %d", config.MinLastEventsCount, config.MaxLastEventsCount)
+	ErrInvalidMetricsPort         = fmt.Errorf("Must be a port between %d - %d", config.MinMetricsPort, config.MaxMetricsPort)
+	ErrInvalidColumnWidth         = fmt.Errorf("Must be a width between %d - %d", config.MinColumnWidth, config.MaxColumnWidth)
 )
@@ -5,7 +5,6 @@ go 1.16
 require fyne.io/fyne/v2 v2.1.1
 
 require (
-	github.com/asecurityteam/rolling v2.0.4+incompatible
 	github.com/edgexfoundry/go-mod-core-contracts v0.1.149
 	github.com/edgexfoundry/go-mod-messaging/v2 v2.0.1
 	github.com/fsnotify/fsnotify v1.5.1 // indirect
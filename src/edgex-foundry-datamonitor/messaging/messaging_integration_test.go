@@ -16,6 +16,7 @@
 package messaging_test
 
 import (
+	"context"
 	"testing"
 	"time"
 
@@ -78,7 +79,8 @@ func TestClient_Subscribe(t *testing.T) {
 			require.Nil(t, err)
 
 			ep := services.NewEventProcessor(events)
-			go ep.Run()
+			require.NoError(t, ep.Start(context.Background()))
+			defer ep.Stop(context.Background())
 
 			gracePeriod := time.NewTimer(10 * time.Second)
 		LOOP:
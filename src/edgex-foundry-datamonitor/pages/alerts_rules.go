@@ -0,0 +1,221 @@
+// Copyright 2021 Alessandro De Blasis <alex@deblasis.net>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+package pages
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/data/validation"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/theme"
+	"fyne.io/fyne/v2/widget"
+	"github.com/deblasis/edgex-foundry-datamonitor/config"
+	"github.com/deblasis/edgex-foundry-datamonitor/data"
+	"github.com/deblasis/edgex-foundry-datamonitor/services"
+)
+
+// rulesPanel lists the alert rules and lets them be edited
+type rulesPanel struct {
+	list      *widget.List
+	addBtn    *widget.Button
+	editBtn   *widget.Button
+	toggleBtn *widget.Button
+	deleteBtn *widget.Button
+
+	rules    []services.Rule
+	selected int
+
+	content *fyne.Container
+}
+
+func (p *alertsPageHandler) renderRulesPanel(win fyne.Window) fyne.CanvasObject {
+	r := &rulesPanel{
+		selected: -1,
+		rules:    p.appState.GetRulesEngine().Rules(),
+	}
+	r.list = widget.NewList(
+		func() int {
+			return len(r.rules)
+		},
+		func() fyne.CanvasObject {
+			return container.NewBorder(nil, nil, widget.NewLabel("disabled"), nil, widget.NewLabel(""))
+		},
+		func(i widget.ListItemID, o fyne.CanvasObject) {
+			rule := r.rules[i]
+			c := o.(*fyne.Container)
+			status := "enabled"
+			if !rule.Enabled {
+				status = "disabled"
+			}
+			c.Objects[1].(*widget.Label).SetText(status)
+			name := c.Objects[0].(*widget.Label)
+			name.TextStyle = fyne.TextStyle{Bold: rule.Enabled}
+			name.SetText(fmt.Sprintf("%v: %v", rule.Name, rule.Description()))
+		},
+	)
+
+	r.addBtn = widget.NewButtonWithIcon("Add rule", theme.ContentAddIcon(), func() {
+		p.editRule(win, r, -1)
+	})
+	r.editBtn = widget.NewButtonWithIcon("Edit", theme.DocumentCreateIcon(), func() {
+		p.editRule(win, r, r.selected)
+	})
+	r.toggleBtn = widget.NewButtonWithIcon("Enable/Disable", theme.MediaPauseIcon(), func() {
+		rules := append([]services.Rule{}, r.rules...)
+		rules[r.selected].Enabled = !rules[r.selected].Enabled
+		p.saveRules(r, rules)
+	})
+	r.deleteBtn = widget.NewButtonWithIcon("Delete", theme.DeleteIcon(), func() {
+		rule := r.rules[r.selected]
+		dialog.ShowConfirm("Delete rule", fmt.Sprintf("Delete the rule %v?", rule.Name), func(ok bool) {
+			if !ok {
+				return
+			}
+			rules := append([]services.Rule{}, r.rules[:r.selected]...)
+			rules = append(rules, r.rules[r.selected+1:]...)
+			p.saveRules(r, rules)
+		}, win)
+	})
+
+	r.list.OnSelected = func(id widget.ListItemID) {
+		r.selected = id
+		r.updateButtons()
+	}
+	r.list.OnUnselected = func(widget.ListItemID) {
+		r.selected = -1
+		r.updateButtons()
+	}
+	r.updateButtons()
+
+	r.content = container.NewBorder(
+		container.NewHBox(r.addBtn, r.editBtn, r.toggleBtn, r.deleteBtn),
+		nil, nil, nil,
+		r.list,
+	)
+	return r.content
+}
+
+func (r *rulesPanel) updateButtons() {
+	for _, b := range []*widget.Button{r.editBtn, r.toggleBtn, r.deleteBtn} {
+		if r.selected >= 0 && r.selected < len(r.rules) {
+			b.Enable()
+		} else {
+			b.Disable()
+		}
+	}
+}
+
+// saveRules stores the rules in the preferences and starts evaluating them
+func (p *alertsPageHandler) saveRules(r *rulesPanel, rules []services.Rule) {
+	fyne.CurrentApp().Preferences().SetString(config.PrefAlertRules, services.FormatRules(rules))
+	p.appState.GetRulesEngine().SetRules(rules, time.Now())
+
+	r.rules = rules
+	r.selected = -1
+	r.list.UnselectAll()
+	r.list.Refresh()
+	r.updateButtons()
+}
+
+// editRule opens the rule at index in a form, a negative index adds a new one
+func (p *alertsPageHandler) editRule(win fyne.Window, r *rulesPanel, index int) {
+	rule := services.Rule{Enabled: true, Condition: services.GreaterThan}
+	if index >= 0 {
+		rule = r.rules[index]
+	}
+
+	name := widget.NewEntry()
+	name.Validator = data.StringNotEmptyValidator
+	name.SetText(rule.Name)
+	device := widget.NewEntry()
+	device.SetPlaceHolder("* matches any device")
+	device.SetText(rule.Device)
+	resource := widget.NewEntry()
+	resource.SetPlaceHolder("* matches any resource")
+	resource.SetText(rule.Resource)
+
+	conditions := make([]string, 0, len(services.RuleConditions))
+	for _, c := range services.RuleConditions {
+		conditions = append(conditions, string(c))
+	}
+	condition := widget.NewSelect(conditions, func(string) {})
+	condition.SetSelected(string(rule.Condition))
+
+	value := widget.NewEntry()
+	value.SetText(rule.Value)
+	upper := widget.NewEntry()
+	upper.SetPlaceHolder("only for between")
+	upper.SetText(rule.Upper)
+	hold := widget.NewEntry()
+	hold.Validator = validation.NewRegexp(`^\d+$`, "Must be a number of seconds")
+	hold.SetText(fmt.Sprintf("%d", rule.HoldSeconds))
+	enabled := widget.NewCheck("Enabled", func(bool) {})
+	enabled.SetChecked(rule.Enabled)
+
+	title := "Add rule"
+	if index >= 0 {
+		title = "Edit rule"
+	}
+	items := []*widget.FormItem{
+		{Text: "Name", Widget: name},
+		{Text: "Device", Widget: device, HintText: "Name or glob pattern"},
+		{Text: "Resource", Widget: resource, HintText: "Name or glob pattern"},
+		{Text: "Condition", Widget: condition},
+		{Text: "Value", Widget: value, HintText: "Threshold, expected value or lower bound"},
+		{Text: "Upper bound", Widget: upper},
+		{Text: "Hold", Widget: hold, HintText: "Seconds the condition must hold"},
+		{Text: "", Widget: enabled},
+	}
+	dialog.ShowForm(title, "Save", "Cancel", items, func(ok bool) {
+		if !ok {
+			return
+		}
+		seconds, _ := strconv.Atoi(hold.Text)
+		edited := services.Rule{
+			Name:        strings.TrimSpace(name.Text),
+			Enabled:     enabled.Checked,
+			Device:      strings.TrimSpace(device.Text),
+			Resource:    strings.TrimSpace(resource.Text),
+			Condition:   services.RuleCondition(condition.Selected),
+			Value:       strings.TrimSpace(value.Text),
+			Upper:       strings.TrimSpace(upper.Text),
+			HoldSeconds: seconds,
+		}
+		if err := edited.Validate(); err != nil {
+			dialog.ShowError(err, win)
+			return
+		}
+		for i, other := range r.rules {
+			if i != index && other.Name == edited.Name {
+				dialog.ShowError(errors.New("Another rule has the same name"), win)
+				return
+			}
+		}
+
+		rules := append([]services.Rule{}, r.rules...)
+		if index >= 0 {
+			rules[index] = edited
+		} else {
+			rules = append(rules, edited)
+		}
+		p.saveRules(r, rules)
+	}, win)
+}
@@ -0,0 +1,181 @@
+// Copyright 2021 Alessandro De Blasis <alex@deblasis.net>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+package pages
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/theme"
+	"fyne.io/fyne/v2/widget"
+	"github.com/deblasis/edgex-foundry-datamonitor/config"
+	"github.com/deblasis/edgex-foundry-datamonitor/services"
+)
+
+const (
+	alertsColRaised = iota
+	alertsColStatus
+	alertsColKind
+	alertsColSource
+	alertsColMessage
+)
+
+var alertsColumns = []string{"Raised", "Status", "Kind", "Source", "Message"}
+
+type alertsPageHandler struct {
+	appState *services.AppManager
+
+	Key widget.TreeNodeID
+
+	summary    *widget.Label
+	activeOnly *widget.Check
+	clearBtn   *widget.Button
+	table      *widget.Table
+
+	rows     []services.Alert
+	rowsLock sync.RWMutex
+}
+
+func NewAlertsPageHandler(appState *services.AppManager) *alertsPageHandler {
+	p := &alertsPageHandler{
+		Key:      AlertsPageKey,
+		appState: appState,
+	}
+
+	p.summary = widget.NewLabel("")
+	p.activeOnly = widget.NewCheck("Active only", func(bool) {})
+	p.clearBtn = widget.NewButtonWithIcon("Clear recovered", theme.DeleteIcon(), func() {})
+
+	return p
+}
+
+func (p *alertsPageHandler) SetInitialState() {
+	p.table = p.renderAlertsTable()
+}
+func (p *alertsPageHandler) RehydrateSession() {
+}
+func (p *alertsPageHandler) SetupBindings() {
+	p.activeOnly.OnChanged = func(bool) {
+		p.updateAlerts()
+	}
+	p.clearBtn.OnTapped = func() {
+		p.appState.GetEventProcessor().Alerts().ClearRecovered()
+		SaveAlerts(p.appState.GetEventProcessor().Alerts())
+		p.updateAlerts()
+	}
+	p.updateAlerts()
+}
+
+// LoadAlerts puts back the alerts of the previous run from the preferences
+func LoadAlerts(appMgr *services.AppManager) {
+	alerts, err := services.ParseAlerts(fyne.CurrentApp().Preferences().String(config.PrefAlerts))
+	if err != nil {
+		log.Errorf("cannot load the alerts: %v", err)
+		return
+	}
+	appMgr.GetEventProcessor().Alerts().Load(alerts, time.Now())
+}
+
+// SaveAlerts stores the alert log in the preferences, it's subscribed to the log so that it survives a restart
+func SaveAlerts(alertLog *services.AlertLog) {
+	fyne.CurrentApp().Preferences().SetString(config.PrefAlerts, services.FormatAlerts(alertLog.Get()))
+}
+
+// OnAlert is subscribed to the alert log, it's called when an alert is raised or recovers
+func (p *alertsPageHandler) OnAlert(alert services.Alert) {
+	p.updateAlerts()
+}
+
+func (p *alertsPageHandler) updateAlerts() {
+	alertLog := p.appState.GetEventProcessor().Alerts()
+	all := alertLog.Get()
+
+	rows := make([]services.Alert, 0, len(all))
+	for _, a := range all {
+		if p.activeOnly.Checked && !a.Active() {
+			continue
+		}
+		rows = append(rows, a)
+	}
+
+	p.rowsLock.Lock()
+	p.rows = rows
+	p.rowsLock.Unlock()
+
+	p.summary.SetText(fmt.Sprintf("%d active, %d alerts in total", alertLog.ActiveCount(), len(all)))
+	if p.table != nil {
+		p.table.Refresh()
+	}
+}
+
+func alertStatusText(alert services.Alert) string {
+	if alert.Active() {
+		return "ACTIVE"
+	}
+	return fmt.Sprintf("recovered at %v (after %v)", alert.RecoveredAt.Format("15:04:05"), alert.RecoveredAt.Sub(alert.RaisedAt).Round(time.Second))
+}
+
+func (p *alertsPageHandler) renderAlertsTable() *widget.Table {
+	t := widget.NewTable(
+		func() (int, int) {
+			p.rowsLock.RLock()
+			defer p.rowsLock.RUnlock()
+			return len(p.rows) + 1, len(alertsColumns)
+		},
+		func() fyne.CanvasObject {
+			return widget.NewLabel("---fdaec17c-c0fc-4a04-982e-31a08a0bb776---")
+		},
+		func(i widget.TableCellID, o fyne.CanvasObject) {
+			p.rowsLock.RLock()
+			defer p.rowsLock.RUnlock()
+
+			label := o.(*widget.Label)
+			if i.Row == 0 {
+				label.TextStyle = fyne.TextStyle{Bold: true}
+				label.SetText(alertsColumns[i.Col])
+				return
+			}
+			if i.Row > len(p.rows) {
+				label.SetText("")
+				return
+			}
+
+			alert := p.rows[i.Row-1]
+			label.TextStyle = fyne.TextStyle{Bold: alert.Active()}
+			switch i.Col {
+			case alertsColRaised:
+				label.SetText(alert.RaisedAt.Format("2006-01-02 15:04:05"))
+			case alertsColStatus:
+				label.SetText(alertStatusText(alert))
+			case alertsColKind:
+				label.SetText(alert.Kind.String())
+			case alertsColSource:
+				label.SetText(alert.Source)
+			case alertsColMessage:
+				label.SetText(alert.Message)
+			}
+		},
+	)
+	t.SetColumnWidth(alertsColRaised, 170)
+	t.SetColumnWidth(alertsColStatus, 260)
+	t.SetColumnWidth(alertsColKind, 120)
+	t.SetColumnWidth(alertsColSource, 200)
+	t.SetColumnWidth(alertsColMessage, 600)
+	return t
+}
@@ -0,0 +1,379 @@
+// Copyright 2021 Alessandro De Blasis <alex@deblasis.net>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+package pages
+
+import (
+	"fmt"
+	"image/color"
+	"math"
+	"sort"
+	"sync"
+	"time"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/canvas"
+	"fyne.io/fyne/v2/driver/desktop"
+	"fyne.io/fyne/v2/theme"
+	"fyne.io/fyne/v2/widget"
+	"github.com/deblasis/edgex-foundry-datamonitor/services"
+)
+
+const (
+	chartMarginLeft   = 70
+	chartMarginRight  = 16
+	chartMarginTop    = 28
+	chartMarginBottom = 28
+	chartTicks        = 5
+)
+
+// chartPalette is cycled through by the series in the order they are plotted
+var chartPalette = []color.Color{
+	color.NRGBA{R: 0x1f, G: 0x77, B: 0xb4, A: 0xff},
+	color.NRGBA{R: 0xff, G: 0x7f, B: 0x0e, A: 0xff},
+	color.NRGBA{R: 0x2c, G: 0xa0, B: 0x2c, A: 0xff},
+	color.NRGBA{R: 0xd6, G: 0x27, B: 0x28, A: 0xff},
+	color.NRGBA{R: 0x94, G: 0x67, B: 0xbd, A: 0xff},
+	color.NRGBA{R: 0x8c, G: 0x56, B: 0x4b, A: 0xff},
+	color.NRGBA{R: 0xe3, G: 0x77, B: 0xc2, A: 0xff},
+	color.NRGBA{R: 0x17, G: 0xbe, B: 0xcf, A: 0xff},
+}
+
+type chartSeries struct {
+	Name   string
+	Color  color.Color
+	Points []services.SeriesPoint
+}
+
+// lineChart plots series over a span of time with canvas primitives,
+// hovering shows the values nearest to the pointer and scrolling zooms
+type lineChart struct {
+	widget.BaseWidget
+
+	// OnZoom is called when the user scrolls on the chart
+	OnZoom func(in bool)
+
+	series []chartSeries
+	from   time.Time
+	to     time.Time
+	hover  *fyne.Position
+	sync.RWMutex
+}
+
+func newLineChart() *lineChart {
+	c := &lineChart{}
+	c.ExtendBaseWidget(c)
+	return c
+}
+
+// SetData replaces what is plotted, the points of each series must be sorted by time
+func (c *lineChart) SetData(series []chartSeries, from, to time.Time) {
+	c.Lock()
+	c.series = series
+	c.from = from
+	c.to = to
+	c.Unlock()
+	c.Refresh()
+}
+
+func (c *lineChart) CreateRenderer() fyne.WidgetRenderer {
+	c.ExtendBaseWidget(c)
+	return &lineChartRenderer{c: c}
+}
+
+func (c *lineChart) MouseIn(e *desktop.MouseEvent) {
+	c.setHover(&e.Position)
+}
+
+func (c *lineChart) MouseMoved(e *desktop.MouseEvent) {
+	c.setHover(&e.Position)
+}
+
+func (c *lineChart) MouseOut() {
+	c.setHover(nil)
+}
+
+func (c *lineChart) setHover(pos *fyne.Position) {
+	c.Lock()
+	c.hover = pos
+	c.Unlock()
+	c.Refresh()
+}
+
+func (c *lineChart) Scrolled(e *fyne.ScrollEvent) {
+	if c.OnZoom != nil && e.Scrolled.DY != 0 {
+		c.OnZoom(e.Scrolled.DY > 0)
+	}
+}
+
+type lineChartRenderer struct {
+	c       *lineChart
+	size    fyne.Size
+	objects []fyne.CanvasObject
+}
+
+func (r *lineChartRenderer) Layout(size fyne.Size) {
+	r.size = size
+	r.build()
+}
+
+func (r *lineChartRenderer) MinSize() fyne.Size {
+	return fyne.NewSize(300, 200)
+}
+
+func (r *lineChartRenderer) Refresh() {
+	r.build()
+	canvas.Refresh(r.c)
+}
+
+func (r *lineChartRenderer) Objects() []fyne.CanvasObject {
+	return r.objects
+}
+
+func (r *lineChartRenderer) Destroy() {
+}
+
+// chartArea maps times and values to positions in the plot area
+type chartArea struct {
+	left, top, width, height float32
+	from                     time.Time
+	span                     time.Duration
+	min, max                 float64
+}
+
+func (a chartArea) x(t time.Time) float32 {
+	return a.left + float32(float64(t.Sub(a.from))/float64(a.span))*a.width
+}
+
+func (a chartArea) y(v float64) float32 {
+	return a.top + a.height - float32((v-a.min)/(a.max-a.min))*a.height
+}
+
+func (a chartArea) contains(pos fyne.Position) bool {
+	return pos.X >= a.left && pos.X <= a.left+a.width && pos.Y >= a.top && pos.Y <= a.top+a.height
+}
+
+// valueRange pads the range of the values so that the lines don't touch the borders
+func valueRange(series []chartSeries) (float64, float64, bool) {
+	min, max := math.Inf(1), math.Inf(-1)
+	for _, s := range series {
+		for _, p := range s.Points {
+			min = math.Min(min, p.Value)
+			max = math.Max(max, p.Value)
+		}
+	}
+	if math.IsInf(min, 0) {
+		return 0, 0, false
+	}
+	pad := (max - min) * 0.05
+	if pad == 0 {
+		pad = math.Max(math.Abs(max)*0.1, 1)
+	}
+	return min - pad, max + pad, true
+}
+
+func (r *lineChartRenderer) build() {
+	r.c.RLock()
+	defer r.c.RUnlock()
+
+	objects := []fyne.CanvasObject{}
+	defer func() {
+		r.objects = objects
+	}()
+
+	area := chartArea{
+		left:   chartMarginLeft,
+		top:    chartMarginTop,
+		width:  r.size.Width - chartMarginLeft - chartMarginRight,
+		height: r.size.Height - chartMarginTop - chartMarginBottom,
+		from:   r.c.from,
+		span:   r.c.to.Sub(r.c.from),
+	}
+	if area.width <= 0 || area.height <= 0 || area.span <= 0 {
+		return
+	}
+
+	border := canvas.NewRectangle(color.Transparent)
+	border.StrokeColor = theme.DisabledColor()
+	border.StrokeWidth = 1
+	border.Move(fyne.NewPos(area.left, area.top))
+	border.Resize(fyne.NewSize(area.width, area.height))
+	objects = append(objects, border)
+
+	objects = append(objects, r.legend(area)...)
+
+	for i := 0; i <= chartTicks; i++ {
+		t := area.from.Add(area.span * time.Duration(i) / chartTicks)
+		label := chartText(t.Format("15:04:05"), theme.ForegroundColor())
+		x := area.x(t) - label.MinSize().Width/2
+		label.Move(fyne.NewPos(x, area.top+area.height+4))
+		objects = append(objects, label)
+	}
+
+	min, max, ok := valueRange(r.c.series)
+	if !ok {
+		empty := chartText("No data in this time span", theme.DisabledColor())
+		empty.Move(fyne.NewPos(area.left+(area.width-empty.MinSize().Width)/2, area.top+area.height/2))
+		objects = append(objects, empty)
+		return
+	}
+	area.min, area.max = min, max
+
+	for i := 0; i <= chartTicks; i++ {
+		v := min + (max-min)*float64(i)/chartTicks
+		y := area.y(v)
+		grid := canvas.NewLine(theme.DisabledColor())
+		grid.StrokeWidth = 0.5
+		grid.Position1 = fyne.NewPos(area.left, y)
+		grid.Position2 = fyne.NewPos(area.left+area.width, y)
+		label := chartText(fmt.Sprintf("%.4g", v), theme.ForegroundColor())
+		label.Move(fyne.NewPos(area.left-label.MinSize().Width-6, y-label.MinSize().Height/2))
+		objects = append(objects, grid, label)
+	}
+
+	// there is no point in drawing more segments than there are pixels
+	maxPoints := int(area.width) * 2
+	for _, s := range r.c.series {
+		step := 1
+		if len(s.Points) > maxPoints {
+			step = (len(s.Points) + maxPoints - 1) / maxPoints
+		}
+		if len(s.Points) == 1 {
+			objects = append(objects, chartDot(area, s.Points[0], s.Color))
+			continue
+		}
+		for i := step; i < len(s.Points); i += step {
+			objects = append(objects, chartSegment(area, s.Points[i-step], s.Points[i], s.Color))
+		}
+		if last := len(s.Points) - 1; last%step != 0 {
+			objects = append(objects, chartSegment(area, s.Points[last-last%step], s.Points[last], s.Color))
+		}
+	}
+
+	if r.c.hover != nil && area.contains(*r.c.hover) {
+		objects = append(objects, r.inspect(area, *r.c.hover)...)
+	}
+}
+
+func (r *lineChartRenderer) legend(area chartArea) []fyne.CanvasObject {
+	objects := []fyne.CanvasObject{}
+	x := area.left
+	for _, s := range r.c.series {
+		swatch := canvas.NewRectangle(s.Color)
+		swatch.Resize(fyne.NewSize(12, 12))
+		swatch.Move(fyne.NewPos(x, (chartMarginTop-12)/2))
+		name := chartText(s.Name, theme.ForegroundColor())
+		name.Move(fyne.NewPos(x+16, (chartMarginTop-name.MinSize().Height)/2))
+		objects = append(objects, swatch, name)
+		x += 16 + name.MinSize().Width + 16
+	}
+	return objects
+}
+
+// inspect draws a cursor at the hovered time and the values of the series nearest to it
+func (r *lineChartRenderer) inspect(area chartArea, pos fyne.Position) []fyne.CanvasObject {
+	at := area.from.Add(time.Duration(float64(area.span) * float64((pos.X-area.left)/area.width)))
+
+	cursor := canvas.NewLine(theme.ForegroundColor())
+	cursor.StrokeWidth = 0.5
+	cursor.Position1 = fyne.NewPos(pos.X, area.top)
+	cursor.Position2 = fyne.NewPos(pos.X, area.top+area.height)
+	objects := []fyne.CanvasObject{cursor}
+
+	lines := []*canvas.Text{chartText(at.Format("15:04:05.000"), theme.ForegroundColor())}
+	for _, s := range r.c.series {
+		p, ok := nearestPoint(s.Points, at)
+		if !ok {
+			continue
+		}
+		objects = append(objects, chartDot(area, p, s.Color))
+		lines = append(lines, chartText(fmt.Sprintf("%v: %v at %v", s.Name, formatChartValue(p.Value), p.At.Format("15:04:05.000")), s.Color))
+	}
+
+	var width, height float32
+	for _, l := range lines {
+		width = float32(math.Max(float64(width), float64(l.MinSize().Width)))
+		height += l.MinSize().Height
+	}
+	width += 2 * theme.Padding()
+	height += 2 * theme.Padding()
+
+	// keep the box inside the plot area, on the other side of the cursor if needed
+	x, y := pos.X+12, pos.Y+12
+	if x+width > area.left+area.width {
+		x = pos.X - 12 - width
+	}
+	if y+height > area.top+area.height {
+		y = area.top + area.height - height
+	}
+	x = float32(math.Max(float64(x), float64(area.left)))
+	y = float32(math.Max(float64(y), float64(area.top)))
+
+	box := canvas.NewRectangle(theme.BackgroundColor())
+	box.StrokeColor = theme.DisabledColor()
+	box.StrokeWidth = 1
+	box.Move(fyne.NewPos(x, y))
+	box.Resize(fyne.NewSize(width, height))
+	objects = append(objects, box)
+
+	ty := y + theme.Padding()
+	for _, l := range lines {
+		l.Move(fyne.NewPos(x+theme.Padding(), ty))
+		ty += l.MinSize().Height
+		objects = append(objects, l)
+	}
+	return objects
+}
+
+// nearestPoint looks for the point closest in time in points sorted by time
+func nearestPoint(points []services.SeriesPoint, at time.Time) (services.SeriesPoint, bool) {
+	if len(points) == 0 {
+		return services.SeriesPoint{}, false
+	}
+	i := sort.Search(len(points), func(i int) bool {
+		return !points[i].At.Before(at)
+	})
+	if i == len(points) {
+		return points[i-1], true
+	}
+	if i > 0 && at.Sub(points[i-1].At) < points[i].At.Sub(at) {
+		return points[i-1], true
+	}
+	return points[i], true
+}
+
+func formatChartValue(v float64) string {
+	return fmt.Sprintf("%.6g", v)
+}
+
+func chartText(text string, c color.Color) *canvas.Text {
+	t := canvas.NewText(text, c)
+	t.TextSize = theme.CaptionTextSize()
+	return t
+}
+
+func chartSegment(area chartArea, from, to services.SeriesPoint, c color.Color) *canvas.Line {
+	l := canvas.NewLine(c)
+	l.StrokeWidth = 1.5
+	l.Position1 = fyne.NewPos(area.x(from.At), area.y(from.Value))
+	l.Position2 = fyne.NewPos(area.x(to.At), area.y(to.Value))
+	return l
+}
+
+func chartDot(area chartArea, p services.SeriesPoint, c color.Color) *canvas.Circle {
+	dot := canvas.NewCircle(c)
+	dot.Move(fyne.NewPos(area.x(p.At)-3, area.y(p.Value)-3))
+	dot.Resize(fyne.NewSize(6, 6))
+	return dot
+}
@@ -0,0 +1,219 @@
+// Copyright 2021 Alessandro De Blasis <alex@deblasis.net>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+package pages
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"fyne.io/fyne/v2/theme"
+	"fyne.io/fyne/v2/widget"
+	"github.com/deblasis/edgex-foundry-datamonitor/services"
+	"github.com/edgexfoundry/go-mod-core-contracts/v2/dtos"
+)
+
+// chartWindows are the spans of time the chart can show, zooming steps through them
+var chartWindows = []time.Duration{
+	10 * time.Second,
+	30 * time.Second,
+	time.Minute,
+	5 * time.Minute,
+	15 * time.Minute,
+	30 * time.Minute,
+	time.Hour,
+}
+
+type chartsPageHandler struct {
+	appState *services.AppManager
+
+	Key widget.TreeNodeID
+
+	seriesPicker *widget.CheckGroup
+	window       *widget.Select
+	zoomInBtn    *widget.Button
+	zoomOutBtn   *widget.Button
+	pauseBtn     *widget.Button
+	status       *widget.Label
+	chart        *lineChart
+
+	// keys maps the options of the picker, named after the series, back to them
+	keys     map[string]services.SeriesKey
+	keysLock sync.Mutex
+}
+
+func NewChartsPageHandler(appState *services.AppManager) *chartsPageHandler {
+	p := &chartsPageHandler{
+		Key:      ChartsPageKey,
+		appState: appState,
+		keys:     map[string]services.SeriesKey{},
+	}
+
+	p.seriesPicker = widget.NewCheckGroup([]string{}, func([]string) {})
+	windows := make([]string, 0, len(chartWindows))
+	for _, w := range chartWindows {
+		windows = append(windows, formatWindow(w))
+	}
+	p.window = widget.NewSelect(windows, func(string) {})
+	p.zoomInBtn = widget.NewButtonWithIcon("", theme.ZoomInIcon(), func() {})
+	p.zoomOutBtn = widget.NewButtonWithIcon("", theme.ZoomOutIcon(), func() {})
+	p.pauseBtn = widget.NewButtonWithIcon("Pause", theme.MediaPauseIcon(), func() {})
+	p.status = widget.NewLabel("")
+
+	return p
+}
+
+func (p *chartsPageHandler) SetInitialState() {
+	p.chart = newLineChart()
+	p.updateSeriesOptions()
+}
+func (p *chartsPageHandler) RehydrateSession() {
+	p.seriesPicker.Selected = p.appState.GetChartsPageSeries()
+	p.window.Selected = formatWindow(p.appState.GetChartsPageWindow())
+	p.updatePauseButton()
+}
+func (p *chartsPageHandler) SetupBindings() {
+	p.seriesPicker.OnChanged = func(selected []string) {
+		p.appState.SetChartsPageSeries(selected)
+		p.updateChart()
+	}
+	p.window.OnChanged = func(selected string) {
+		for _, w := range chartWindows {
+			if formatWindow(w) == selected {
+				p.appState.SetChartsPageWindow(w)
+			}
+		}
+		p.updateChart()
+	}
+	p.zoomInBtn.OnTapped = func() {
+		p.zoom(true)
+	}
+	p.zoomOutBtn.OnTapped = func() {
+		p.zoom(false)
+	}
+	p.chart.OnZoom = p.zoom
+	p.pauseBtn.OnTapped = func() {
+		if p.appState.GetChartsPagePausedAt().IsZero() {
+			p.appState.SetChartsPagePausedAt(time.Now())
+		} else {
+			p.appState.SetChartsPagePausedAt(time.Time{})
+		}
+		p.updatePauseButton()
+		p.updateChart()
+	}
+	p.updateChart()
+}
+
+func (p *chartsPageHandler) OnEventReceived(event dtos.Event) {
+
+	if p.appState.GetConnectionState() != services.ClientConnected {
+		return
+	}
+	p.updateSeriesOptions()
+
+	// a paused chart keeps still, the data keeps being collected
+	if !p.appState.GetChartsPagePausedAt().IsZero() {
+		return
+	}
+	p.updateChart()
+}
+
+// zoom steps to the next shorter or longer window
+func (p *chartsPageHandler) zoom(in bool) {
+	current := p.appState.GetChartsPageWindow()
+	i := 0
+	for i < len(chartWindows)-1 && chartWindows[i] < current {
+		i++
+	}
+	if in && i > 0 {
+		i--
+	} else if !in && i < len(chartWindows)-1 {
+		i++
+	}
+	p.window.SetSelected(formatWindow(chartWindows[i]))
+}
+
+func (p *chartsPageHandler) updatePauseButton() {
+	if p.appState.GetChartsPagePausedAt().IsZero() {
+		p.pauseBtn.SetText("Pause")
+		p.pauseBtn.SetIcon(theme.MediaPauseIcon())
+		return
+	}
+	p.pauseBtn.SetText("Resume")
+	p.pauseBtn.SetIcon(theme.MediaPlayIcon())
+}
+
+// updateSeriesOptions adds the series that showed up since the last time to the picker
+func (p *chartsPageHandler) updateSeriesOptions() {
+	keys := p.appState.GetSeriesStore().Keys()
+
+	p.keysLock.Lock()
+	if len(keys) == len(p.keys) {
+		p.keysLock.Unlock()
+		return
+	}
+	options := make([]string, 0, len(keys))
+	for _, k := range keys {
+		p.keys[k.String()] = k
+		options = append(options, k.String())
+	}
+	p.keysLock.Unlock()
+
+	p.seriesPicker.Options = options
+	p.seriesPicker.Refresh()
+}
+
+func (p *chartsPageHandler) updateChart() {
+	if p.chart == nil {
+		return
+	}
+	store := p.appState.GetSeriesStore()
+
+	to := p.appState.GetChartsPagePausedAt()
+	if to.IsZero() {
+		to = time.Now()
+	}
+	from := to.Add(-p.appState.GetChartsPageWindow())
+
+	selected := p.appState.GetChartsPageSeries()
+	series := make([]chartSeries, 0, len(selected))
+	points := 0
+	p.keysLock.Lock()
+	for i, name := range selected {
+		key, ok := p.keys[name]
+		if !ok {
+			continue
+		}
+		s := chartSeries{
+			Name:   name,
+			Color:  chartPalette[i%len(chartPalette)],
+			Points: store.Points(key, from, to),
+		}
+		points += len(s.Points)
+		series = append(series, s)
+	}
+	p.keysLock.Unlock()
+
+	p.chart.SetData(series, from, to)
+
+	switch {
+	case len(p.seriesPicker.Options) == 0:
+		p.status.SetText("Waiting for numeric readings")
+	case len(series) == 0:
+		p.status.SetText("Pick the series to plot")
+	default:
+		p.status.SetText(fmt.Sprintf("%d points in %d series", points, len(series)))
+	}
+}
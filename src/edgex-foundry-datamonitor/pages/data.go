@@ -40,7 +40,7 @@ func dataScreen(win fyne.Window, appManager *services.AppManager) fyne.CanvasObj
 					if err := appManager.Connect(); err != nil {
 						uerr := fmt.Errorf("Cannot connect\n%s", err)
 						dialog.ShowError(uerr, win)
-						log.Error("cannot connect: %v", err)
+						log.Errorf("cannot connect: %v", err)
 					}
 					appManager.Refresh()
 				}),
@@ -56,16 +56,17 @@ func dataScreen(win fyne.Window, appManager *services.AppManager) fyne.CanvasObj
 	// It will have a radio button to select between events or readings
 	radioGroup := container.NewVBox(
 		widget.NewLabelWithStyle("Show", fyne.TextAlignLeading, fyne.TextStyle{Bold: true}),
-		h.dataType,
+		container.NewHBox(h.dataType, h.pinnedOnly, h.anomaliesOnly, h.deviceFilter),
 	)
 	searchBox := container.NewVBox(
 		widget.NewLabelWithStyle("Filter", fyne.TextAlignLeading, fyne.TextStyle{Bold: true}),
-		container.NewBorder(nil, nil, nil, container.NewHBox(h.searchBtn, h.resetSearchBtn), container.NewMax(h.search)),
+		container.NewBorder(nil, nil, nil, container.NewHBox(h.searchBtn, h.resetSearchBtn, h.historyBtn, h.savedSearchesBtn), container.NewMax(h.search)),
+		container.NewHBox(h.fieldsBtn, h.receivedWithin),
 	)
 
 	bufferSizeContainer := container.NewGridWithColumns(2,
 		container.NewBorder(nil, nil, widget.NewLabel("Buffer size"), h.applyBufferSizeBtn, h.bufferSize),
-		h.bufferProgress,
+		container.NewBorder(nil, nil, nil, h.exportBtn, h.bufferProgress),
 	)
 
 	h.SetInitialState()
@@ -83,7 +84,7 @@ func dataScreen(win fyne.Window, appManager *services.AppManager) fyne.CanvasObj
 		nil,
 		nil,
 		nil,
-		container.NewMax(h.eventsTable, h.readingsTable),
+		container.NewMax(h.eventsView, h.readingsTable),
 	)
 
 	content := container.NewBorder(
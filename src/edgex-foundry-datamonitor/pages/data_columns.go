@@ -0,0 +1,439 @@
+// Copyright 2021 Alessandro De Blasis <alex@deblasis.net>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+package pages
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/data/binding"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/layout"
+	"fyne.io/fyne/v2/theme"
+	"fyne.io/fyne/v2/widget"
+	"github.com/deblasis/edgex-foundry-datamonitor/config"
+	"github.com/deblasis/edgex-foundry-datamonitor/data"
+)
+
+// dataColumn is a column that the tables of the Data page can show
+type dataColumn struct {
+	// key identifies the column in the saved layouts
+	key    string
+	header string
+	width  float32
+	// hidden columns are only shown once they are picked in the column chooser
+	hidden bool
+}
+
+// wideColumnWidth fits ids and timestamps
+const wideColumnWidth = 330
+
+// There are no Source Name and Units columns: the events and readings of go-mod-core-contracts v0.1.149,
+// the version this module is built with, don't have SourceName nor Units. They can be added along with
+// the fields once the contracts are upgraded to a version that has them
+var eventsColumns = []dataColumn{
+	pinColumn:            {key: "pin", header: "Pin", width: pinColumnWidth},
+	eventsColId:          {key: "id", header: "Id", width: wideColumnWidth},
+	eventsColDeviceName:  {key: "deviceName", header: "Device Name", width: 220},
+	eventsColProfileName: {key: "profileName", header: "Profile Name", width: 220},
+	eventsColOrigin:      {key: "origin", header: "Origin", width: wideColumnWidth},
+	eventsColReadings:    {key: "readings", header: "Readings", width: 95},
+	eventsColTags:        {key: "tags", header: "Tags", width: 220},
+	eventsColCreated:     {key: "created", header: "Created", width: wideColumnWidth},
+	eventsColDuplicates:  {key: "duplicates", header: "Duplicates", width: 110},
+	eventsColNote:        {key: "note", header: "Note", width: 220},
+	eventsColLatency:     {key: "latency", header: "Latency", width: 110},
+	eventsColReceived:    {key: "received", header: "Received", width: wideColumnWidth, hidden: true},
+	eventsColSerial:      {key: "serial", header: "Serial", width: 90, hidden: true},
+	eventsColMatchedIn:   {key: "matchedIn", header: "Matched In", width: 220},
+}
+
+var readingsColumns = []dataColumn{
+	pinColumn:               {key: "pin", header: "Pin", width: pinColumnWidth},
+	readingsColId:           {key: "id", header: "Id", width: wideColumnWidth},
+	readingsColDeviceName:   {key: "deviceName", header: "Device Name", width: 220},
+	readingsColResourceName: {key: "resourceName", header: "Resource Name", width: 220},
+	readingsColProfileName:  {key: "profileName", header: "Profile Name", width: 220},
+	readingsColValueType:    {key: "valueType", header: "Value Type", width: 130},
+	readingsColValue:        {key: "value", header: "Value", width: 220},
+	readingsColBinaryValue:  {key: "binaryValue", header: "Binary Value", width: 110},
+	readingsColMediaType:    {key: "mediaType", header: "Media Type", width: 100},
+	readingsColOrigin:       {key: "origin", header: "Origin", width: wideColumnWidth},
+	readingsColCreated:      {key: "created", header: "Created", width: wideColumnWidth},
+	readingsColNote:         {key: "note", header: "Note", width: 220},
+	readingsColLatency:      {key: "latency", header: "Latency", width: 110},
+	readingsColAnomaly:      {key: "anomaly", header: "Anomaly", width: 100},
+	readingsColEventId:      {key: "eventId", header: "Event Id", width: wideColumnWidth, hidden: true},
+	readingsColReceived:     {key: "received", header: "Received", width: wideColumnWidth, hidden: true},
+	readingsColSerial:       {key: "serial", header: "Serial", width: 90, hidden: true},
+	readingsColMatchedIn:    {key: "matchedIn", header: "Matched In", width: 220},
+}
+
+var errNoColumnsShown = errors.New("At least one column must be shown")
+
+func dataColumnsOf(dataType string) []dataColumn {
+	if dataType == config.DataTypeReadings {
+		return readingsColumns
+	}
+	return eventsColumns
+}
+
+// shownColumn is a column shown in a table, a layout lists them in the order they appear
+type shownColumn struct {
+	column int
+	width  float32
+}
+
+func defaultColumnLayout(columns []dataColumn) []shownColumn {
+	layout := make([]shownColumn, 0, len(columns))
+	for i, c := range columns {
+		if !c.hidden {
+			layout = append(layout, shownColumn{column: i, width: c.width})
+		}
+	}
+	return layout
+}
+
+// parseColumnLayout reads a comma separated list of key=width, e.g. "pin=70, deviceName=220".
+// The columns that are unknown are skipped so that a layout saved by another version still applies
+func parseColumnLayout(s string, columns []dataColumn) ([]shownColumn, error) {
+	layout := make([]shownColumn, 0, len(columns))
+	for _, item := range strings.Split(s, ",") {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+		parts := strings.SplitN(item, "=", 2)
+		if len(parts) != 2 {
+			return nil, fmt.Errorf("%q is not in the form column=width", item)
+		}
+		width, err := strconv.Atoi(strings.TrimSpace(parts[1]))
+		if err != nil || width < config.MinColumnWidth || width > config.MaxColumnWidth {
+			return nil, fmt.Errorf("%q is not a valid width", strings.TrimSpace(parts[1]))
+		}
+		column := columnByKey(columns, strings.TrimSpace(parts[0]))
+		if column < 0 || layoutShows(layout, column) {
+			continue
+		}
+		layout = append(layout, shownColumn{column: column, width: float32(width)})
+	}
+	if len(layout) == 0 {
+		return nil, errNoColumnsShown
+	}
+	return layout, nil
+}
+
+func formatColumnLayout(layout []shownColumn, columns []dataColumn) string {
+	items := make([]string, 0, len(layout))
+	for _, c := range layout {
+		items = append(items, fmt.Sprintf("%s=%d", columns[c.column].key, int(c.width)))
+	}
+	return strings.Join(items, ",")
+}
+
+func columnByKey(columns []dataColumn, key string) int {
+	for i, c := range columns {
+		if c.key == key {
+			return i
+		}
+	}
+	return -1
+}
+
+func layoutShows(layout []shownColumn, column int) bool {
+	for _, c := range layout {
+		if c.column == column {
+			return true
+		}
+	}
+	return false
+}
+
+// columnAt returns what the column of a cell shows, -1 beyond the last one
+func columnAt(layout []shownColumn, col int) int {
+	if col < 0 || col >= len(layout) {
+		return -1
+	}
+	return layout[col].column
+}
+
+func applyColumnWidths(t *widget.Table, layout []shownColumn) {
+	for i, c := range layout {
+		t.SetColumnWidth(i, c.width)
+	}
+}
+
+// loadColumnLayouts reads the saved layouts, falling back to the default ones
+func (p *dataPageHandler) loadColumnLayouts() {
+	cfg := p.appState.GetConfig()
+	p.tableDataLock.Lock()
+	defer p.tableDataLock.Unlock()
+	for _, dataType := range []string{config.DataTypeEvents, config.DataTypeReadings} {
+		columns := dataColumnsOf(dataType)
+		layout := defaultColumnLayout(columns)
+		if saved := cfg.GetDataTableColumns(dataType); saved != "" {
+			if parsed, err := parseColumnLayout(saved, columns); err != nil {
+				log.Warnf("ignoring the columns saved for the %s table: %v", dataType, err)
+			} else {
+				layout = parsed
+			}
+		}
+		*p.columnLayoutOf(dataType) = layout
+	}
+}
+
+// columnLayoutOf must be called holding tableDataLock
+func (p *dataPageHandler) columnLayoutOf(dataType string) *[]shownColumn {
+	if dataType == config.DataTypeReadings {
+		return &p.readingsColumns
+	}
+	return &p.eventsColumns
+}
+
+// dataColumnAt returns what the column of a cell of the table of the data type shows
+func (p *dataPageHandler) dataColumnAt(dataType string, col int) int {
+	p.tableDataLock.RLock()
+	defer p.tableDataLock.RUnlock()
+	return columnAt(*p.columnLayoutOf(dataType), col)
+}
+
+// setColumnLayout shows the columns in the table of the data type and saves them for the next time,
+// hiding the column the table is sorted by goes back to the default order
+func (p *dataPageHandler) setColumnLayout(dataType string, layout []shownColumn) {
+	p.tableDataLock.Lock()
+	*p.columnLayoutOf(dataType) = layout
+	p.tableDataLock.Unlock()
+
+	p.appState.GetConfig().SetDataTableColumns(dataType, formatColumnLayout(layout, dataColumnsOf(dataType)))
+	if s, ok := p.appState.GetDataPageSort(dataType); ok && !layoutShows(layout, s.Column) {
+		p.appState.ResetDataPageSort(dataType)
+		p.refreshTable()
+	}
+
+	t := p.eventsTable
+	if dataType == config.DataTypeReadings {
+		t = p.readingsTable
+	}
+	applyColumnWidths(t, layout)
+	t.Refresh()
+}
+
+// columnChoice is a row of the column chooser
+type columnChoice struct {
+	column  int
+	visible bool
+	width   string
+}
+
+// columnChoices lists the shown columns in their order, followed by the others
+func columnChoices(layout []shownColumn, columns []dataColumn) []columnChoice {
+	choices := make([]columnChoice, 0, len(columns))
+	for _, c := range layout {
+		choices = append(choices, columnChoice{column: c.column, visible: true, width: strconv.Itoa(int(c.width))})
+	}
+	for i, c := range columns {
+		if !layoutShows(layout, i) {
+			choices = append(choices, columnChoice{column: i, width: strconv.Itoa(int(c.width))})
+		}
+	}
+	return choices
+}
+
+func layoutFromChoices(choices []columnChoice, columns []dataColumn) ([]shownColumn, error) {
+	layout := make([]shownColumn, 0, len(choices))
+	for _, c := range choices {
+		if !c.visible {
+			continue
+		}
+		width, err := strconv.Atoi(c.width)
+		if err != nil || width < config.MinColumnWidth || width > config.MaxColumnWidth {
+			return nil, fmt.Errorf("%s: %v", columns[c.column].header, data.ErrInvalidColumnWidth)
+		}
+		layout = append(layout, shownColumn{column: c.column, width: float32(width)})
+	}
+	if len(layout) == 0 {
+		return nil, errNoColumnsShown
+	}
+	return layout, nil
+}
+
+// showColumnChooser lets the user pick, reorder and resize the columns of the table of the data type
+func (p *dataPageHandler) showColumnChooser(win fyne.Window, dataType string) {
+	columns := dataColumnsOf(dataType)
+	p.tableDataLock.RLock()
+	choices := columnChoices(*p.columnLayoutOf(dataType), columns)
+	p.tableDataLock.RUnlock()
+
+	rows := container.NewVBox()
+	var render func()
+	render = func() {
+		rows.Objects = nil
+		for i := range choices {
+			i := i
+			choice := &choices[i]
+
+			visible := widget.NewCheck(columns[choice.column].header, func(checked bool) {
+				choice.visible = checked
+			})
+			visible.Checked = choice.visible
+
+			width := widget.NewEntry()
+			width.SetText(choice.width)
+			width.Validator = data.MinMaxValidator(config.MinColumnWidth, config.MaxColumnWidth, data.ErrInvalidColumnWidth)
+			width.OnChanged = func(s string) {
+				choice.width = s
+			}
+
+			up := widget.NewButtonWithIcon("", theme.MoveUpIcon(), func() {
+				choices[i-1], choices[i] = choices[i], choices[i-1]
+				render()
+			})
+			if i == 0 {
+				up.Disable()
+			}
+			down := widget.NewButtonWithIcon("", theme.MoveDownIcon(), func() {
+				choices[i], choices[i+1] = choices[i+1], choices[i]
+				render()
+			})
+			if i == len(choices)-1 {
+				down.Disable()
+			}
+
+			rows.Add(container.NewGridWithColumns(2,
+				visible,
+				container.NewBorder(nil, nil, nil, container.NewHBox(up, down), width),
+			))
+		}
+		rows.Refresh()
+	}
+	render()
+
+	var dlg dialog.Dialog
+	resetBtn := widget.NewButtonWithIcon("Reset", theme.ViewRefreshIcon(), func() {
+		choices = columnChoices(defaultColumnLayout(columns), columns)
+		render()
+	})
+	applyBtn := widget.NewButtonWithIcon("Apply", theme.ConfirmIcon(), func() {
+		layout, err := layoutFromChoices(choices, columns)
+		if err != nil {
+			dialog.ShowError(err, win)
+			return
+		}
+		p.setColumnLayout(dataType, layout)
+		dlg.Hide()
+	})
+	applyBtn.Importance = widget.HighImportance
+
+	content := container.NewBorder(
+		widget.NewLabel("Pick the columns to show, their order and their width"),
+		container.NewHBox(resetBtn, layout.NewSpacer(), applyBtn),
+		nil, nil,
+		container.NewVScroll(rows),
+	)
+	dlg = dialog.NewCustom(fmt.Sprintf("Columns of the %s table", strings.ToLower(dataType)), "Cancel", content, win)
+	dlg.Resize(fyne.NewSize(520, 600))
+	dlg.Show()
+}
+
+// timestampText is empty when the timestamp is unknown
+func timestampText(nanos int64) string {
+	if nanos == 0 {
+		return ""
+	}
+	return time.Unix(0, nanos).String()
+}
+
+func eventCellText(row binding.DataMap, column int) string {
+	switch column {
+	case pinColumn:
+		return pinText(getBool(row, "Pinned"))
+	case eventsColId:
+		return getString(row, "Id")
+	case eventsColDeviceName:
+		return getString(row, "DeviceName")
+	case eventsColProfileName:
+		return getString(row, "ProfileName")
+	case eventsColOrigin:
+		return timestampText(getInt(row, "Origin"))
+	case eventsColReadings:
+		return fmt.Sprintf("%d", getInt(row, "ReadingsCount"))
+	case eventsColTags:
+		return getString(row, "Tags")
+	case eventsColCreated:
+		return timestampText(getInt(row, "Created"))
+	case eventsColDuplicates:
+		return copiesText(getInt(row, "Copies"))
+	case eventsColNote:
+		return getString(row, "Note")
+	case eventsColLatency:
+		return getString(row, "Latency")
+	case eventsColReceived:
+		return timestampText(getInt(row, "ReceivedAt"))
+	case eventsColSerial:
+		return fmt.Sprintf("%d", getInt(row, "Serial"))
+	}
+	return ""
+}
+
+func readingCellText(row binding.DataMap, column int) string {
+	switch column {
+	case pinColumn:
+		return pinText(getBool(row, "Pinned"))
+	case readingsColId:
+		return getString(row, "Id")
+	case readingsColDeviceName:
+		return getString(row, "DeviceName")
+	case readingsColResourceName:
+		return getString(row, "ResourceName")
+	case readingsColProfileName:
+		return getString(row, "ProfileName")
+	case readingsColValueType:
+		return getString(row, "ValueType")
+	case readingsColValue:
+		return getString(row, "Value")
+	case readingsColBinaryValue:
+		// the payload itself is in the inspector
+		if binaryValue := getString(row, "BinaryValue"); binaryValue != "" {
+			return bytesText(len(binaryValue))
+		}
+		return ""
+	case readingsColMediaType:
+		return getString(row, "MediaType")
+	case readingsColOrigin:
+		return timestampText(getInt(row, "Origin"))
+	case readingsColCreated:
+		return timestampText(getInt(row, "Created"))
+	case readingsColNote:
+		return getString(row, "Note")
+	case readingsColLatency:
+		return getString(row, "Latency")
+	case readingsColAnomaly:
+		return getString(row, "Anomaly")
+	case readingsColEventId:
+		return getString(row, "EventId")
+	case readingsColReceived:
+		return timestampText(getInt(row, "ReceivedAt"))
+	case readingsColSerial:
+		return fmt.Sprintf("%d", getInt(row, "Serial"))
+	}
+	return ""
+}
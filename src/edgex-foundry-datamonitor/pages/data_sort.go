@@ -0,0 +1,226 @@
+// Copyright 2021 Alessandro De Blasis <alex@deblasis.net>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+package pages
+
+import (
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/deblasis/edgex-foundry-datamonitor/config"
+	"github.com/deblasis/edgex-foundry-datamonitor/services"
+)
+
+const (
+	eventsColId = iota + 1
+	eventsColDeviceName
+	eventsColProfileName
+	eventsColOrigin
+	eventsColReadings
+	eventsColTags
+	eventsColCreated
+	eventsColDuplicates
+	eventsColNote
+	eventsColLatency
+	eventsColReceived
+	eventsColSerial
+	eventsColMatchedIn
+)
+
+const (
+	readingsColId = iota + 1
+	readingsColDeviceName
+	readingsColResourceName
+	readingsColProfileName
+	readingsColValueType
+	readingsColValue
+	readingsColBinaryValue
+	readingsColMediaType
+	readingsColOrigin
+	readingsColCreated
+	readingsColNote
+	readingsColLatency
+	readingsColAnomaly
+	readingsColEventId
+	readingsColReceived
+	readingsColSerial
+	readingsColMatchedIn
+)
+
+// dataTableSort is how a table of the Data page is sorted, by Origin until a header is tapped.
+// The direction of the default comes from the preferences
+func (p *dataPageHandler) dataTableSort(dataType string) services.TableSort {
+	if s, ok := p.appState.GetDataPageSort(dataType); ok {
+		return s
+	}
+	column := eventsColOrigin
+	if dataType == config.DataTypeReadings {
+		column = readingsColOrigin
+	}
+	return services.TableSort{
+		Column:    column,
+		Ascending: p.appState.GetConfig().GetEventsTableSortOrderAscending(),
+	}
+}
+
+// sortDataTableBy sorts by the column, tapping the same column again flips the order
+func (p *dataPageHandler) sortDataTableBy(dataType string, column int) {
+	s := p.dataTableSort(dataType)
+	if s.Column == column {
+		s.Ascending = !s.Ascending
+	} else {
+		s = services.TableSort{Column: column, Ascending: true}
+	}
+	p.appState.SetDataPageSort(dataType, s)
+	p.refreshTable()
+}
+
+// headerText is the header of the column, marked if the table is sorted by it
+func headerText(columns []dataColumn, column int, s services.TableSort) string {
+	if column == s.Column {
+		return columns[column].header + sortIndicator(s.Ascending)
+	}
+	return columns[column].header
+}
+
+func sortEvents(events []services.EventRecord, s services.TableSort) {
+	sort.SliceStable(events, func(i, j int) bool {
+		if s.Ascending {
+			return eventsLess(events[i], events[j], s.Column)
+		}
+		return eventsLess(events[j], events[i], s.Column)
+	})
+}
+
+func eventsLess(a, b services.EventRecord, column int) bool {
+	switch column {
+	case pinColumn:
+		return !a.Pinned && b.Pinned
+	case eventsColId:
+		return a.Id < b.Id
+	case eventsColDeviceName:
+		return lessFold(a.DeviceName, b.DeviceName)
+	case eventsColProfileName:
+		return lessFold(a.ProfileName, b.ProfileName)
+	case eventsColReadings:
+		return len(a.Readings) < len(b.Readings)
+	case eventsColTags:
+		return lessFold(tagsText(a.Tags), tagsText(b.Tags))
+	case eventsColCreated:
+		return a.Created < b.Created
+	case eventsColDuplicates:
+		return a.Copies < b.Copies
+	case eventsColNote:
+		return lessFold(a.Note, b.Note)
+	case eventsColLatency:
+		return lessLatency(a.Latency())(b.Latency())
+	case eventsColReceived:
+		return a.ReceivedAt < b.ReceivedAt
+	case eventsColSerial:
+		return a.Serial < b.Serial
+	case eventsColMatchedIn:
+		return services.FilterFieldNames(a.MatchedIn) < services.FilterFieldNames(b.MatchedIn)
+	}
+	return a.Origin < b.Origin
+}
+
+func sortReadings(readings []services.ReadingRecord, s services.TableSort, anomalies *services.AnomalyDetector) {
+	sort.SliceStable(readings, func(i, j int) bool {
+		if s.Ascending {
+			return readingsLess(readings[i], readings[j], s.Column, anomalies)
+		}
+		return readingsLess(readings[j], readings[i], s.Column, anomalies)
+	})
+}
+
+func readingsLess(a, b services.ReadingRecord, column int, anomalies *services.AnomalyDetector) bool {
+	switch column {
+	case pinColumn:
+		return !a.Pinned && b.Pinned
+	case readingsColId:
+		return a.Id < b.Id
+	case readingsColDeviceName:
+		return lessFold(a.DeviceName, b.DeviceName)
+	case readingsColResourceName:
+		return lessFold(a.ResourceName, b.ResourceName)
+	case readingsColProfileName:
+		return lessFold(a.ProfileName, b.ProfileName)
+	case readingsColValueType:
+		return a.ValueType < b.ValueType
+	case readingsColValue:
+		return lessValue(a.Value, b.Value)
+	case readingsColBinaryValue:
+		return len(a.BinaryValue) < len(b.BinaryValue)
+	case readingsColMediaType:
+		return a.MediaType < b.MediaType
+	case readingsColCreated:
+		return a.Created < b.Created
+	case readingsColNote:
+		return lessFold(a.Note, b.Note)
+	case readingsColLatency:
+		return lessLatency(a.Latency())(b.Latency())
+	case readingsColAnomaly:
+		return anomalyScore(anomalies, a.Id) < anomalyScore(anomalies, b.Id)
+	case readingsColEventId:
+		return a.EventId < b.EventId
+	case readingsColReceived:
+		return a.ReceivedAt < b.ReceivedAt
+	case readingsColSerial:
+		return a.Serial < b.Serial
+	case readingsColMatchedIn:
+		return services.FilterFieldNames(a.MatchedIn) < services.FilterFieldNames(b.MatchedIn)
+	}
+	return a.Origin < b.Origin
+}
+
+func lessFold(a, b string) bool {
+	return strings.ToLower(a) < strings.ToLower(b)
+}
+
+// lessValue compares numbers as numbers, anything else as text after them
+func lessValue(a, b string) bool {
+	na, errA := strconv.ParseFloat(a, 64)
+	nb, errB := strconv.ParseFloat(b, 64)
+	switch {
+	case errA == nil && errB == nil:
+		return na < nb
+	case errA == nil:
+		return true
+	case errB == nil:
+		return false
+	}
+	return lessFold(a, b)
+}
+
+// lessLatency puts the unknown latencies first
+func lessLatency(a time.Duration, okA bool) func(time.Duration, bool) bool {
+	return func(b time.Duration, okB bool) bool {
+		if !okA || !okB {
+			return !okA && okB
+		}
+		return a < b
+	}
+}
+
+// anomalyScore is how far the reading is from the mean, the readings that were not flagged come first
+func anomalyScore(anomalies *services.AnomalyDetector, readingId string) float64 {
+	anomaly, ok := anomalies.GetAnomaly(readingId)
+	if !ok {
+		return -1
+	}
+	return math.Abs(anomaly.ZScore)
+}
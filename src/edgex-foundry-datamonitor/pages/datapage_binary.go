@@ -0,0 +1,191 @@
+// Copyright 2021 Alessandro De Blasis <alex@deblasis.net>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+package pages
+
+import (
+	"bytes"
+	"encoding/hex"
+	"fmt"
+	"image"
+	"mime"
+	"strings"
+
+	// the formats the previews can decode
+	_ "image/gif"
+	_ "image/jpeg"
+	_ "image/png"
+
+	log "github.com/sirupsen/logrus"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/canvas"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/layout"
+	"fyne.io/fyne/v2/theme"
+	"fyne.io/fyne/v2/widget"
+)
+
+const (
+	detailTabBinary = "Binary"
+
+	// maxHexDumpBytes keeps the dump of large payloads, like camera frames, responsive
+	maxHexDumpBytes = 64 * 1024
+)
+
+// previewableMediaTypes are the binary readings shown as images
+var previewableMediaTypes = map[string]string{
+	"image/jpeg": ".jpg",
+	"image/png":  ".png",
+	"image/gif":  ".gif",
+}
+
+// binaryViewer is the Binary tab of the inspector
+type binaryViewer struct {
+	info    *widget.Label
+	saveBtn *widget.Button
+	preview *fyne.Container
+	hexDump *widget.Entry
+
+	readingId string
+	mediaType string
+	value     []byte
+}
+
+func (p *dataPageHandler) renderBinaryViewer(win fyne.Window) *container.TabItem {
+	b := &binaryViewer{
+		info:    widget.NewLabel(""),
+		preview: container.NewMax(),
+		hexDump: widget.NewMultiLineEntry(),
+	}
+	b.hexDump.TextStyle = fyne.TextStyle{Monospace: true}
+	b.saveBtn = widget.NewButtonWithIcon("Save to file", theme.DocumentSaveIcon(), func() {
+		saveBinary(b.readingId, b.mediaType, b.value, win)
+	})
+	p.detail.binary = b
+
+	return container.NewTabItem(detailTabBinary, container.NewBorder(
+		container.NewHBox(b.info, layout.NewSpacer(), b.saveBtn),
+		nil, nil, nil,
+		b.preview,
+	))
+}
+
+// showBinary loads the payload of the reading in the viewer
+func (b *binaryViewer) showBinary(readingId string, mediaType string, value []byte) {
+	b.readingId = readingId
+	b.mediaType = mediaType
+	b.value = value
+
+	info := fmt.Sprintf("%s of %s", bytesText(len(value)), mediaType)
+	if mediaType == "" {
+		info = fmt.Sprintf("%s of an unknown media type", bytesText(len(value)))
+	}
+	dump := hexDumpText(value)
+	if len(value) > maxHexDumpBytes {
+		info = info + fmt.Sprintf(", the first %s are dumped", bytesText(maxHexDumpBytes))
+	}
+	b.hexDump.SetText(dump)
+	dumpView := container.NewScroll(b.hexDump)
+
+	if _, ok := previewableMediaTypes[baseMediaType(mediaType)]; !ok {
+		b.info.SetText(info)
+		b.preview.Objects = []fyne.CanvasObject{dumpView}
+		b.preview.Refresh()
+		return
+	}
+
+	img, err := decodeImage(value)
+	if err != nil {
+		log.Debugf("cannot preview reading %v: %v", readingId, err)
+		b.info.SetText(info + fmt.Sprintf(", it cannot be previewed: %v", err))
+		b.preview.Objects = []fyne.CanvasObject{dumpView}
+		b.preview.Refresh()
+		return
+	}
+
+	size := img.Bounds().Size()
+	b.info.SetText(info + fmt.Sprintf(", %dx%d pixels", size.X, size.Y))
+	preview := canvas.NewImageFromImage(img)
+	preview.FillMode = canvas.ImageFillContain
+	split := container.NewVSplit(preview, dumpView)
+	split.Offset = 0.7
+	b.preview.Objects = []fyne.CanvasObject{split}
+	b.preview.Refresh()
+}
+
+func saveBinary(readingId string, mediaType string, value []byte, win fyne.Window) {
+	dlg := dialog.NewFileSave(func(writer fyne.URIWriteCloser, err error) {
+		if err != nil {
+			dialog.ShowError(err, win)
+			return
+		}
+		if writer == nil {
+			return
+		}
+		defer writer.Close()
+
+		if _, err := writer.Write(value); err != nil {
+			log.Errorf("cannot save reading %v: %v", readingId, err)
+			dialog.ShowError(fmt.Errorf("Cannot save the binary value\n%s", err), win)
+		}
+	}, win)
+	dlg.SetFileName(readingId + binaryExtension(mediaType))
+	dlg.Show()
+}
+
+// baseMediaType drops the parameters, e.g. "image/jpeg; q=0.9" is "image/jpeg"
+func baseMediaType(mediaType string) string {
+	base, _, err := mime.ParseMediaType(mediaType)
+	if err != nil {
+		return strings.ToLower(strings.TrimSpace(mediaType))
+	}
+	return base
+}
+
+func binaryExtension(mediaType string) string {
+	if ext, ok := previewableMediaTypes[baseMediaType(mediaType)]; ok {
+		return ext
+	}
+	return ".bin"
+}
+
+func decodeImage(value []byte) (image.Image, error) {
+	img, _, err := image.Decode(bytes.NewReader(value))
+	return img, err
+}
+
+// hexDumpText is the dump of the first maxHexDumpBytes of the value
+func hexDumpText(value []byte) string {
+	if len(value) > maxHexDumpBytes {
+		value = value[:maxHexDumpBytes]
+	}
+	return hex.Dump(value)
+}
+
+// bytesText reads like "512 bytes" or "12.3 KiB"
+func bytesText(n int) string {
+	const unit = 1024
+	if n < unit {
+		return fmt.Sprintf("%d bytes", n)
+	}
+	value, prefix := float64(n)/unit, "KMGT"
+	i := 0
+	for value >= unit && i < len(prefix)-1 {
+		value /= unit
+		i++
+	}
+	return fmt.Sprintf("%.1f %ciB", value, prefix[i])
+}
@@ -0,0 +1,406 @@
+// Copyright 2021 Alessandro De Blasis <alex@deblasis.net>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+package pages
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+
+	log "github.com/sirupsen/logrus"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/data/binding"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/theme"
+	"fyne.io/fyne/v2/widget"
+	"github.com/deblasis/edgex-foundry-datamonitor/config"
+	"github.com/edgexfoundry/go-mod-core-contracts/v2/dtos"
+)
+
+const (
+	// selectedMarker prefixes the first cell of the selected rows
+	selectedMarker = "> "
+	// changedMarker flags the lines of a diff whose sides differ
+	changedMarker = "*"
+)
+
+var diffHeaders = []string{"", "Field", "A", "B"}
+
+// rowSelection are the rows picked for comparison, in the order they were picked
+type rowSelection struct {
+	serials []int64
+	rows    map[int64]binding.DataMap
+}
+
+func newRowSelection() *rowSelection {
+	return &rowSelection{
+		rows: map[int64]binding.DataMap{},
+	}
+}
+
+func (s *rowSelection) toggle(row binding.DataMap) {
+	serial := getInt(row, "Serial")
+	if _, ok := s.rows[serial]; ok {
+		delete(s.rows, serial)
+		for i, selected := range s.serials {
+			if selected == serial {
+				s.serials = append(s.serials[:i], s.serials[i+1:]...)
+				break
+			}
+		}
+		return
+	}
+	s.rows[serial] = row
+	s.serials = append(s.serials, serial)
+}
+
+func (s *rowSelection) has(serial int64) bool {
+	_, ok := s.rows[serial]
+	return ok
+}
+
+// diffLine is a line of a diff, the ones without a name head a section
+type diffLine struct {
+	name  string
+	left  string
+	right string
+}
+
+func (l diffLine) changed() bool {
+	return l.name != "" && l.left != l.right
+}
+
+// selectionOf must be called holding tableDataLock
+func (p *dataPageHandler) selectionOf(dataType string) *rowSelection {
+	if dataType == config.DataTypeReadings {
+		return p.readingsSelection
+	}
+	return p.eventsSelection
+}
+
+// toggleSelected adds the row to the rows to compare or takes it out
+func (p *dataPageHandler) toggleSelected(dataType string, row binding.DataMap) {
+	p.tableDataLock.Lock()
+	p.selectionOf(dataType).toggle(row)
+	p.tableDataLock.Unlock()
+
+	p.updateSelectControls()
+	if dataType == config.DataTypeReadings {
+		p.readingsTable.Refresh()
+	} else {
+		p.eventsTable.Refresh()
+	}
+}
+
+func (p *dataPageHandler) setSelectMode(on bool) {
+	p.selectMode = on
+	if !on {
+		p.tableDataLock.Lock()
+		p.eventsSelection = newRowSelection()
+		p.readingsSelection = newRowSelection()
+		p.tableDataLock.Unlock()
+		p.eventsTable.Refresh()
+		p.readingsTable.Refresh()
+	}
+	p.updateSelectControls()
+}
+
+func (p *dataPageHandler) updateSelectControls() {
+	if !p.selectMode {
+		p.selectBtn.SetText("Select")
+		p.selectBtn.SetIcon(theme.CheckButtonCheckedIcon())
+		p.compareBtn.Hide()
+		return
+	}
+
+	p.tableDataLock.RLock()
+	selected := len(p.selectionOf(p.dataType.Selected).serials)
+	p.tableDataLock.RUnlock()
+
+	p.selectBtn.SetText(fmt.Sprintf("Done selecting (%d)", selected))
+	p.selectBtn.SetIcon(theme.CancelIcon())
+	p.compareBtn.Show()
+	if selected == 2 {
+		p.compareBtn.Enable()
+	} else {
+		p.compareBtn.Disable()
+	}
+}
+
+// showCompare opens the diff of the two selected rows of the data type
+func (p *dataPageHandler) showCompare(win fyne.Window, dataType string) {
+	p.tableDataLock.RLock()
+	selection := p.selectionOf(dataType)
+	if len(selection.serials) != 2 {
+		p.tableDataLock.RUnlock()
+		return
+	}
+	a, b := selection.rows[selection.serials[0]], selection.rows[selection.serials[1]]
+	p.tableDataLock.RUnlock()
+
+	var lines []diffLine
+	var title string
+	switch dataType {
+	case config.DataTypeEvents:
+		lines = eventsDiff(a, b)
+		title = "Compare events"
+	default:
+		lines = p.readingsDiff(a, b)
+		title = "Compare readings"
+	}
+
+	shown := lines
+	table := widget.NewTable(
+		func() (int, int) {
+			return len(shown) + 1, len(diffHeaders)
+		},
+		func() fyne.CanvasObject {
+			return widget.NewLabel("---fdaec17c-c0fc-4a04-982e---")
+		},
+		func(i widget.TableCellID, o fyne.CanvasObject) {
+			label := o.(*widget.Label)
+			if i.Row == 0 {
+				label.TextStyle = fyne.TextStyle{Bold: true}
+				label.SetText(diffHeaders[i.Col])
+				return
+			}
+			if i.Row > len(shown) {
+				label.SetText("")
+				return
+			}
+			line := shown[i.Row-1]
+			if line.name == "" {
+				label.TextStyle = fyne.TextStyle{Bold: true, Italic: true}
+				if i.Col == 1 {
+					label.SetText(line.left)
+				} else {
+					label.SetText("")
+				}
+				return
+			}
+			// changes stand out
+			label.TextStyle = fyne.TextStyle{Bold: line.changed()}
+			switch i.Col {
+			case 0:
+				if line.changed() {
+					label.SetText(changedMarker)
+				} else {
+					label.SetText("")
+				}
+			case 1:
+				label.SetText(line.name)
+			case 2:
+				label.SetText(line.left)
+			case 3:
+				label.SetText(line.right)
+			}
+		},
+	)
+	table.SetColumnWidth(0, 30)
+	table.SetColumnWidth(1, 200)
+	table.SetColumnWidth(2, 380)
+	table.SetColumnWidth(3, 380)
+
+	changes := 0
+	for _, l := range lines {
+		if l.changed() {
+			changes++
+		}
+	}
+	onlyChanges := widget.NewCheck("Only differences", func(only bool) {
+		shown = lines
+		if only {
+			shown = changedLines(lines)
+		}
+		table.Refresh()
+	})
+
+	content := container.NewBorder(
+		container.NewHBox(
+			widget.NewLabelWithStyle(fmt.Sprintf("%d differences, marked with %s", changes, changedMarker), fyne.TextAlignLeading, fyne.TextStyle{Bold: true}),
+			onlyChanges,
+		),
+		nil, nil, nil,
+		table,
+	)
+	dlg := dialog.NewCustom(title, "Close", content, win)
+	dlg.Resize(fyne.NewSize(1100, 800))
+	dlg.Show()
+}
+
+// changedLines keeps the changes along with the heads of their sections
+func changedLines(lines []diffLine) []diffLine {
+	changed := make([]diffLine, 0, len(lines))
+	var section *diffLine
+	for i, l := range lines {
+		if l.name == "" {
+			section = &lines[i]
+			continue
+		}
+		if !l.changed() {
+			continue
+		}
+		if section != nil {
+			changed = append(changed, *section)
+			section = nil
+		}
+		changed = append(changed, l)
+	}
+	return changed
+}
+
+func eventsDiff(a, b binding.DataMap) []diffLine {
+	eventA, eventB := decodeEvent(a), decodeEvent(b)
+
+	lines := []diffLine{{left: "Fields"}}
+	lines = append(lines, fieldsDiff(eventDetailFields(a), eventDetailFields(b))...)
+	lines = append(lines, diffLine{left: "Tags"})
+	lines = append(lines, tagsDiff(eventA.Tags, eventB.Tags)...)
+	return append(lines, eventReadingsDiff(eventA.Readings, eventB.Readings)...)
+}
+
+func (p *dataPageHandler) readingsDiff(a, b binding.DataMap) []diffLine {
+	lines := []diffLine{{left: "Fields"}}
+	lines = append(lines, fieldsDiff(readingDetailFields(a), readingDetailFields(b))...)
+	// readings carry no tags, the ones of their event apply
+	lines = append(lines, diffLine{left: "Tags of the parent events"})
+	return append(lines, tagsDiff(p.parentTags(a), p.parentTags(b))...)
+}
+
+func (p *dataPageHandler) parentTags(reading binding.DataMap) map[string]string {
+	event, ok := p.appState.GetDB().GetEventBySerial(getInt(reading, "EventSerial"))
+	if !ok {
+		return nil
+	}
+	return event.Tags
+}
+
+func decodeEvent(row binding.DataMap) dtos.Event {
+	var event dtos.Event
+	if err := json.Unmarshal([]byte(getString(row, "Json")), &event); err != nil {
+		log.Warnf("cannot decode event %v: %v", getString(row, "Id"), err)
+	}
+	return event
+}
+
+// fieldsDiff pairs the fields by name, both sides list the same ones
+func fieldsDiff(a, b []detailField) []diffLine {
+	right := map[string]string{}
+	for _, f := range b {
+		right[f.name] = f.value
+	}
+	lines := make([]diffLine, 0, len(a))
+	for _, f := range a {
+		lines = append(lines, diffLine{name: f.name, left: f.value, right: right[f.name]})
+	}
+	return lines
+}
+
+func tagsDiff(a, b map[string]string) []diffLine {
+	keys := map[string]bool{}
+	for k := range a {
+		keys[k] = true
+	}
+	for k := range b {
+		keys[k] = true
+	}
+	sorted := make([]string, 0, len(keys))
+	for k := range keys {
+		sorted = append(sorted, k)
+	}
+	sort.Strings(sorted)
+
+	lines := make([]diffLine, 0, len(sorted))
+	for _, k := range sorted {
+		lines = append(lines, diffLine{name: k, left: tagValueText(a, k), right: tagValueText(b, k)})
+	}
+	return lines
+}
+
+func tagValueText(tags map[string]string, key string) string {
+	v, ok := tags[key]
+	if !ok {
+		return "(missing)"
+	}
+	return v
+}
+
+// eventReadingsDiff pairs the readings by resource, in the order they appear when a resource is repeated
+func eventReadingsDiff(a, b []dtos.BaseReading) []diffLine {
+	keysA, keysB := readingKeys(a), readingKeys(b)
+	byKeyB := map[string]dtos.BaseReading{}
+	for i, r := range b {
+		byKeyB[keysB[i]] = r
+	}
+
+	lines := make([]diffLine, 0)
+	paired := map[string]bool{}
+	for i, r := range a {
+		r := r
+		other, ok := byKeyB[keysA[i]]
+		paired[keysA[i]] = true
+		lines = append(lines, readingDiff(keysA[i], &r, otherReading(other, ok))...)
+	}
+	for i, r := range b {
+		if paired[keysB[i]] {
+			continue
+		}
+		r := r
+		lines = append(lines, readingDiff(keysB[i], nil, &r)...)
+	}
+	return lines
+}
+
+func otherReading(r dtos.BaseReading, ok bool) *dtos.BaseReading {
+	if !ok {
+		return nil
+	}
+	return &r
+}
+
+// readingKeys names the readings by resource, "res", "res #2" and so on for the repeated ones
+func readingKeys(readings []dtos.BaseReading) []string {
+	keys := make([]string, len(readings))
+	seen := map[string]int{}
+	for i, r := range readings {
+		seen[r.ResourceName]++
+		keys[i] = r.ResourceName
+		if n := seen[r.ResourceName]; n > 1 {
+			keys[i] = fmt.Sprintf("%s #%d", r.ResourceName, n)
+		}
+	}
+	return keys
+}
+
+func readingDiff(key string, a, b *dtos.BaseReading) []diffLine {
+	side := func(r *dtos.BaseReading, value func(dtos.BaseReading) string) string {
+		if r == nil {
+			return "(missing)"
+		}
+		return value(*r)
+	}
+	valueType := func(r dtos.BaseReading) string { return r.ValueType }
+	value := readingValueText
+	origin := func(r dtos.BaseReading) string { return humanTimestamp(r.Origin) }
+
+	return []diffLine{
+		{left: fmt.Sprintf("Reading %s", key)},
+		{name: "Value Type", left: side(a, valueType), right: side(b, valueType)},
+		{name: "Value", left: side(a, value), right: side(b, value)},
+		{name: "Origin", left: side(a, origin), right: side(b, origin)},
+	}
+}
@@ -0,0 +1,477 @@
+// Copyright 2021 Alessandro De Blasis <alex@deblasis.net>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+package pages
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/data/binding"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/layout"
+	"fyne.io/fyne/v2/theme"
+	"fyne.io/fyne/v2/widget"
+	"github.com/deblasis/edgex-foundry-datamonitor/config"
+	"github.com/edgexfoundry/go-mod-core-contracts/v2/dtos"
+)
+
+const (
+	detailTabFields   = "Fields"
+	detailTabTags     = "Tags"
+	detailTabReadings = "Readings"
+	detailTabJson     = "JSON"
+)
+
+var detailReadingsHeaders = []string{"Resource Name", "Value Type", "Value", "Origin"}
+
+var errRowEvicted = errors.New("The item has been evicted from the buffer and can no longer be pinned or unpinned")
+
+// detailItem identifies what is shown in the detail dialog
+type detailItem struct {
+	dataType string
+	serial   int64
+	pinned   bool
+	// eventId is the parent event of a reading, eventSerial the copy of it the reading came with
+	eventId     string
+	eventSerial int64
+}
+
+// detailField is a line of the Fields tab of the inspector
+type detailField struct {
+	name  string
+	value string
+}
+
+// detailInspector shows an event or a reading in a dialog,
+// previous and next step through the rows it was opened from
+type detailInspector struct {
+	dlg      dialog.Dialog
+	title    *widget.Label
+	position *widget.Label
+	prevBtn  *widget.Button
+	nextBtn  *widget.Button
+
+	pinBtn    *widget.Button
+	parentBtn *widget.Button
+	note      *widget.Entry
+	noteBox   *fyne.Container
+
+	tabs          *container.AppTabs
+	fieldsTab     *container.TabItem
+	tagsTab       *container.TabItem
+	readingsTab   *container.TabItem
+	binaryTab     *container.TabItem
+	jsonTab       *container.TabItem
+	fields        *fyne.Container
+	tagsTable     *widget.Table
+	tagsEmpty     *widget.Label
+	readingsTable *widget.Table
+	json          *widget.Entry
+	binary        *binaryViewer
+
+	// rows are the result set the inspector steps through, index is the one shown
+	rows  []binding.DataMap
+	index int
+	item  detailItem
+
+	tags     [][2]string
+	readings []dtos.BaseReading
+}
+
+func (p *dataPageHandler) renderDetailInspector(win fyne.Window) {
+	d := &detailInspector{
+		title:     widget.NewLabelWithStyle("", fyne.TextAlignLeading, fyne.TextStyle{Bold: true}),
+		position:  widget.NewLabel(""),
+		fields:    container.New(layout.NewFormLayout()),
+		tagsEmpty: widget.NewLabelWithStyle("", fyne.TextAlignCenter, fyne.TextStyle{Italic: true}),
+		json:      widget.NewMultiLineEntry(),
+	}
+	p.detail = d
+
+	d.prevBtn = widget.NewButtonWithIcon("", theme.NavigateBackIcon(), func() {
+		p.stepDetail(-1)
+	})
+	d.nextBtn = widget.NewButtonWithIcon("", theme.NavigateNextIcon(), func() {
+		p.stepDetail(1)
+	})
+
+	d.pinBtn = widget.NewButtonWithIcon("Pin", theme.ContentAddIcon(), func() {
+		if !p.togglePin(d.item.dataType, d.item.serial, d.item.pinned) {
+			dialog.ShowError(errRowEvicted, win)
+			return
+		}
+		d.item.pinned = !d.item.pinned
+		// the row is shown again when stepping back to it
+		if item, err := d.rows[d.index].GetItem("Pinned"); err == nil {
+			item.(binding.Bool).Set(d.item.pinned)
+		}
+		p.updateDetailPinControls()
+	})
+	d.parentBtn = widget.NewButtonWithIcon("Parent event", theme.MoveUpIcon(), func() {
+		if p.openParentEvent(d.item.eventId, d.item.eventSerial, win) {
+			d.dlg.Hide()
+		}
+	})
+
+	d.note = widget.NewMultiLineEntry()
+	d.note.SetPlaceHolder("Notes about this pinned item")
+	d.note.Wrapping = fyne.TextWrapWord
+	saveNoteBtn := widget.NewButtonWithIcon("Save note", theme.DocumentSaveIcon(), func() {
+		p.saveNote(d.item.dataType, d.item.serial, d.note.Text)
+		if item, err := d.rows[d.index].GetItem("Note"); err == nil {
+			item.(binding.String).Set(d.note.Text)
+		}
+	})
+	d.noteBox = container.NewBorder(
+		widget.NewLabelWithStyle("Note", fyne.TextAlignLeading, fyne.TextStyle{Bold: true}),
+		nil, nil, saveNoteBtn,
+		d.note,
+	)
+
+	d.tagsTable = widget.NewTable(
+		func() (int, int) {
+			return len(d.tags) + 1, 2
+		},
+		func() fyne.CanvasObject {
+			return widget.NewLabel("---fdaec17c-c0fc-4a04-982e---")
+		},
+		func(i widget.TableCellID, o fyne.CanvasObject) {
+			label := o.(*widget.Label)
+			if i.Row == 0 {
+				label.TextStyle = fyne.TextStyle{Bold: true}
+				label.SetText([]string{"Tag", "Value"}[i.Col])
+				return
+			}
+			label.TextStyle = fyne.TextStyle{}
+			if i.Row > len(d.tags) {
+				label.SetText("")
+				return
+			}
+			label.SetText(d.tags[i.Row-1][i.Col])
+		},
+	)
+	d.tagsTable.SetColumnWidth(1, 400)
+
+	d.readingsTable = widget.NewTable(
+		func() (int, int) {
+			return len(d.readings) + 1, len(detailReadingsHeaders)
+		},
+		func() fyne.CanvasObject {
+			return widget.NewLabel("---fdaec17c-c0fc-4a04-982e---")
+		},
+		func(i widget.TableCellID, o fyne.CanvasObject) {
+			label := o.(*widget.Label)
+			if i.Row == 0 {
+				label.TextStyle = fyne.TextStyle{Bold: true}
+				label.SetText(detailReadingsHeaders[i.Col])
+				return
+			}
+			label.TextStyle = fyne.TextStyle{}
+			if i.Row > len(d.readings) {
+				label.SetText("")
+				return
+			}
+			r := d.readings[i.Row-1]
+			switch i.Col {
+			case 0:
+				label.SetText(r.ResourceName)
+			case 1:
+				label.SetText(r.ValueType)
+			case 2:
+				label.SetText(readingValueText(r))
+			case 3:
+				label.SetText(timestampText(r.Origin))
+			}
+		},
+	)
+	d.readingsTable.SetColumnWidth(3, wideColumnWidth)
+	d.readingsTable.OnSelected = func(id widget.TableCellID) {
+		defer d.readingsTable.UnselectAll()
+		if id.Row == 0 || id.Row > len(d.readings) {
+			return
+		}
+		p.inspectReadingOfEvent(d.item.eventSerial, d.readings[id.Row-1].Id, win)
+	}
+
+	copyToClipboardBtn := widget.NewButtonWithIcon("Copy to clipboard", theme.ContentCopyIcon(), func() {
+		fyne.Clipboard.SetContent(win.Clipboard(), d.json.Text)
+	})
+
+	d.fieldsTab = container.NewTabItem(detailTabFields, container.NewVScroll(d.fields))
+	d.tagsTab = container.NewTabItem(detailTabTags, container.NewMax(d.tagsTable, container.NewCenter(d.tagsEmpty)))
+	d.readingsTab = container.NewTabItem(detailTabReadings, d.readingsTable)
+	d.binaryTab = p.renderBinaryViewer(win)
+	d.jsonTab = container.NewTabItem(detailTabJson, container.NewBorder(
+		nil, container.NewHBox(layout.NewSpacer(), copyToClipboardBtn), nil, nil,
+		container.NewVScroll(container.NewMax(d.json)),
+	))
+	d.tabs = container.NewAppTabs(d.fieldsTab, d.tagsTab, d.readingsTab, d.jsonTab)
+
+	content := container.NewBorder(
+		container.NewBorder(nil, nil,
+			container.NewHBox(d.prevBtn, d.nextBtn, d.position),
+			container.NewHBox(d.parentBtn, d.pinBtn),
+			d.title,
+		),
+		d.noteBox, nil, nil,
+		d.tabs,
+	)
+	d.dlg = dialog.NewCustom("Detail", "Close", content, win)
+	d.dlg.Resize(fyne.NewSize(900, 900))
+}
+
+// inspect opens the inspector on rows[index], rows being the result set the user is looking at
+func (p *dataPageHandler) inspect(dataType string, rows []binding.DataMap, index int) {
+	if index < 0 || index >= len(rows) {
+		return
+	}
+	d := p.detail
+	d.rows = rows
+	d.index = index
+	d.item.dataType = dataType
+	p.showDetailRow()
+	d.dlg.Show()
+}
+
+// inspectRow opens the inspector on the row, stepping through the result set it belongs to if it's still there
+func (p *dataPageHandler) inspectRow(dataType string, rows []binding.DataMap, row binding.DataMap) {
+	serial := getInt(row, "Serial")
+	for i, r := range rows {
+		if getInt(r, "Serial") == serial {
+			p.inspect(dataType, rows, i)
+			return
+		}
+	}
+	p.inspect(dataType, []binding.DataMap{row}, 0)
+}
+
+// inspectReadingOfEvent moves the inspector to a reading of the event, stepping through its siblings
+func (p *dataPageHandler) inspectReadingOfEvent(eventSerial int64, readingId string, win fyne.Window) {
+	readings := p.appState.GetDB().GetReadingsByEventSerial(eventSerial)
+	rows := make([]binding.DataMap, 0, len(readings))
+	index := -1
+	for _, reading := range readings {
+		r := newReadingRow(reading)
+		if reading.Id == readingId {
+			index = len(rows)
+		}
+		rows = append(rows, binding.BindStruct(&r))
+	}
+	if index < 0 {
+		log.Debugf("reading %v is no longer buffered", readingId)
+		dialog.ShowInformation("Reading", fmt.Sprintf("The reading %v is no longer buffered", readingId), win)
+		return
+	}
+	p.inspect(config.DataTypeReadings, rows, index)
+}
+
+func (p *dataPageHandler) stepDetail(delta int) {
+	d := p.detail
+	index := d.index + delta
+	if index < 0 || index >= len(d.rows) {
+		return
+	}
+	d.index = index
+	p.showDetailRow()
+}
+
+// showDetailRow fills the inspector with the current row
+func (p *dataPageHandler) showDetailRow() {
+	d := p.detail
+	row := d.rows[d.index]
+	dataType := d.item.dataType
+
+	d.item = detailItem{
+		dataType:    dataType,
+		serial:      getInt(row, "Serial"),
+		pinned:      getBool(row, "Pinned"),
+		eventId:     getString(row, "EventId"),
+		eventSerial: getInt(row, "EventSerial"),
+	}
+
+	d.position.SetText(fmt.Sprintf("%d of %d", d.index+1, len(d.rows)))
+	if d.index > 0 {
+		d.prevBtn.Enable()
+	} else {
+		d.prevBtn.Disable()
+	}
+	if d.index < len(d.rows)-1 {
+		d.nextBtn.Enable()
+	} else {
+		d.nextBtn.Disable()
+	}
+
+	var fields []detailField
+	tabs := []*container.TabItem{d.fieldsTab, d.tagsTab}
+	switch dataType {
+	case config.DataTypeEvents:
+		var event dtos.Event
+		if err := json.Unmarshal([]byte(getString(row, "Json")), &event); err != nil {
+			log.Warnf("cannot decode event %v: %v", getString(row, "Id"), err)
+		}
+		d.item.eventId = event.Id
+		d.item.eventSerial = d.item.serial
+		d.title.SetText(fmt.Sprintf("Event from %v", getString(row, "DeviceName")))
+		fields = eventDetailFields(row)
+		d.tags = sortedTags(event.Tags)
+		d.tagsEmpty.SetText("The event has no tags")
+		d.readings = event.Readings
+		d.readingsTab.Text = fmt.Sprintf("%s (%d)", detailTabReadings, len(event.Readings))
+		d.readingsTable.Refresh()
+		tabs = append(tabs, d.readingsTab)
+		d.parentBtn.Hide()
+	default:
+		d.title.SetText(fmt.Sprintf("Reading %v of %v", getString(row, "ResourceName"), getString(row, "DeviceName")))
+		fields = readingDetailFields(row)
+		// readings carry no tags, the ones of their event apply
+		d.tags = nil
+		d.tagsEmpty.SetText("The parent event is no longer buffered")
+		if event, ok := p.appState.GetDB().GetEventBySerial(d.item.eventSerial); ok {
+			d.tags = sortedTags(event.Tags)
+			d.tagsEmpty.SetText("The parent event has no tags")
+		}
+		d.readings = nil
+		if binaryValue := getString(row, "BinaryValue"); binaryValue != "" {
+			d.binary.showBinary(getString(row, "Id"), getString(row, "MediaType"), []byte(binaryValue))
+			tabs = append(tabs, d.binaryTab)
+		}
+		d.parentBtn.Show()
+	}
+	tabs = append(tabs, d.jsonTab)
+
+	d.fields.Objects = nil
+	for _, f := range fields {
+		value := widget.NewLabel(f.value)
+		value.Wrapping = fyne.TextWrapWord
+		d.fields.Add(widget.NewLabelWithStyle(f.name, fyne.TextAlignTrailing, fyne.TextStyle{Bold: true}))
+		d.fields.Add(value)
+	}
+	d.fields.Refresh()
+
+	if len(d.tags) == 0 {
+		d.tagsTable.Hide()
+		d.tagsEmpty.Show()
+	} else {
+		d.tagsEmpty.Hide()
+		d.tagsTable.Show()
+		d.tagsTable.Refresh()
+	}
+
+	selected := d.tabs.Selected()
+	d.tabs.SetItems(tabs)
+	d.tabs.SelectTabIndex(0)
+	for _, tab := range tabs {
+		if tab == selected {
+			d.tabs.SelectTab(tab)
+		}
+	}
+
+	d.json.SetText(getString(row, "Json"))
+	d.note.SetText(getString(row, "Note"))
+	p.updateDetailPinControls()
+}
+
+func (p *dataPageHandler) updateDetailPinControls() {
+	d := p.detail
+	if d.item.pinned {
+		d.pinBtn.SetText("Unpin")
+		d.pinBtn.SetIcon(theme.ContentRemoveIcon())
+		d.noteBox.Show()
+	} else {
+		d.pinBtn.SetText("Pin")
+		d.pinBtn.SetIcon(theme.ContentAddIcon())
+		d.noteBox.Hide()
+	}
+}
+
+func eventDetailFields(row binding.DataMap) []detailField {
+	return []detailField{
+		{"Id", getString(row, "Id")},
+		{"Device Name", getString(row, "DeviceName")},
+		{"Profile Name", getString(row, "ProfileName")},
+		{"Origin", humanTimestamp(getInt(row, "Origin"))},
+		{"Created", humanTimestamp(getInt(row, "Created"))},
+		{"Received", humanTimestamp(getInt(row, "ReceivedAt"))},
+		{"Latency", getString(row, "Latency")},
+		{"Readings", fmt.Sprintf("%d", getInt(row, "ReadingsCount"))},
+		{"Received copies", fmt.Sprintf("%d", getInt(row, "Copies"))},
+		{"Serial", fmt.Sprintf("%d", getInt(row, "Serial"))},
+	}
+}
+
+func readingDetailFields(row binding.DataMap) []detailField {
+	fields := []detailField{
+		{"Id", getString(row, "Id")},
+		{"Event Id", getString(row, "EventId")},
+		{"Device Name", getString(row, "DeviceName")},
+		{"Resource Name", getString(row, "ResourceName")},
+		{"Profile Name", getString(row, "ProfileName")},
+		{"Value Type", getString(row, "ValueType")},
+	}
+	if binaryValue := getString(row, "BinaryValue"); binaryValue != "" {
+		fields = append(fields,
+			detailField{"Media Type", getString(row, "MediaType")},
+			detailField{"Binary Value", bytesText(len(binaryValue))},
+		)
+	} else {
+		fields = append(fields, detailField{"Value", getString(row, "Value")})
+	}
+	return append(fields,
+		detailField{"Origin", humanTimestamp(getInt(row, "Origin"))},
+		detailField{"Created", humanTimestamp(getInt(row, "Created"))},
+		detailField{"Received", humanTimestamp(getInt(row, "ReceivedAt"))},
+		detailField{"Latency", getString(row, "Latency")},
+		detailField{"Anomaly", getString(row, "Anomaly")},
+		detailField{"Serial", fmt.Sprintf("%d", getInt(row, "Serial"))},
+	)
+}
+
+// humanTimestamp reads like "2021-10-19 10:04:05.123 CEST (3s ago)", empty when the timestamp is unknown
+func humanTimestamp(nanos int64) string {
+	if nanos == 0 {
+		return ""
+	}
+	t := time.Unix(0, nanos)
+	ago := time.Since(t).Truncate(time.Second)
+	if ago < 0 {
+		return fmt.Sprintf("%v (in %v)", t.Format("2006-01-02 15:04:05.000 MST"), -ago)
+	}
+	return fmt.Sprintf("%v (%v ago)", t.Format("2006-01-02 15:04:05.000 MST"), ago)
+}
+
+func readingValueText(r dtos.BaseReading) string {
+	if len(r.BinaryValue) > 0 {
+		return fmt.Sprintf("%s of %v", bytesText(len(r.BinaryValue)), r.MediaType)
+	}
+	return r.Value
+}
+
+func sortedTags(tags map[string]string) [][2]string {
+	sorted := make([][2]string, 0, len(tags))
+	for k, v := range tags {
+		sorted = append(sorted, [2]string{k, v})
+	}
+	sort.Slice(sorted, func(i, j int) bool {
+		return sorted[i][0] < sorted[j][0]
+	})
+	return sorted
+}
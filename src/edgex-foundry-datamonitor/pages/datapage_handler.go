@@ -17,7 +17,6 @@ package pages
 import (
 	"encoding/json"
 	"fmt"
-	"sort"
 	"strconv"
 	"strings"
 	"sync"
@@ -42,12 +41,28 @@ type dataPageHandler struct {
 	appState *services.AppManager
 	Key      widget.TreeNodeID
 
-	sortAsc bool
+	// the sorting of the rows in the tables, guarded by tableDataLock
+	eventsSort   services.TableSort
+	readingsSort services.TableSort
+	// the columns shown in the tables, guarded by tableDataLock
+	eventsColumns   []shownColumn
+	readingsColumns []shownColumn
+	// the rows picked for comparison, guarded by tableDataLock
+	eventsSelection   *rowSelection
+	readingsSelection *rowSelection
+	// the filter the rows shown were matched with, guarded by tableDataLock
+	matchFilter string
+	// selectMode makes tapping a row pick it instead of opening it
+	selectMode bool
 
 	dataType           *widget.RadioGroup
 	search             *widget.Entry
 	searchBtn          *widget.Button
 	resetSearchBtn     *widget.Button
+	historyBtn         *widget.Button
+	fieldsBtn          *widget.Button
+	receivedWithin     *widget.Select
+	savedSearchesBtn   *widget.Button
 	applyBufferSizeBtn *widget.Button
 	bufferSize         *widget.Entry
 	bufferSizeBinding  binding.Int
@@ -55,6 +70,13 @@ type dataPageHandler struct {
 
 	statusText *widget.Label
 
+	freezeBtn     *widget.Button
+	jumpToLiveBtn *widget.Button
+	frozenText    *widget.Label
+	columnsBtn    *widget.Button
+	selectBtn     *widget.Button
+	compareBtn    *widget.Button
+
 	bufferProgress *widget.ProgressBar
 	tableHeading   *fyne.Container
 
@@ -63,13 +85,24 @@ type dataPageHandler struct {
 	readingsTable *widget.Table
 	tableViewLock sync.RWMutex
 
+	// eventsView splits the events table from the readings of the selected event
+	eventsView *container.Split
+	linked     *linkedReadingsPanel
+
 	tableContainer              *fyne.Container
 	eventsTableDataMapBinding   *[]binding.DataMap
 	readingsTableDataMapBinding *[]binding.DataMap
 
 	tableDataLock sync.RWMutex
 
-	jsonDetail *widget.Entry
+	// detail inspects the tapped event or reading
+	detail *detailInspector
+
+	pinnedOnly    *widget.Check
+	anomaliesOnly *widget.Check
+	// deviceFilter shows the device the data is restricted to, tapping it lifts the restriction
+	deviceFilter *widget.Button
+	exportBtn    *widget.Button
 }
 
 func NewDataPageHandler(appState *services.AppManager) *dataPageHandler {
@@ -91,6 +124,10 @@ func NewDataPageHandler(appState *services.AppManager) *dataPageHandler {
 
 	p.searchBtn = widget.NewButtonWithIcon("Search", theme.SearchIcon(), func() {})
 	p.resetSearchBtn = widget.NewButtonWithIcon("", theme.DeleteIcon(), func() {})
+	p.historyBtn = widget.NewButtonWithIcon("", theme.HistoryIcon(), func() {})
+	p.savedSearchesBtn = widget.NewButtonWithIcon("Saved", theme.StorageIcon(), func() {})
+	p.fieldsBtn = widget.NewButtonWithIcon("All fields", theme.ListIcon(), func() {})
+	p.receivedWithin = widget.NewSelect(windowOptions(), func(string) {})
 
 	p.bufferSizeBinding = binding.NewInt()
 	p.bufferSize = widget.NewEntryWithData(binding.IntToString(p.bufferSizeBinding))
@@ -101,6 +138,24 @@ func NewDataPageHandler(appState *services.AppManager) *dataPageHandler {
 	p.bufferSize.Validator = data.MinMaxValidator(config.MinBufferSize, config.MaxBufferSize, data.ErrInvalidBufferSize)
 	p.applyBufferSizeBtn = widget.NewButtonWithIcon("", theme.DocumentSaveIcon(), func() {})
 
+	p.freezeBtn = widget.NewButtonWithIcon("Freeze", theme.MediaPauseIcon(), func() {})
+	p.jumpToLiveBtn = widget.NewButtonWithIcon("Jump to live", theme.MediaPlayIcon(), func() {})
+	p.jumpToLiveBtn.Importance = widget.HighImportance
+	p.frozenText = widget.NewLabelWithStyle("", fyne.TextAlignTrailing, fyne.TextStyle{Italic: true})
+	p.columnsBtn = widget.NewButtonWithIcon("Columns", theme.ListIcon(), func() {})
+	p.selectBtn = widget.NewButtonWithIcon("Select", theme.CheckButtonCheckedIcon(), func() {})
+	p.compareBtn = widget.NewButtonWithIcon("Compare", theme.ViewRestoreIcon(), func() {})
+	p.compareBtn.Importance = widget.HighImportance
+	p.compareBtn.Hide()
+	p.eventsSelection = newRowSelection()
+	p.readingsSelection = newRowSelection()
+
+	p.pinnedOnly = widget.NewCheck("Pinned only", func(bool) {})
+	p.anomaliesOnly = widget.NewCheck("Anomalies only", func(bool) {})
+	p.deviceFilter = widget.NewButtonWithIcon("", theme.CancelIcon(), func() {})
+	p.deviceFilter.Hide()
+	p.exportBtn = widget.NewButtonWithIcon("Export session", theme.DownloadIcon(), func() {})
+
 	p.bufferProgress = widget.NewProgressBar()
 
 	p.bufferProgress.TextFormatter = func() string {
@@ -129,61 +184,105 @@ func (p *dataPageHandler) SetInitialState() {
 	p.eventsTableDataMapBinding = &[]binding.DataMap{}
 	p.readingsTableDataMapBinding = &[]binding.DataMap{}
 
+	p.loadColumnLayouts()
 	p.eventsTable = p.renderEventsTable()
 	p.readingsTable = p.renderReadingsTable()
 
-	p.setTableByDataType(p.dataType.Selected, false)
-
-	//Json detail dialog
-	p.jsonDetail = widget.NewEntry()
-	p.jsonDetail.Disabled()
+	p.renderLinkedReadingsPanel()
+	p.eventsView = container.NewVSplit(p.eventsTable, p.linked.content)
+	p.eventsView.Offset = 0.65
 
-	win := fyne.CurrentApp().Driver().AllWindows()[0]
+	p.setTableByDataType(p.dataType.Selected, false)
 
-	copyToClipboardBtn := widget.NewButtonWithIcon("Copy to clipboard", theme.ContentCopyIcon(), func() {
-		fyne.Clipboard.SetContent(win.Clipboard(), p.jsonDetail.Text)
-	})
-
-	detailBox := container.NewBorder(
-		container.NewBorder(nil, nil, nil, copyToClipboardBtn, widget.NewLabelWithStyle("Selected item", fyne.TextAlignLeading, fyne.TextStyle{Bold: true})),
-		nil, nil, nil,
-		container.NewVScroll(container.NewMax(p.jsonDetail)),
-	)
-	dlg := dialog.NewCustom("Detail", "Close", detailBox, win)
-	dlg.Resize(fyne.NewSize(800, 1000))
+	p.renderDetailInspector(fyne.CurrentApp().Driver().AllWindows()[0])
 
 	p.eventsTable.OnSelected = func(id widget.TableCellID) {
+		defer p.eventsTable.UnselectAll()
+		column := p.dataColumnAt(config.DataTypeEvents, id.Col)
 		if id.Row == 0 {
+			p.sortDataTableBy(config.DataTypeEvents, column)
 			return
 		}
 
 		p.tableViewLock.RLock()
 		defer p.tableViewLock.RUnlock()
 		dm := *p.eventsTableDataMapBinding
-		js, _ := dm[id.Row-1].GetItem("Json")
+		if id.Row > len(dm) {
+			return
+		}
+		row := dm[id.Row-1]
+
+		if p.selectMode {
+			p.toggleSelected(config.DataTypeEvents, row)
+			return
+		}
+
+		if column == pinColumn {
+			p.togglePin(config.DataTypeEvents, getInt(row, "Serial"), getBool(row, "Pinned"))
+			return
+		}
+
+		p.selectEvent(row)
+	}
+
+	p.linked.detailBtn.OnTapped = func() {
+		p.tableViewLock.RLock()
+		rows := *p.eventsTableDataMapBinding
+		p.tableViewLock.RUnlock()
+		p.inspectRow(config.DataTypeEvents, rows, p.linked.selected)
+	}
+	p.linked.closeBtn.OnTapped = func() {
+		p.selectEvent(nil)
+	}
+	p.linked.table.OnSelected = func(id widget.TableCellID) {
+		if id.Row == 0 {
+			return
+		}
+		defer p.linked.table.UnselectAll()
 
-		v, _ := js.(binding.String).Get()
+		p.tableDataLock.RLock()
+		rows := p.linked.rows
+		p.tableDataLock.RUnlock()
+		if id.Row > len(rows) {
+			return
+		}
+		row := rows[id.Row-1]
 
-		p.jsonDetail.SetText(v)
-		dlg.Show()
-		p.eventsTable.UnselectAll()
+		if id.Col == pinColumn {
+			p.togglePin(config.DataTypeReadings, getInt(row, "Serial"), getBool(row, "Pinned"))
+			return
+		}
+
+		p.inspect(config.DataTypeReadings, rows, id.Row-1)
 	}
 
 	p.readingsTable.OnSelected = func(id widget.TableCellID) {
+		defer p.readingsTable.UnselectAll()
+		column := p.dataColumnAt(config.DataTypeReadings, id.Col)
 		if id.Row == 0 {
+			p.sortDataTableBy(config.DataTypeReadings, column)
 			return
 		}
 
 		p.tableViewLock.RLock()
 		defer p.tableViewLock.RUnlock()
 		dm := *p.readingsTableDataMapBinding
-		js, _ := dm[id.Row-1].GetItem("Json")
+		if id.Row > len(dm) {
+			return
+		}
+		row := dm[id.Row-1]
+
+		if p.selectMode {
+			p.toggleSelected(config.DataTypeReadings, row)
+			return
+		}
 
-		v, _ := js.(binding.String).Get()
+		if column == pinColumn {
+			p.togglePin(config.DataTypeReadings, getInt(row, "Serial"), getBool(row, "Pinned"))
+			return
+		}
 
-		p.jsonDetail.SetText(v)
-		dlg.Show()
-		p.readingsTable.UnselectAll()
+		p.inspect(config.DataTypeReadings, dm, id.Row-1)
 	}
 
 }
@@ -194,6 +293,13 @@ func (p *dataPageHandler) RehydrateSession() {
 		p.search.Text = config.StringVal(sessionSearch)
 		p.resetSearchBtn.Enable()
 	}
+	p.updateFreezeControls()
+	p.pinnedOnly.Checked = p.appState.GetDataPagePinnedOnly()
+	p.anomaliesOnly.Checked = p.appState.GetDataPageAnomaliesOnly()
+	p.receivedWithin.Selected = windowOption(p.appState.GetDataPageWindow())
+	p.updateFieldsBtn()
+	p.updateDeviceFilter()
+
 	bufferSize := p.appState.GetDataPageBufferSize()
 	if bufferSize != nil {
 		b := p.bufferSizeBinding
@@ -224,6 +330,7 @@ func (p *dataPageHandler) SetupBindings() {
 	p.searchBtn.OnTapped = func() {
 		p.appState.SetDataPageSearch(p.search.Text)
 		p.resetSearchBtn.Enable()
+		p.rememberFilter(p.currentFilter())
 
 		p.updateTableByDataType(p.dataType.Selected)
 		p.updateStatusByDataType(p.dataType.Selected)
@@ -283,6 +390,75 @@ func (p *dataPageHandler) SetupBindings() {
 		p.applyBufferSizeBtn.OnTapped()
 	}
 
+	p.pinnedOnly.OnChanged = func(pinnedOnly bool) {
+		p.appState.SetDataPagePinnedOnly(pinnedOnly)
+		p.refreshTable()
+	}
+
+	p.anomaliesOnly.OnChanged = func(anomaliesOnly bool) {
+		p.appState.SetDataPageAnomaliesOnly(anomaliesOnly)
+		p.refreshTable()
+	}
+
+	p.receivedWithin.OnChanged = func(option string) {
+		p.appState.SetDataPageWindow(windowOf(option))
+		p.updateTableByDataType(config.DataTypeEvents)
+		p.updateTableByDataType(config.DataTypeReadings)
+		p.refreshTable()
+	}
+
+	p.fieldsBtn.OnTapped = func() {
+		win := fyne.CurrentApp().Driver().AllWindows()[0]
+		p.showFieldScope(win, p.dataType.Selected)
+	}
+
+	p.deviceFilter.OnTapped = func() {
+		p.appState.SetDataPageDevice("")
+		p.updateDeviceFilter()
+		p.refreshTable()
+	}
+
+	p.exportBtn.OnTapped = func() {
+		win := fyne.CurrentApp().Driver().AllWindows()[0]
+		p.exportSession(win)
+	}
+
+	p.historyBtn.OnTapped = func() {
+		win := fyne.CurrentApp().Driver().AllWindows()[0]
+		p.showFilterHistory(win)
+	}
+
+	p.savedSearchesBtn.OnTapped = func() {
+		win := fyne.CurrentApp().Driver().AllWindows()[0]
+		p.showSavedSearches(win)
+	}
+
+	p.selectBtn.OnTapped = func() {
+		p.setSelectMode(!p.selectMode)
+	}
+
+	p.compareBtn.OnTapped = func() {
+		win := fyne.CurrentApp().Driver().AllWindows()[0]
+		p.showCompare(win, p.dataType.Selected)
+	}
+
+	p.columnsBtn.OnTapped = func() {
+		win := fyne.CurrentApp().Driver().AllWindows()[0]
+		p.showColumnChooser(win, p.dataType.Selected)
+	}
+
+	p.freezeBtn.OnTapped = func() {
+		log.Debug("freezing data page")
+		p.appState.SetDataPageFrozenSnapshot(p.appState.GetDB().Snapshot())
+		p.refreshFrozenState()
+	}
+
+	p.jumpToLiveBtn.OnTapped = func() {
+		log.Debug("back to live data")
+		p.appState.SetDataPageFrozenSnapshot(nil)
+		p.refreshFrozenState()
+	}
+
 	p.resetSearchBtn.OnTapped = func() {
 		log.Debug("filter reset")
 		p.appState.SetDataPageSearch("")
@@ -311,6 +487,12 @@ func (p *dataPageHandler) SetupBindings() {
 	p.tableHeading = container.NewHBox(
 		p.statusText,
 		layout.NewSpacer(),
+		p.frozenText,
+		p.selectBtn,
+		p.compareBtn,
+		p.columnsBtn,
+		p.freezeBtn,
+		p.jumpToLiveBtn,
 		widget.NewLabelWithStyle(fmt.Sprintf("sorted %v by timestamp", sortorder), fyne.TextAlignTrailing, fyne.TextStyle{Italic: true}),
 	)
 
@@ -331,6 +513,8 @@ func (p *dataPageHandler) SetupBindings() {
 		p.setBufferUsageBindingByDataType(currentDataType)
 
 		p.updateStatusByDataType(p.dataType.Selected)
+		p.updateSelectControls()
+		p.updateFieldsBtn()
 
 		//change table
 		p.setTableByDataType(currentDataType, true)
@@ -346,12 +530,12 @@ func (p *dataPageHandler) setTableByDataType(currentDataType string, refresh boo
 	switch currentDataType {
 	case config.DataTypeEvents:
 		p.table = p.eventsTable
-		p.eventsTable.Show()
+		p.eventsView.Show()
 		p.readingsTable.Hide()
 
 	case config.DataTypeReadings:
 		p.table = p.readingsTable
-		p.eventsTable.Hide()
+		p.eventsView.Hide()
 		p.readingsTable.Show()
 	default:
 		log.Fatalf("unhandled type %v", currentDataType)
@@ -368,108 +552,50 @@ func (p *dataPageHandler) renderReadingsTable() *widget.Table {
 		func() (int, int) {
 			p.tableDataLock.RLock()
 			defer p.tableDataLock.RUnlock()
-			return len(*p.readingsTableDataMapBinding) + 1, 10
-		},
-		func() fyne.CanvasObject {
-			return widget.NewLabel("---fdaec17c-c0fc-4a04-982e-31a08a0bb776---")
+			return len(*p.readingsTableDataMapBinding) + 1, len(p.readingsColumns)
 		},
+		newDataCell,
 		func(i widget.TableCellID, o fyne.CanvasObject) {
 			p.tableDataLock.RLock()
 			defer p.tableDataLock.RUnlock()
 
-			label := o.(*widget.Label)
+			cell := o.(*widget.RichText)
+			column := columnAt(p.readingsColumns, i.Col)
+			if column < 0 {
+				setCellText(cell, "", fyne.TextStyle{}, nil)
+				return
+			}
 			switch i.Row {
 			case 0:
-				label.TextStyle = fyne.TextStyle{Bold: true}
-				switch i.Col {
-
-				case 0:
-					label.SetText("Id")
-				case 1:
-					label.SetText("Device Name")
-				case 2:
-					label.SetText("Resource Name")
-				case 3:
-					label.SetText("Profile Name")
-				case 4:
-					label.SetText("Value Type")
-				case 5:
-					label.SetText("Value")
-				case 6:
-					label.SetText("Binary Value")
-				case 7:
-					label.SetText("Media Type")
-				case 8:
-					label.SetText("Origin")
-				case 9:
-					label.SetText("Created")
-					label.TextStyle = fyne.TextStyle{Bold: true}
-				default:
-					label.SetText("")
-				}
+				setCellText(cell, headerText(readingsColumns, column, p.readingsSort), fyne.TextStyle{Bold: true}, nil)
 			default:
-				label.TextStyle = fyne.TextStyle{Bold: false}
-
 				dm := *p.readingsTableDataMapBinding
 				if dm == nil || i.Row > len(dm) {
-					label.SetText("")
+					setCellText(cell, "", fyne.TextStyle{}, nil)
 					break
 				}
 
 				row := dm[i.Row-1]
-
-				switch i.Col {
-				case 0:
-					id, _ := row.GetItem("Id")
-					o.(*widget.Label).Bind(id.(binding.String))
-				case 1:
-					eventName, _ := row.GetItem("DeviceName")
-					o.(*widget.Label).Bind(eventName.(binding.String))
-				case 2:
-					resourceName, _ := row.GetItem("ResourceName")
-					o.(*widget.Label).Bind(resourceName.(binding.String))
-				case 3:
-					profileName, _ := row.GetItem("ProfileName")
-					o.(*widget.Label).Bind(profileName.(binding.String))
-				case 4:
-					valueType, _ := row.GetItem("ValueType")
-					o.(*widget.Label).Bind(valueType.(binding.String))
-				case 5:
-					value, _ := row.GetItem("Value")
-					o.(*widget.Label).Bind(value.(binding.String))
-				case 6:
-					binaryValue, _ := row.GetItem("BinaryValue")
-					o.(*widget.Label).Bind(binaryValue.(binding.String))
-
-					//o.(*widget.Label).Bind(binaryValue.(binding.String))
-				case 7:
-					mediaType, _ := row.GetItem("MediaType")
-					o.(*widget.Label).Bind(mediaType.(binding.String))
-				case 8:
-					origin, _ := row.GetItem("Origin")
-					v, _ := origin.(binding.Int).Get()
-					o.(*widget.Label).SetText(time.Unix(0, int64(v)).String())
-				case 9:
-					created, _ := row.GetItem("Created")
-					v, _ := created.(binding.Int).Get()
-					txt := time.Unix(0, int64(v)).String()
-					if v == 0 {
-						txt = ""
-					}
-					o.(*widget.Label).SetText(txt)
-				default:
-					label.SetText("")
+				// anomalies stand out
+				style := fyne.TextStyle{Bold: getString(row, "Anomaly") != ""}
+				txt := readingCellText(row, column)
+				if column == readingsColMatchedIn {
+					txt = matchedInText(config.DataTypeReadings, row, p.readingsColumns, p.matchFilter)
 				}
-
+				ranges := matchRangesOf(config.DataTypeReadings, row, column, txt, p.matchFilter)
+				if i.Col == 0 && p.readingsSelection.has(getInt(row, "Serial")) {
+					txt = selectedMarker + txt
+					ranges = shiftRanges(ranges, len(selectedMarker))
+				}
+				setCellText(cell, txt, style, ranges)
 			}
 
 		},
 	)
 
-	//BinaryValue can be smaller
-	t.SetColumnWidth(6, 110)
-	//MediaType can be smaller
-	t.SetColumnWidth(7, 100)
+	p.tableDataLock.RLock()
+	applyColumnWidths(t, p.readingsColumns)
+	p.tableDataLock.RUnlock()
 
 	return t
 }
@@ -479,94 +605,48 @@ func (p *dataPageHandler) renderEventsTable() *widget.Table {
 		func() (int, int) {
 			p.tableDataLock.RLock()
 			defer p.tableDataLock.RUnlock()
-			return len(*p.eventsTableDataMapBinding) + 1, 7
-		},
-		func() fyne.CanvasObject {
-			return widget.NewLabel("---fdaec17c-c0fc-4a04-982e-31a08a0bb776---")
+			return len(*p.eventsTableDataMapBinding) + 1, len(p.eventsColumns)
 		},
+		newDataCell,
 		func(i widget.TableCellID, o fyne.CanvasObject) {
 			p.tableDataLock.RLock()
 			defer p.tableDataLock.RUnlock()
 
-			label := o.(*widget.Label)
+			cell := o.(*widget.RichText)
+			column := columnAt(p.eventsColumns, i.Col)
+			if column < 0 {
+				setCellText(cell, "", fyne.TextStyle{}, nil)
+				return
+			}
 			switch i.Row {
 			case 0:
-				label.TextStyle = fyne.TextStyle{Bold: true}
-				switch i.Col {
-
-				case 0:
-					label.SetText("Id")
-				case 1:
-					label.SetText("Device Name")
-				case 2:
-					label.SetText("Profile Name")
-				case 3:
-					label.SetText("Origin")
-				case 4:
-					label.SetText("Readings")
-				case 5:
-					label.SetText("Tags")
-				case 6:
-					label.SetText("Created")
-				default:
-					label.SetText("")
-				}
+				setCellText(cell, headerText(eventsColumns, column, p.eventsSort), fyne.TextStyle{Bold: true}, nil)
 			default:
-				label.TextStyle = fyne.TextStyle{Bold: false}
-
 				dm := *p.eventsTableDataMapBinding
 				if dm == nil || i.Row > len(dm) {
-					label.SetText("")
+					setCellText(cell, "", fyne.TextStyle{}, nil)
 					break
 				}
 
 				row := dm[i.Row-1]
-
-				switch i.Col {
-				case 0:
-					id, _ := row.GetItem("Id")
-					o.(*widget.Label).Bind(id.(binding.String))
-				case 1:
-					eventName, _ := row.GetItem("DeviceName")
-					o.(*widget.Label).Bind(eventName.(binding.String))
-				case 2:
-					profileName, _ := row.GetItem("ProfileName")
-					o.(*widget.Label).Bind(profileName.(binding.String))
-				// case 3:
-				// 	created, _ := row.GetItem("Created")
-				// 	v, _ := created.(binding.Int).Get()
-				// 	o.(*widget.Label).SetText(time.Unix(0, int64(v)).String())
-				case 3:
-					origin, _ := row.GetItem("Origin")
-					v, _ := origin.(binding.Int).Get()
-					o.(*widget.Label).SetText(time.Unix(0, int64(v)).String())
-				case 4:
-					readings, _ := row.GetItem("ReadingsCount")
-					v, _ := readings.(binding.Int).Get()
-					o.(*widget.Label).SetText(fmt.Sprintf("%d", v))
-				case 5:
-					tags, _ := row.GetItem("Tags")
-					v, _ := tags.(binding.String).Get()
-					o.(*widget.Label).SetText(v)
-				case 6:
-					created, _ := row.GetItem("Created")
-					v, _ := created.(binding.Int).Get()
-					txt := time.Unix(0, int64(v)).String()
-					if v == 0 {
-						txt = ""
-					}
-					o.(*widget.Label).SetText(txt)
-				default:
-					label.SetText("")
+				txt := eventCellText(row, column)
+				if column == eventsColMatchedIn {
+					txt = matchedInText(config.DataTypeEvents, row, p.eventsColumns, p.matchFilter)
 				}
-
+				ranges := matchRangesOf(config.DataTypeEvents, row, column, txt, p.matchFilter)
+				if i.Col == 0 && p.eventsSelection.has(getInt(row, "Serial")) {
+					txt = selectedMarker + txt
+					ranges = shiftRanges(ranges, len(selectedMarker))
+				}
+				setCellText(cell, txt, fyne.TextStyle{}, ranges)
 			}
 
 		},
 	)
 
-	//Readings can be smaller
-	t.SetColumnWidth(4, 95)
+	p.tableDataLock.RLock()
+	applyColumnWidths(t, p.eventsColumns)
+	p.tableDataLock.RUnlock()
 
 	return t
 }
@@ -604,6 +684,13 @@ func (p *dataPageHandler) updateStatusByDataType(currentDataType string) {
 	if currentDataType == "" || p.statusText == nil {
 		return
 	}
+	snapshot := p.appState.GetDataPageFrozenSnapshot()
+	pinnedOnly := p.appState.GetDataPagePinnedOnly()
+	anomaliesOnly := p.appState.GetDataPageAnomaliesOnly()
+	device := p.appState.GetDataPageDevice()
+	fields := services.ScopeFilterFields(filterFieldsOf(currentDataType), p.appState.GetDataPageFields())
+	scoped := len(p.appState.GetDataPageFields()) > 0
+	window := p.appState.GetDataPageWindow()
 	p.appState.RLock()
 	defer p.appState.RUnlock()
 	log.Debugf("updateStatusByDataType for %v", currentDataType)
@@ -614,16 +701,62 @@ func (p *dataPageHandler) updateStatusByDataType(currentDataType string) {
 	switch currentDataType {
 	case config.DataTypeEvents:
 		rowCount = int(p.appState.GetDB().GetEventsCount())
+		if snapshot != nil {
+			rowCount = len(snapshot.Events)
+		}
 		recordType = "events"
 	case config.DataTypeReadings:
 		rowCount = int(p.appState.GetDB().GetReadingsCount())
+		if snapshot != nil {
+			rowCount = len(snapshot.Readings)
+		}
 		recordType = "readings"
 	}
 
 	filter := p.appState.GetDataPageSearch()
+	if snapshot != nil {
+		filter = config.String(snapshot.Filter)
+	}
 	txt := fmt.Sprintf("Last %v %v", rowCount, recordType)
+	if pinnedOnly {
+		switch currentDataType {
+		case config.DataTypeEvents:
+			rowCount = len(p.appState.GetDB().GetPinnedEvents())
+		case config.DataTypeReadings:
+			rowCount = len(p.appState.GetDB().GetPinnedReadings())
+		}
+		p.statusText.SetText(fmt.Sprintf("%v pinned %v", rowCount, recordType))
+		return
+	}
+	if anomaliesOnly || device != "" {
+		p.tableDataLock.RLock()
+		switch currentDataType {
+		case config.DataTypeEvents:
+			rowCount = len(*p.eventsTableDataMapBinding)
+			txt = fmt.Sprintf("Last %v events", rowCount)
+			if anomaliesOnly {
+				txt = fmt.Sprintf("Last %v events with anomalous readings", rowCount)
+			}
+		case config.DataTypeReadings:
+			rowCount = len(*p.readingsTableDataMapBinding)
+			txt = fmt.Sprintf("Last %v readings", rowCount)
+			if anomaliesOnly {
+				txt = fmt.Sprintf("Last %v anomalous readings", rowCount)
+			}
+		}
+		p.tableDataLock.RUnlock()
+	}
+	if device != "" {
+		txt = txt + fmt.Sprintf(" from %v", device)
+	}
+	if window > 0 {
+		txt = txt + fmt.Sprintf(" received in the last %v", services.WindowText(window))
+	}
 	if filter != nil && *filter != "" {
 		txt = txt + fmt.Sprintf(" matching \"%v\" (case-insensitive)", *filter)
+		if scoped {
+			txt = txt + fmt.Sprintf(" in %v", services.FilterFieldNames(fields))
+		}
 	}
 	p.statusText.SetText(txt)
 
@@ -636,74 +769,81 @@ func (p *dataPageHandler) updateTableByDataType(currentDataType string) {
 	}
 
 	db := p.appState.GetDB()
+	snapshot := p.appState.GetDataPageFrozenSnapshot()
+	pinnedOnly := p.appState.GetDataPagePinnedOnly()
+	anomaliesOnly := p.appState.GetDataPageAnomaliesOnly()
+	anomalies := p.appState.GetAnomalyDetector()
+	device := p.appState.GetDataPageDevice()
+	filter := db.GetFilter()
+	if snapshot != nil {
+		filter = snapshot.Filter
+	}
 	log.Debugf("updating datatable for %v", currentDataType)
 
-	sortAsc := fyne.CurrentApp().Preferences().BoolWithFallback(config.PrefEventsTableSortOrderAscending, config.DefaultEventsTableSortOrderAscending)
-
 	if currentDataType == config.DataTypeEvents {
 		p.tableDataLock.Lock()
 		defer p.tableDataLock.Unlock()
 		p.eventsTableDataMapBinding = &[]binding.DataMap{}
+		p.matchFilter = filter
+
+		var events []services.EventRecord
+		switch {
+		case pinnedOnly:
+			events = db.GetPinnedEvents()
+		case snapshot != nil:
+			events = snapshot.Events
+		default:
+			events = db.GetEvents()
+		}
 
-		events := db.GetEvents()
-
-		evts := make([]dtos.Event, len(events))
+		evts := make([]services.EventRecord, len(events))
 		copy(evts, events)
 
-		if !sortAsc {
-			log.Debugf("sorting events desc")
-			sort.Slice(evts, func(i, j int) bool {
-				return evts[i].Origin > evts[j].Origin
-			})
-		}
+		p.eventsSort = p.dataTableSort(config.DataTypeEvents)
+		sortEvents(evts, p.eventsSort)
 
 		for _, row := range evts {
-			tags, _ := json.MarshalIndent(row.Tags, "", "    ")
-			eventJson, _ := json.MarshalIndent(row, "", "    ")
-			r := eventRow{
-				Id:            row.Id,
-				DeviceName:    row.DeviceName,
-				ProfileName:   row.ProfileName,
-				Created:       row.Created,
-				Origin:        row.Origin,
-				ReadingsCount: int64(len(row.Readings)),
-				Tags:          string(tags),
-				Json:          string(eventJson),
+			if anomaliesOnly && !hasAnomalies(anomalies, row) {
+				continue
 			}
+			if device != "" && row.DeviceName != device {
+				continue
+			}
+			r := newEventRow(row)
 			*p.eventsTableDataMapBinding = append(*p.eventsTableDataMapBinding, binding.BindStruct(&r))
 		}
 	} else if currentDataType == config.DataTypeReadings {
 		p.tableDataLock.Lock()
 		defer p.tableDataLock.Unlock()
 		p.readingsTableDataMapBinding = &[]binding.DataMap{}
+		p.matchFilter = filter
+
+		var readings []services.ReadingRecord
+		switch {
+		case pinnedOnly:
+			readings = db.GetPinnedReadings()
+		case snapshot != nil:
+			readings = snapshot.Readings
+		default:
+			readings = db.GetReadings()
+		}
 
-		readings := db.GetReadings()
-
-		rdngs := make([]dtos.BaseReading, len(readings))
+		rdngs := make([]services.ReadingRecord, len(readings))
 		copy(rdngs, readings)
 
-		if !p.sortAsc {
-			log.Debugf("sorting readings desc")
-			sort.Slice(rdngs, func(i, j int) bool {
-				return rdngs[i].Origin > rdngs[j].Origin
-			})
-		}
+		p.readingsSort = p.dataTableSort(config.DataTypeReadings)
+		sortReadings(rdngs, p.readingsSort, anomalies)
 
 		for _, row := range rdngs {
-			readingJson, _ := json.MarshalIndent(row, "", "    ")
-			r := readingRow{
-				Id:           row.Id,
-				Created:      row.Created,
-				Origin:       row.Origin,
-				DeviceName:   row.DeviceName,
-				ProfileName:  row.ProfileName,
-				ResourceName: row.ResourceName,
-				ValueType:    row.ValueType,
-				BinaryValue:  string(row.BinaryValue),
-				MediaType:    row.MediaType,
-				Value:        row.Value,
-				Json:         string(readingJson),
+			anomaly, flagged := anomalies.GetAnomaly(row.Id)
+			if anomaliesOnly && !flagged {
+				continue
+			}
+			if device != "" && row.DeviceName != device {
+				continue
 			}
+			r := newReadingRow(row)
+			r.Anomaly = anomalyText(anomaly, flagged)
 			*p.readingsTableDataMapBinding = append(*p.readingsTableDataMapBinding, binding.BindStruct(&r))
 		}
 	}
@@ -716,11 +856,135 @@ func (p *dataPageHandler) OnEventReceived(event dtos.Event) {
 		return
 	}
 
-	p.updateTableByDataType(p.dataType.Selected)
 	p.updateBufferUsageBindingByDataType(p.dataType.Selected)
+
+	// a frozen view keeps still, only the counter of what's coming in moves
+	if p.appState.GetDataPageFrozenSnapshot() != nil {
+		p.updateFreezeControls()
+		return
+	}
+
+	p.updateTableByDataType(p.dataType.Selected)
 	p.updateStatusByDataType(p.dataType.Selected)
+	p.updateLinkedReadings()
 	if p.table != nil {
 		p.table.Refresh()
 	}
 
 }
+
+// refreshFrozenState redraws the table after freezing or going back to live data
+func (p *dataPageHandler) refreshFrozenState() {
+	p.updateFreezeControls()
+	p.refreshTable()
+}
+
+func (p *dataPageHandler) refreshTable() {
+	p.updateTableByDataType(p.dataType.Selected)
+	p.updateStatusByDataType(p.dataType.Selected)
+	p.updateBufferUsageBindingByDataType(p.dataType.Selected)
+	p.updateLinkedReadings()
+	if p.table != nil {
+		p.table.Refresh()
+	}
+}
+
+// togglePin pins or unpins the row, it's false when the row has been evicted in the meantime
+func (p *dataPageHandler) togglePin(dataType string, serial int64, pinned bool) bool {
+	db := p.appState.GetDB()
+
+	var ok bool
+	switch dataType {
+	case config.DataTypeEvents:
+		if pinned {
+			ok = db.UnpinEvent(serial)
+		} else {
+			ok = db.PinEvent(serial)
+		}
+	case config.DataTypeReadings:
+		if pinned {
+			ok = db.UnpinReading(serial)
+		} else {
+			ok = db.PinReading(serial)
+		}
+	}
+	if !ok {
+		log.Warnf("cannot toggle pin on %v %v, it has been evicted", dataType, serial)
+		return false
+	}
+
+	// the frozen copy doesn't know about the change, it's shared with the readers so it's replaced, not changed
+	if snapshot := p.appState.GetDataPageFrozenSnapshot(); snapshot != nil {
+		switch dataType {
+		case config.DataTypeEvents:
+			p.appState.SetDataPageFrozenSnapshot(snapshot.WithEventPinned(serial, !pinned))
+		case config.DataTypeReadings:
+			p.appState.SetDataPageFrozenSnapshot(snapshot.WithReadingPinned(serial, !pinned))
+		}
+	}
+
+	go p.refreshTable()
+	return true
+}
+
+func (p *dataPageHandler) saveNote(dataType string, serial int64, note string) {
+	db := p.appState.GetDB()
+	switch dataType {
+	case config.DataTypeEvents:
+		db.SetEventNote(serial, note)
+	case config.DataTypeReadings:
+		db.SetReadingNote(serial, note)
+	}
+	go p.refreshTable()
+}
+
+func (p *dataPageHandler) exportSession(win fyne.Window) {
+	export := p.appState.GetDB().Export()
+
+	dlg := dialog.NewFileSave(func(writer fyne.URIWriteCloser, err error) {
+		if err != nil {
+			dialog.ShowError(err, win)
+			return
+		}
+		if writer == nil {
+			return
+		}
+		defer writer.Close()
+
+		enc := json.NewEncoder(writer)
+		enc.SetIndent("", "    ")
+		if err := enc.Encode(export); err != nil {
+			log.Errorf("cannot export session: %v", err)
+			dialog.ShowError(fmt.Errorf("Cannot export session\n%s", err), win)
+		}
+	}, win)
+	dlg.SetFileName(fmt.Sprintf("edgex-datamonitor-%v.json", time.Now().Format("20060102-150405")))
+	dlg.Show()
+}
+
+func (p *dataPageHandler) updateFreezeControls() {
+	snapshot := p.appState.GetDataPageFrozenSnapshot()
+	if snapshot == nil {
+		p.frozenText.Hide()
+		p.jumpToLiveBtn.Hide()
+		p.freezeBtn.Show()
+		return
+	}
+
+	newEvents, newReadings := p.appState.GetDB().CountIngestedSince(snapshot)
+	p.frozenText.SetText(fmt.Sprintf("Frozen, %d new events and %d new readings since", newEvents, newReadings))
+	p.frozenText.Show()
+	p.freezeBtn.Hide()
+	p.jumpToLiveBtn.Show()
+}
+
+// updateDeviceFilter shows the device the data is restricted to, if any
+func (p *dataPageHandler) updateDeviceFilter() {
+	device := p.appState.GetDataPageDevice()
+	if device == "" {
+		p.deviceFilter.Hide()
+		return
+	}
+	p.deviceFilter.SetText(fmt.Sprintf("Device: %v", device))
+	p.deviceFilter.Show()
+}
@@ -0,0 +1,187 @@
+// Copyright 2021 Alessandro De Blasis <alex@deblasis.net>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+package pages
+
+import (
+	"fmt"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/data/binding"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/theme"
+	"fyne.io/fyne/v2/widget"
+	"github.com/deblasis/edgex-foundry-datamonitor/config"
+)
+
+// linkedReadingsPanel shows the readings of the event selected in the events table
+type linkedReadingsPanel struct {
+	title      *widget.Label
+	detailBtn  *widget.Button
+	closeBtn   *widget.Button
+	table      *widget.Table
+	emptyLabel *widget.Label
+
+	// selected is the row of the selected event, nil when nothing is selected
+	selected binding.DataMap
+	rows     []binding.DataMap
+
+	content *fyne.Container
+}
+
+func (p *dataPageHandler) renderLinkedReadingsPanel() {
+	l := &linkedReadingsPanel{
+		title:      widget.NewLabelWithStyle("", fyne.TextAlignLeading, fyne.TextStyle{Bold: true}),
+		detailBtn:  widget.NewButtonWithIcon("Event detail", theme.InfoIcon(), func() {}),
+		closeBtn:   widget.NewButtonWithIcon("", theme.CancelIcon(), func() {}),
+		emptyLabel: widget.NewLabelWithStyle("Select an event to see its readings", fyne.TextAlignCenter, fyne.TextStyle{Italic: true}),
+	}
+	l.table = widget.NewTable(
+		func() (int, int) {
+			p.tableDataLock.RLock()
+			defer p.tableDataLock.RUnlock()
+			return len(l.rows) + 1, 6
+		},
+		func() fyne.CanvasObject {
+			return widget.NewLabel("---fdaec17c-c0fc-4a04-982e-31a08a0bb776---")
+		},
+		func(i widget.TableCellID, o fyne.CanvasObject) {
+			p.tableDataLock.RLock()
+			defer p.tableDataLock.RUnlock()
+
+			label := o.(*widget.Label)
+			if i.Row == 0 {
+				label.TextStyle = fyne.TextStyle{Bold: true}
+				label.SetText([]string{"Pin", "Id", "Resource Name", "Value Type", "Value", "Origin"}[i.Col])
+				return
+			}
+			label.TextStyle = fyne.TextStyle{Bold: false}
+			if i.Row > len(l.rows) {
+				label.SetText("")
+				return
+			}
+
+			row := l.rows[i.Row-1]
+			switch i.Col {
+			case pinColumn:
+				label.SetText(pinText(getBool(row, "Pinned")))
+			case 1:
+				label.SetText(getString(row, "Id"))
+			case 2:
+				label.SetText(getString(row, "ResourceName"))
+			case 3:
+				label.SetText(getString(row, "ValueType"))
+			case 4:
+				label.SetText(getString(row, "Value"))
+			case 5:
+				label.SetText(time.Unix(0, getInt(row, "Origin")).String())
+			}
+		},
+	)
+	l.table.SetColumnWidth(pinColumn, pinColumnWidth)
+
+	l.content = container.NewBorder(
+		container.NewVBox(
+			widget.NewSeparator(),
+			container.NewBorder(nil, nil, nil, container.NewHBox(l.detailBtn, l.closeBtn), l.title),
+		),
+		nil, nil, nil,
+		container.NewMax(l.table, container.NewCenter(l.emptyLabel)),
+	)
+	p.linked = l
+	p.updateLinkedReadings()
+}
+
+// selectEvent shows the readings of the event in the lower half of the events view
+func (p *dataPageHandler) selectEvent(row binding.DataMap) {
+	p.linked.selected = row
+	p.updateLinkedReadings()
+}
+
+// updateLinkedReadings reloads the readings of the selected event, some of them might have been evicted meanwhile
+func (p *dataPageHandler) updateLinkedReadings() {
+	l := p.linked
+	if l == nil {
+		return
+	}
+
+	if l.selected == nil {
+		p.tableDataLock.Lock()
+		l.rows = nil
+		p.tableDataLock.Unlock()
+
+		l.title.SetText("Readings")
+		l.detailBtn.Disable()
+		l.closeBtn.Disable()
+		l.table.Hide()
+		l.emptyLabel.Show()
+		return
+	}
+
+	eventId := getString(l.selected, "Id")
+	readings := p.appState.GetDB().GetReadingsByEventSerial(getInt(l.selected, "Serial"))
+	rows := make([]binding.DataMap, 0, len(readings))
+	for _, reading := range readings {
+		r := newReadingRow(reading)
+		rows = append(rows, binding.BindStruct(&r))
+	}
+
+	p.tableDataLock.Lock()
+	l.rows = rows
+	p.tableDataLock.Unlock()
+
+	txt := fmt.Sprintf("Readings of event %v from %v", eventId, getString(l.selected, "DeviceName"))
+	if missing := int(getInt(l.selected, "ReadingsCount")) - len(rows); missing > 0 {
+		txt = txt + fmt.Sprintf(" (%d evicted)", missing)
+	}
+	l.title.SetText(txt)
+	l.detailBtn.Enable()
+	l.closeBtn.Enable()
+	l.emptyLabel.Hide()
+	l.table.Show()
+	l.table.Refresh()
+}
+
+// openParentEvent switches to the events and selects the copy of the event the reading came with
+func (p *dataPageHandler) openParentEvent(eventId string, eventSerial int64, win fyne.Window) bool {
+	event, ok := p.appState.GetDB().GetEventBySerial(eventSerial)
+	if !ok {
+		log.Debugf("parent event %v is no longer buffered", eventId)
+		dialog.ShowInformation("Parent event", fmt.Sprintf("The event %v is no longer buffered", eventId), win)
+		return false
+	}
+
+	r := newEventRow(event)
+	p.selectEvent(binding.BindStruct(&r))
+	p.dataType.SetSelected(config.DataTypeEvents)
+
+	position := -1
+	p.tableDataLock.RLock()
+	for i, row := range *p.eventsTableDataMapBinding {
+		if getInt(row, "Serial") == event.Serial {
+			position = i
+			break
+		}
+	}
+	p.tableDataLock.RUnlock()
+
+	if position >= 0 {
+		p.eventsTable.ScrollTo(widget.TableCellID{Row: position + 1, Col: 1})
+	}
+	return true
+}
@@ -0,0 +1,163 @@
+// Copyright 2021 Alessandro De Blasis <alex@deblasis.net>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+package pages
+
+import (
+	"strings"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/data/binding"
+	"fyne.io/fyne/v2/theme"
+	"fyne.io/fyne/v2/widget"
+	"github.com/deblasis/edgex-foundry-datamonitor/config"
+	"github.com/deblasis/edgex-foundry-datamonitor/services"
+)
+
+// eventMatchColumns maps the fields of the loose search to the columns showing them
+var eventMatchColumns = map[string]int{
+	"event_id":          eventsColId,
+	"event_deviceName":  eventsColDeviceName,
+	"event_profileName": eventsColProfileName,
+	"event_created":     eventsColCreated,
+	"event_origin":      eventsColOrigin,
+	"event_tags":        eventsColTags,
+}
+
+// readingMatchColumns maps the fields of the loose search to the columns showing them,
+// the fields of the parent event have none and the binary value is only shown by its size
+var readingMatchColumns = map[string]int{
+	"event_id":             readingsColEventId,
+	"reading_id":           readingsColId,
+	"reading_created":      readingsColCreated,
+	"reading_origin":       readingsColOrigin,
+	"reading_deviceName":   readingsColDeviceName,
+	"reading_resourceName": readingsColResourceName,
+	"reading_profileName":  readingsColProfileName,
+	"reading_valueType":    readingsColValueType,
+	"reading_mediaType":    readingsColMediaType,
+	"reading_value":        readingsColValue,
+}
+
+// highlightStyle makes the text matching the filter stand out from the rest of the cell
+var highlightStyle = widget.RichTextStyle{
+	ColorName: theme.ColorNamePrimary,
+	Inline:    true,
+	TextStyle: fyne.TextStyle{Bold: true},
+}
+
+func matchedInKeys(fields []services.FilterField) string {
+	keys := make([]string, len(fields))
+	for i, f := range fields {
+		keys[i] = f.Key
+	}
+	return strings.Join(keys, ",")
+}
+
+func filterFieldsOf(dataType string) []services.FilterField {
+	if dataType == config.DataTypeReadings {
+		return services.ReadingFilterFields
+	}
+	return services.EventFilterFields
+}
+
+func matchColumnsOf(dataType string) map[string]int {
+	if dataType == config.DataTypeReadings {
+		return readingMatchColumns
+	}
+	return eventMatchColumns
+}
+
+func cellTextOf(dataType string, row binding.DataMap, column int) string {
+	if dataType == config.DataTypeReadings {
+		return readingCellText(row, column)
+	}
+	return eventCellText(row, column)
+}
+
+// hasMatchIn is true when the filter was found in the field
+func hasMatchIn(row binding.DataMap, key string) bool {
+	return strings.Contains(","+getString(row, "MatchedIn")+",", ","+key+",")
+}
+
+// matchedInText names the fields the row matched in, when the match can't be seen in the columns shown,
+// e.g. because it's in a hidden column, in a field of the parent event or the timestamp is shown formatted
+func matchedInText(dataType string, row binding.DataMap, layout []shownColumn, filter string) string {
+	if filter == "" || getString(row, "MatchedIn") == "" {
+		return ""
+	}
+
+	columns := matchColumnsOf(dataType)
+	matched := make([]services.FilterField, 0)
+	for _, f := range filterFieldsOf(dataType) {
+		if !hasMatchIn(row, f.Key) {
+			continue
+		}
+		if column, ok := columns[f.Key]; ok && layoutShows(layout, column) &&
+			services.LooseMatch(cellTextOf(dataType, row, column), filter) {
+			return ""
+		}
+		matched = append(matched, f)
+	}
+	return services.FilterFieldNames(matched)
+}
+
+// matchRangesOf is where to highlight the filter in the cell, only the columns of the fields that matched are
+func matchRangesOf(dataType string, row binding.DataMap, column int, text string, filter string) [][]int {
+	if filter == "" {
+		return nil
+	}
+	for key, c := range matchColumnsOf(dataType) {
+		if c == column && hasMatchIn(row, key) {
+			return services.MatchRanges(text, filter)
+		}
+	}
+	return nil
+}
+
+// shiftRanges moves the ranges along when some text is put in front of the one they were found in
+func shiftRanges(ranges [][]int, by int) [][]int {
+	shifted := make([][]int, len(ranges))
+	for i, r := range ranges {
+		shifted[i] = []int{r[0] + by, r[1] + by}
+	}
+	return shifted
+}
+
+// setCellText shows the text with the given style, highlighting the ranges
+func setCellText(cell *widget.RichText, text string, style fyne.TextStyle, ranges [][]int) {
+	plain := widget.RichTextStyleInline
+	plain.TextStyle = style
+
+	segments := make([]widget.RichTextSegment, 0, 2*len(ranges)+1)
+	last := 0
+	for _, r := range ranges {
+		if r[0] > last {
+			segments = append(segments, &widget.TextSegment{Text: text[last:r[0]], Style: plain})
+		}
+		segments = append(segments, &widget.TextSegment{Text: text[r[0]:r[1]], Style: highlightStyle})
+		last = r[1]
+	}
+	if last < len(text) || len(segments) == 0 {
+		segments = append(segments, &widget.TextSegment{Text: text[last:], Style: plain})
+	}
+
+	cell.Segments = segments
+	cell.Refresh()
+}
+
+// newDataCell creates the cells of the data tables, their widths come from the column layout
+func newDataCell() fyne.CanvasObject {
+	return widget.NewRichText()
+}
@@ -0,0 +1,327 @@
+// Copyright 2021 Alessandro De Blasis <alex@deblasis.net>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+package pages
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/widget"
+	"github.com/deblasis/edgex-foundry-datamonitor/config"
+	"github.com/deblasis/edgex-foundry-datamonitor/data"
+	"github.com/deblasis/edgex-foundry-datamonitor/services"
+)
+
+// savedSearchPrefix marks the nodes of the saved searches, they sit under the Data page in the navigation tree
+const savedSearchPrefix = "data/search/"
+
+// dataWindows are the spans of time the Data page can be restricted to, the latest ones
+var dataWindows = []time.Duration{
+	time.Minute,
+	5 * time.Minute,
+	15 * time.Minute,
+	time.Hour,
+	6 * time.Hour,
+}
+
+const anyTime = "Any time"
+
+func windowOption(w time.Duration) string {
+	if w == 0 {
+		return anyTime
+	}
+	return "Last " + services.WindowText(w)
+}
+
+func windowOptions() []string {
+	options := []string{anyTime}
+	for _, w := range dataWindows {
+		options = append(options, windowOption(w))
+	}
+	return options
+}
+
+func windowOf(option string) time.Duration {
+	for _, w := range dataWindows {
+		if windowOption(w) == option {
+			return w
+		}
+	}
+	return 0
+}
+
+// SavedSearchPageKey is the node of the saved search in the navigation tree
+func SavedSearchPageKey(name string) widget.TreeNodeID {
+	return widget.TreeNodeID(savedSearchPrefix + name)
+}
+
+// SetSavedSearchPages lists the saved searches under the Data page in the navigation tree
+func SetSavedSearchPages(searches []services.DataFilter) {
+	for uid := range Pages {
+		if strings.HasPrefix(uid, savedSearchPrefix) {
+			delete(Pages, uid)
+		}
+	}
+	children := make([]widget.TreeNodeID, 0, len(searches))
+	for _, s := range searches {
+		uid := SavedSearchPageKey(s.Name)
+		Pages[uid] = Page{Title: s.Name, Intro: s.String(), View: dataScreen}
+		children = append(children, uid)
+	}
+	if len(children) == 0 {
+		delete(PageIndex, DataPageKey)
+		return
+	}
+	PageIndex[DataPageKey] = children
+}
+
+// ApplySavedSearch filters the Data page by the saved search of the node, false if the node is not a saved search
+func ApplySavedSearch(uid widget.TreeNodeID, appMgr *services.AppManager) bool {
+	if !strings.HasPrefix(uid, savedSearchPrefix) {
+		return false
+	}
+	name := strings.TrimPrefix(uid, savedSearchPrefix)
+	for _, s := range appMgr.GetSavedSearches() {
+		if s.Name == name {
+			appMgr.GetPageHandler(DataPageKey).(*dataPageHandler).applyFilter(s)
+			return true
+		}
+	}
+	log.Warnf("the saved search %v is gone", name)
+	return false
+}
+
+// LoadSavedSearches reads the saved searches and the filter history from the preferences
+func LoadSavedSearches(appMgr *services.AppManager) {
+	preferences := fyne.CurrentApp().Preferences()
+	if searches, err := services.ParseDataFilters(preferences.String(config.PrefSavedSearches)); err != nil {
+		log.Errorf("cannot load the saved searches: %v", err)
+	} else {
+		appMgr.SetSavedSearches(searches)
+	}
+	if history, err := services.ParseDataFilters(preferences.String(config.PrefFilterHistory)); err != nil {
+		log.Errorf("cannot load the filter history: %v", err)
+	} else {
+		appMgr.SetFilterHistory(history)
+	}
+	SetSavedSearchPages(appMgr.GetSavedSearches())
+}
+
+// currentFilter is what the Data page is filtered by right now
+func (p *dataPageHandler) currentFilter() services.DataFilter {
+	return services.DataFilter{
+		Text:          config.StringVal(p.appState.GetDataPageSearch()),
+		DataType:      p.dataType.Selected,
+		Device:        p.appState.GetDataPageDevice(),
+		PinnedOnly:    p.appState.GetDataPagePinnedOnly(),
+		AnomaliesOnly: p.appState.GetDataPageAnomaliesOnly(),
+		Fields:        p.appState.GetDataPageFields(),
+		Window:        p.appState.GetDataPageWindow(),
+	}
+}
+
+// applyFilter filters the Data page, it's remembered in the history
+func (p *dataPageHandler) applyFilter(f services.DataFilter) {
+	p.appState.SetDataPageSearch(f.Text)
+	p.appState.SetDataPageDevice(f.Device)
+	p.appState.SetDataPagePinnedOnly(f.PinnedOnly)
+	p.appState.SetDataPageAnomaliesOnly(f.AnomaliesOnly)
+	p.appState.SetDataPageFields(f.Fields)
+	p.appState.SetDataPageWindow(f.Window)
+	p.rememberFilter(f)
+
+	p.search.SetText(f.Text)
+	if f.Text != "" {
+		p.resetSearchBtn.Enable()
+	} else {
+		p.resetSearchBtn.Disable()
+	}
+	p.pinnedOnly.Checked = f.PinnedOnly
+	p.pinnedOnly.Refresh()
+	p.anomaliesOnly.Checked = f.AnomaliesOnly
+	p.anomaliesOnly.Refresh()
+	p.receivedWithin.Selected = windowOption(f.Window)
+	p.receivedWithin.Refresh()
+	p.updateDeviceFilter()
+
+	if f.DataType != "" && f.DataType != p.dataType.Selected {
+		p.dataType.SetSelected(f.DataType)
+	}
+	p.updateFieldsBtn()
+	if p.eventsTable == nil {
+		// not rendered yet, the page picks the filter up from the session
+		return
+	}
+	// the table in the background is updated too, it would be inconsistent when switching data type
+	p.updateTableByDataType(config.DataTypeEvents)
+	p.updateTableByDataType(config.DataTypeReadings)
+	p.refreshTable()
+}
+
+// rememberFilter puts the filter on top of the history, unless it lets everything through
+func (p *dataPageHandler) rememberFilter(f services.DataFilter) {
+	if f.IsEmpty() {
+		return
+	}
+	p.setFilterHistory(services.AddToFilterHistory(p.appState.GetFilterHistory(), f, config.MaxFilterHistory))
+}
+
+func (p *dataPageHandler) setFilterHistory(history []services.DataFilter) {
+	p.appState.SetFilterHistory(history)
+	fyne.CurrentApp().Preferences().SetString(config.PrefFilterHistory, services.FormatDataFilters(history))
+}
+
+// setSavedSearches stores the saved searches and lists them in the navigation tree
+func (p *dataPageHandler) setSavedSearches(searches []services.DataFilter) {
+	p.appState.SetSavedSearches(searches)
+	fyne.CurrentApp().Preferences().SetString(config.PrefSavedSearches, services.FormatDataFilters(searches))
+	SetSavedSearchPages(searches)
+	p.appState.RefreshNavBar()
+}
+
+// showFilterHistory drops down the recent filters below the button
+func (p *dataPageHandler) showFilterHistory(win fyne.Window) {
+	history := p.appState.GetFilterHistory()
+	items := make([]*fyne.MenuItem, 0, len(history)+2)
+	for _, f := range history {
+		f := f
+		items = append(items, fyne.NewMenuItem(f.String(), func() {
+			p.applyFilter(f)
+		}))
+	}
+	if len(history) == 0 {
+		items = append(items, fyne.NewMenuItem("No recent filters", func() {}))
+	} else {
+		items = append(items, fyne.NewMenuItemSeparator(), fyne.NewMenuItem("Clear history", func() {
+			p.setFilterHistory(nil)
+		}))
+	}
+	showMenuBelow(win, p.historyBtn, fyne.NewMenu("", items...))
+}
+
+// showSavedSearches drops down the saved searches below the button, along with the actions to manage them
+func (p *dataPageHandler) showSavedSearches(win fyne.Window) {
+	searches := p.appState.GetSavedSearches()
+	items := make([]*fyne.MenuItem, 0, len(searches)+3)
+	deleteItems := make([]*fyne.MenuItem, 0, len(searches))
+	for _, s := range searches {
+		s := s
+		items = append(items, fyne.NewMenuItem(s.Name, func() {
+			p.applyFilter(s)
+		}))
+		deleteItems = append(deleteItems, fyne.NewMenuItem(s.Name, func() {
+			p.setSavedSearches(services.DeleteSearch(p.appState.GetSavedSearches(), s.Name))
+		}))
+	}
+	if len(searches) > 0 {
+		items = append(items, fyne.NewMenuItemSeparator())
+	}
+	items = append(items, fyne.NewMenuItem("Save current filter...", func() {
+		p.saveCurrentFilter(win)
+	}))
+	if len(searches) > 0 {
+		deleteItem := fyne.NewMenuItem("Delete", func() {})
+		deleteItem.ChildMenu = fyne.NewMenu("", deleteItems...)
+		items = append(items, deleteItem)
+	}
+	showMenuBelow(win, p.savedSearchesBtn, fyne.NewMenu("", items...))
+}
+
+// saveCurrentFilter asks for a name, saving with the name of an existing search replaces it
+func (p *dataPageHandler) saveCurrentFilter(win fyne.Window) {
+	f := p.currentFilter()
+
+	name := widget.NewEntry()
+	name.SetPlaceHolder("e.g. Overheating thermostats")
+	name.Validator = func(s string) error {
+		return data.StringNotEmptyValidator(strings.TrimSpace(s))
+	}
+	items := []*widget.FormItem{
+		widget.NewFormItem("Name", name),
+		widget.NewFormItem("Filter", widget.NewLabel(f.String())),
+	}
+	dialog.ShowForm("Save the current filter", "Save", "Cancel", items, func(ok bool) {
+		if !ok {
+			return
+		}
+		f.Name = strings.TrimSpace(name.Text)
+		p.setSavedSearches(services.SaveSearch(p.appState.GetSavedSearches(), f))
+	}, win)
+}
+
+// updateFieldsBtn tells which fields the search looks in
+func (p *dataPageHandler) updateFieldsBtn() {
+	fields := services.ScopeFilterFields(filterFieldsOf(p.dataType.Selected), p.appState.GetDataPageFields())
+	switch {
+	case len(p.appState.GetDataPageFields()) == 0:
+		p.fieldsBtn.SetText("All fields")
+	case len(fields) == 1:
+		p.fieldsBtn.SetText(fmt.Sprintf("Only %v", fields[0].Name))
+	default:
+		p.fieldsBtn.SetText(fmt.Sprintf("%d fields", len(fields)))
+	}
+}
+
+// showFieldScope lets the user pick the fields the search looks in, among the ones of the data type
+func (p *dataPageHandler) showFieldScope(win fyne.Window, dataType string) {
+	fields := filterFieldsOf(dataType)
+	names := make([]string, len(fields))
+	for i, f := range fields {
+		names[i] = f.Name
+	}
+	picked := widget.NewCheckGroup(names, func([]string) {})
+	if keys := p.appState.GetDataPageFields(); len(keys) > 0 {
+		for _, f := range services.ScopeFilterFields(fields, keys) {
+			picked.Selected = append(picked.Selected, f.Name)
+		}
+	}
+
+	items := []*widget.FormItem{
+		widget.NewFormItem("Search in", picked),
+	}
+	dialog.ShowForm("Fields to search", "Apply", "Cancel", items, func(ok bool) {
+		if !ok {
+			return
+		}
+		// picking every field or none is the same, there's no scope
+		keys := make([]string, 0, len(picked.Selected))
+		if len(picked.Selected) < len(fields) {
+			for _, f := range fields {
+				for _, name := range picked.Selected {
+					if f.Name == name {
+						keys = append(keys, f.Key)
+					}
+				}
+			}
+		}
+		p.appState.SetDataPageFields(keys)
+		p.updateFieldsBtn()
+
+		// the table in the background is updated too, it would be inconsistent when switching data type
+		p.updateTableByDataType(config.DataTypeEvents)
+		p.updateTableByDataType(config.DataTypeReadings)
+		p.refreshTable()
+	}, win)
+}
+
+func showMenuBelow(win fyne.Window, o fyne.CanvasObject, menu *fyne.Menu) {
+	pos := fyne.CurrentApp().Driver().AbsolutePositionForObject(o)
+	widget.ShowPopUpMenuAtPosition(menu, win.Canvas(), pos.Add(fyne.NewPos(0, o.Size().Height)))
+}
@@ -0,0 +1,36 @@
+// Copyright 2021 Alessandro De Blasis <alex@deblasis.net>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+package pages
+
+import (
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"github.com/deblasis/edgex-foundry-datamonitor/services"
+)
+
+func devicesScreen(w fyne.Window, appManager *services.AppManager) fyne.CanvasObject {
+
+	h := appManager.GetPageHandler(DevicesPageKey).(*devicesPageHandler)
+
+	h.SetInitialState()
+	h.RehydrateSession()
+	h.SetupBindings()
+
+	return container.NewBorder(
+		h.summary,
+		nil, nil, nil,
+		h.table,
+	)
+}
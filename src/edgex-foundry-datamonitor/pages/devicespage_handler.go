@@ -0,0 +1,191 @@
+// Copyright 2021 Alessandro De Blasis <alex@deblasis.net>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+package pages
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/widget"
+	"github.com/deblasis/edgex-foundry-datamonitor/services"
+	"github.com/edgexfoundry/go-mod-core-contracts/v2/dtos"
+)
+
+const (
+	devicesColName = iota
+	devicesColProfile
+	devicesColResources
+	devicesColFirstSeen
+	devicesColLastSeen
+	devicesColEvents
+	devicesColEventsPerSecond
+	devicesColTags
+)
+
+var devicesColumns = []string{"Device", "Profile", "Resources", "First seen", "Last seen", "Events", "Events/s", "Tags"}
+
+type devicesPageHandler struct {
+	appState *services.AppManager
+
+	Key widget.TreeNodeID
+
+	summary *widget.Label
+	table   *widget.Table
+
+	rows     []services.DeviceInfo
+	rowsLock sync.RWMutex
+}
+
+func NewDevicesPageHandler(appState *services.AppManager) *devicesPageHandler {
+	p := &devicesPageHandler{
+		Key:      DevicesPageKey,
+		appState: appState,
+	}
+
+	p.summary = widget.NewLabel("")
+
+	return p
+}
+
+func (p *devicesPageHandler) SetInitialState() {
+	p.table = p.renderDevicesTable()
+}
+func (p *devicesPageHandler) RehydrateSession() {
+}
+func (p *devicesPageHandler) SetupBindings() {
+	p.table.OnSelected = func(id widget.TableCellID) {
+		defer p.table.UnselectAll()
+		if id.Row == 0 {
+			return
+		}
+		p.rowsLock.RLock()
+		if id.Row > len(p.rows) {
+			p.rowsLock.RUnlock()
+			return
+		}
+		device := p.rows[id.Row-1].Name
+		p.rowsLock.RUnlock()
+
+		p.showData(device)
+	}
+	p.updateDevices()
+}
+
+func (p *devicesPageHandler) OnEventReceived(event dtos.Event) {
+
+	if p.appState.GetConnectionState() != services.ClientConnected {
+		return
+	}
+	p.updateDevices()
+}
+
+// showData opens the Data page showing only what came from the device
+func (p *devicesPageHandler) showData(device string) {
+	p.appState.SetDataPageDevice(device)
+	p.appState.Navigate(DataPageKey)
+}
+
+func (p *devicesPageHandler) updateDevices() {
+	rows := p.appState.GetDeviceInventory().Devices()
+
+	p.rowsLock.Lock()
+	p.rows = rows
+	p.rowsLock.Unlock()
+
+	p.summary.SetText(fmt.Sprintf("%d devices seen, tap one to see its data", len(rows)))
+	if p.table != nil {
+		p.table.Refresh()
+	}
+}
+
+func (p *devicesPageHandler) renderDevicesTable() *widget.Table {
+	t := widget.NewTable(
+		func() (int, int) {
+			p.rowsLock.RLock()
+			defer p.rowsLock.RUnlock()
+			return len(p.rows) + 1, len(devicesColumns)
+		},
+		func() fyne.CanvasObject {
+			return widget.NewLabel("")
+		},
+		func(i widget.TableCellID, o fyne.CanvasObject) {
+			p.rowsLock.RLock()
+			defer p.rowsLock.RUnlock()
+
+			label := o.(*widget.Label)
+			if i.Row == 0 {
+				label.TextStyle = fyne.TextStyle{Bold: true}
+				label.SetText(devicesColumns[i.Col])
+				return
+			}
+			label.TextStyle = fyne.TextStyle{Bold: false}
+			if i.Row > len(p.rows) {
+				label.SetText("")
+				return
+			}
+
+			row := p.rows[i.Row-1]
+			switch i.Col {
+			case devicesColName:
+				label.SetText(row.Name)
+			case devicesColProfile:
+				label.SetText(strings.Join(row.Profiles, ", "))
+			case devicesColResources:
+				label.SetText(resourcesText(row.Resources))
+			case devicesColFirstSeen:
+				label.SetText(row.FirstSeen.Format("2006-01-02 15:04:05"))
+			case devicesColLastSeen:
+				label.SetText(fmt.Sprintf("%v ago", time.Since(row.LastSeen).Truncate(time.Second)))
+			case devicesColEvents:
+				label.SetText(fmt.Sprintf("%d", row.TotalEvents))
+			case devicesColEventsPerSecond:
+				label.SetText(fmt.Sprintf("%.2f", row.EventsPerSecond))
+			case devicesColTags:
+				label.SetText(tagsText(row.Tags))
+			}
+		},
+	)
+	t.SetColumnWidth(devicesColName, 220)
+	t.SetColumnWidth(devicesColProfile, 180)
+	t.SetColumnWidth(devicesColResources, 350)
+	t.SetColumnWidth(devicesColFirstSeen, 170)
+	t.SetColumnWidth(devicesColLastSeen, 100)
+	t.SetColumnWidth(devicesColEvents, 90)
+	t.SetColumnWidth(devicesColEventsPerSecond, 90)
+	t.SetColumnWidth(devicesColTags, 250)
+	return t
+}
+
+// resourcesText renders temperature (Float32), mode (String, Int8)
+func resourcesText(resources []services.ResourceInfo) string {
+	parts := make([]string, 0, len(resources))
+	for _, r := range resources {
+		parts = append(parts, fmt.Sprintf("%v (%v)", r.Name, strings.Join(r.ValueTypes, ", ")))
+	}
+	return strings.Join(parts, ", ")
+}
+
+func tagsText(tags map[string]string) string {
+	parts := make([]string, 0, len(tags))
+	for k, v := range tags {
+		parts = append(parts, fmt.Sprintf("%v=%v", k, v))
+	}
+	sort.Strings(parts)
+	return strings.Join(parts, ", ")
+}
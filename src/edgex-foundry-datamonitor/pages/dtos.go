@@ -15,18 +15,31 @@
 package pages
 
 type eventRow struct {
+	Serial        int64  `json:"serial"`
+	Pinned        bool   `json:"pinned"`
+	Note          string `json:"note,omitempty"`
+	Copies        int64  `json:"copies"`
 	Id            string `json:"id"`
 	DeviceName    string `json:"deviceName"`
 	ProfileName   string `json:"profileName"`
 	Created       int64  `json:"created"`
 	Origin        int64  `json:"origin"`
 	ReadingsCount int64  `json:"readingsCount"`
+	ReceivedAt    int64  `json:"receivedAt"`
+	Latency       string `json:"latency"`
 	Tags          string `json:"tags,omitempty"`
+	// MatchedIn lists the keys of the fields the filter was found in, comma separated
+	MatchedIn string `json:"matchedIn,omitempty"`
 
 	Json string `json:"json"`
 }
 
 type readingRow struct {
+	Serial       int64  `json:"serial"`
+	Pinned       bool   `json:"pinned"`
+	Note         string `json:"note,omitempty"`
+	EventId      string `json:"eventId"`
+	EventSerial  int64  `json:"eventSerial"`
 	Id           string `json:"id"`
 	Created      int64  `json:"created"`
 	Origin       int64  `json:"origin"`
@@ -37,6 +50,11 @@ type readingRow struct {
 	BinaryValue  string `json:"binaryValue"`
 	MediaType    string `json:"mediaType"`
 	Value        string `json:"value"`
+	ReceivedAt   int64  `json:"receivedAt"`
+	Latency      string `json:"latency"`
+	Anomaly      string `json:"anomaly,omitempty"`
+	// MatchedIn lists the keys of the fields the filter was found in, comma separated
+	MatchedIn string `json:"matchedIn,omitempty"`
 
 	Json string `json:"json"`
 }
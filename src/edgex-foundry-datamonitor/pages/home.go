@@ -79,7 +79,9 @@ func homeScreen(w fyne.Window, appManager *services.AppManager) fyne.CanvasObjec
 	case services.ClientConnected:
 		contentContainer = connectedContent
 		h.dashboardStats.Show()
-		h.tableContainer = container.NewMax(h.dashboardTable)
+		split := container.NewVSplit(h.dashboardTable, h.bottomTabs)
+		split.Offset = 0.35
+		h.tableContainer = container.NewMax(split)
 	case services.ClientConnecting:
 		contentContainer = connectingContent
 		h.dashboardStats.Hide()
@@ -0,0 +1,133 @@
+// Copyright 2021 Alessandro De Blasis <alex@deblasis.net>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+package pages
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/widget"
+	"github.com/deblasis/edgex-foundry-datamonitor/services"
+)
+
+const (
+	lastValueColDevice = iota
+	lastValueColResource
+	lastValueColValue
+	lastValueColTrend
+	lastValueColValueType
+	lastValueColAge
+)
+
+var lastValueHeaders = []string{"Device", "Resource", "Value", "Trend", "Value type", "Age"}
+
+// lastValuesClockInterval is how often the ages are redrawn, they must keep growing when nothing is received
+const lastValuesClockInterval = time.Second
+
+func (p *homePageHandler) renderLastValuesTable() *widget.Table {
+	t := widget.NewTable(
+		func() (int, int) {
+			p.lastValuesLock.RLock()
+			defer p.lastValuesLock.RUnlock()
+			return len(p.lastValues) + 1, len(lastValueHeaders)
+		},
+		func() fyne.CanvasObject {
+			return widget.NewLabel("")
+		},
+		func(i widget.TableCellID, o fyne.CanvasObject) {
+			p.lastValuesLock.RLock()
+			defer p.lastValuesLock.RUnlock()
+
+			label := o.(*widget.Label)
+			if i.Row == 0 {
+				label.TextStyle = fyne.TextStyle{Bold: true}
+				label.SetText(lastValueHeaders[i.Col])
+				return
+			}
+			label.TextStyle = fyne.TextStyle{Bold: false}
+			if i.Row > len(p.lastValues) {
+				label.SetText("")
+				return
+			}
+
+			row := p.lastValues[i.Row-1]
+			switch i.Col {
+			case lastValueColDevice:
+				label.SetText(row.Device)
+			case lastValueColResource:
+				label.SetText(row.Resource)
+			case lastValueColValue:
+				label.TextStyle = fyne.TextStyle{Bold: true}
+				label.SetText(lastValueText(row))
+			case lastValueColTrend:
+				label.SetText(row.Trend.String())
+			case lastValueColValueType:
+				label.SetText(row.ValueType)
+			case lastValueColAge:
+				label.SetText(fmt.Sprintf("%v", time.Since(row.ReceivedAt).Truncate(time.Second)))
+			}
+		},
+	)
+	t.SetColumnWidth(lastValueColDevice, 250)
+	t.SetColumnWidth(lastValueColResource, 200)
+	t.SetColumnWidth(lastValueColValue, 250)
+	t.SetColumnWidth(lastValueColTrend, 70)
+	t.SetColumnWidth(lastValueColValueType, 130)
+	t.SetColumnWidth(lastValueColAge, 100)
+	return t
+}
+
+// lastValueText shows what a binary reading carries rather than its bytes
+func lastValueText(v services.LastValue) string {
+	if v.Value == "" && v.MediaType != "" {
+		return fmt.Sprintf("binary (%v)", v.MediaType)
+	}
+	return v.Value
+}
+
+// RunLastValuesClock redraws the last values while they are shown so that their ages move
+// even when the bus goes quiet, it returns when the context is done
+func (p *homePageHandler) RunLastValuesClock(ctx context.Context) {
+	ticker := time.NewTicker(lastValuesClockInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+		if p.appState.GetCurrentPage() != HomePageKey || p.appState.GetHomePageTab() != homeLastValuesTab {
+			continue
+		}
+		if p.lastValuesTable != nil {
+			p.lastValuesTable.Refresh()
+		}
+	}
+}
+
+func (p *homePageHandler) updateLastValues() {
+	if p.lastValuesTable == nil {
+		return
+	}
+	values := p.appState.GetEventProcessor().LastValues()
+
+	p.lastValuesLock.Lock()
+	p.lastValues = values
+	p.lastValuesLock.Unlock()
+
+	p.lastValuesTable.Refresh()
+}
@@ -0,0 +1,186 @@
+// Copyright 2021 Alessandro De Blasis <alex@deblasis.net>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+package pages
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/widget"
+	"github.com/deblasis/edgex-foundry-datamonitor/services"
+)
+
+const (
+	throughputColName = iota
+	throughputColEventsPerSecond
+	throughputColReadingsPerSecond
+	throughputColTotalEvents
+	throughputColTotalReadings
+	throughputColLastSeen
+	throughputColLatencyP50
+	throughputColLatencyP95
+	throughputColLatencyP99
+	throughputColAnomalies
+)
+
+var throughputHeaders = []string{"Name", "Events/s", "Readings/s", "Total events", "Total readings", "Last seen", "Latency p50", "Latency p95", "Latency p99", "Anomalies"}
+
+var throughputDimensions = []services.ThroughputDimension{services.ByDevice, services.ByProfile, services.ByResource}
+
+func (p *homePageHandler) renderThroughputTable() *widget.Table {
+	t := widget.NewTable(
+		func() (int, int) {
+			p.throughputLock.RLock()
+			defer p.throughputLock.RUnlock()
+			return len(p.throughputRows) + 1, len(throughputHeaders)
+		},
+		func() fyne.CanvasObject {
+			return widget.NewLabel("")
+		},
+		func(i widget.TableCellID, o fyne.CanvasObject) {
+			p.throughputLock.RLock()
+			defer p.throughputLock.RUnlock()
+
+			label := o.(*widget.Label)
+			if i.Row == 0 {
+				label.TextStyle = fyne.TextStyle{Bold: true}
+				txt := throughputHeaders[i.Col]
+				if i.Col == p.throughputSortColumn {
+					txt = txt + sortIndicator(p.throughputSortAsc)
+				}
+				label.SetText(txt)
+				return
+			}
+			label.TextStyle = fyne.TextStyle{Bold: false}
+			if i.Row > len(p.throughputRows) {
+				label.SetText("")
+				return
+			}
+
+			row := p.throughputRows[i.Row-1]
+			switch i.Col {
+			case throughputColName:
+				label.SetText(row.Name)
+			case throughputColEventsPerSecond:
+				label.SetText(fmt.Sprintf("%.2f", row.EventsPerSecond))
+			case throughputColReadingsPerSecond:
+				label.SetText(fmt.Sprintf("%.2f", row.ReadingsPerSecond))
+			case throughputColTotalEvents:
+				label.SetText(fmt.Sprintf("%d", row.TotalEvents))
+			case throughputColTotalReadings:
+				label.SetText(fmt.Sprintf("%d", row.TotalReadings))
+			case throughputColLastSeen:
+				label.SetText(fmt.Sprintf("%v ago", time.Since(row.LastSeen).Truncate(time.Second)))
+			case throughputColLatencyP50:
+				label.SetText(latencyText(row.Latency.P50, row.Latency.Samples > 0))
+			case throughputColLatencyP95:
+				label.SetText(latencyText(row.Latency.P95, row.Latency.Samples > 0))
+			case throughputColLatencyP99:
+				label.SetText(latencyText(row.Latency.P99, row.Latency.Samples > 0))
+			case throughputColAnomalies:
+				label.SetText(fmt.Sprintf("%d", p.throughputAnomalies[row.Name]))
+			}
+		},
+	)
+	t.SetColumnWidth(throughputColName, 250)
+	for col := throughputColEventsPerSecond; col <= throughputColAnomalies; col++ {
+		t.SetColumnWidth(col, 130)
+	}
+	return t
+}
+
+// sortThroughputBy sorts by the column, tapping the same column again flips the order
+func (p *homePageHandler) sortThroughputBy(col int) {
+	p.throughputLock.Lock()
+	if p.throughputSortColumn == col {
+		p.throughputSortAsc = !p.throughputSortAsc
+	} else {
+		p.throughputSortColumn = col
+		// names read better A-Z, numbers are more interesting from the biggest
+		p.throughputSortAsc = col == throughputColName
+	}
+	p.throughputLock.Unlock()
+
+	p.updateThroughput()
+}
+
+func (p *homePageHandler) updateThroughput() {
+	if p.throughputTable == nil {
+		return
+	}
+
+	dimension := services.ByDevice
+	for _, d := range throughputDimensions {
+		if d.String() == p.throughputDimension.Selected {
+			dimension = d
+		}
+	}
+	rows := p.appState.GetEventProcessor().Throughput(dimension)
+	anomalies := p.appState.GetAnomalyDetector().GetAnomaliesCount(dimension)
+
+	p.throughputLock.Lock()
+	col, asc := p.throughputSortColumn, p.throughputSortAsc
+	sort.SliceStable(rows, func(i, j int) bool {
+		less := throughputLess(rows[i], rows[j], col, anomalies)
+		if asc {
+			return less
+		}
+		return throughputLess(rows[j], rows[i], col, anomalies)
+	})
+	p.throughputRows = rows
+	p.throughputAnomalies = anomalies
+	p.throughputLock.Unlock()
+
+	p.throughputTable.Refresh()
+}
+
+func throughputLess(a, b services.ThroughputStats, col int, anomalies map[string]int64) bool {
+	switch col {
+	case throughputColEventsPerSecond:
+		return a.EventsPerSecond < b.EventsPerSecond
+	case throughputColReadingsPerSecond:
+		return a.ReadingsPerSecond < b.ReadingsPerSecond
+	case throughputColTotalEvents:
+		return a.TotalEvents < b.TotalEvents
+	case throughputColTotalReadings:
+		return a.TotalReadings < b.TotalReadings
+	case throughputColLastSeen:
+		return a.LastSeen.Before(b.LastSeen)
+	case throughputColLatencyP50:
+		return a.Latency.P50 < b.Latency.P50
+	case throughputColLatencyP95:
+		return a.Latency.P95 < b.Latency.P95
+	case throughputColLatencyP99:
+		return a.Latency.P99 < b.Latency.P99
+	case throughputColAnomalies:
+		return anomalies[a.Name] < anomalies[b.Name]
+	}
+	return strings.ToLower(a.Name) < strings.ToLower(b.Name)
+}
+
+func (p *homePageHandler) renderThroughputPanel() *fyne.Container {
+	return container.NewBorder(
+		container.NewHBox(
+			widget.NewLabelWithStyle("Throughput by", fyne.TextAlignLeading, fyne.TextStyle{Bold: true}),
+			p.throughputDimension,
+		),
+		nil, nil, nil,
+		p.throughputTable,
+	)
+}
@@ -15,7 +15,9 @@
 package pages
 
 import (
+	"context"
 	"encoding/json"
+	"fmt"
 	"sort"
 	"sync"
 	"time"
@@ -24,28 +26,72 @@ import (
 	"fyne.io/fyne/v2/container"
 	"fyne.io/fyne/v2/data/binding"
 	"fyne.io/fyne/v2/layout"
+	"fyne.io/fyne/v2/theme"
 	"fyne.io/fyne/v2/widget"
 	"github.com/deblasis/edgex-foundry-datamonitor/config"
 	"github.com/deblasis/edgex-foundry-datamonitor/services"
 	"github.com/edgexfoundry/go-mod-core-contracts/v2/dtos"
 )
 
+const (
+	homeThroughputTab = "Throughput"
+	homeLastValuesTab = "Last values"
+)
+
+const (
+	lastEventsColDeviceName = iota
+	lastEventsColProfileName
+	lastEventsColReadings
+	lastEventsColOrigin
+)
+
+var lastEventsHeaders = []string{"Device Name", "Profile Name", "Readings", "Origin Timestamp"}
+
 type homePageHandler struct {
 	appState *services.AppManager
 
 	Key widget.TreeNodeID
 
-	totalNumberEventsBinding   binding.ExternalInt
-	totalNumberReadingsBinding binding.ExternalInt
+	totalNumberEventsBinding   binding.Int
+	totalNumberReadingsBinding binding.Int
+	sinceBinding               binding.String
+	resetCountersBtn           *widget.Button
 
-	eventsPerSecondLastMinute   binding.ExternalFloat
-	readingsPerSecondLastMinute binding.ExternalFloat
+	rateWindow                   *widget.Select
+	eventsPerSecondBinding       binding.Float
+	readingsPerSecondBinding     binding.Float
+	peakEventsPerSecondBinding   binding.Float
+	peakReadingsPerSecondBinding binding.Float
+
+	latencyP50Binding binding.String
+	latencyP95Binding binding.String
+	latencyP99Binding binding.String
+	latencyMaxBinding binding.String
+
+	duplicatesBinding      binding.ExternalInt
+	duplicatesRatioBinding binding.String
+
+	anomaliesBinding binding.String
 
 	//eventsTable    fyne.CanvasObject
 	dashboardTable               *widget.Table
 	dashboardTableDataMapBinding *[]binding.DataMap
 	dashboardTableLock           sync.Mutex
 
+	throughputDimension  *widget.RadioGroup
+	throughputTable      *widget.Table
+	throughputRows       []services.ThroughputStats
+	throughputAnomalies  map[string]int64
+	throughputSortColumn int
+	throughputSortAsc    bool
+	throughputLock       sync.RWMutex
+
+	lastValuesTable *widget.Table
+	lastValues      []services.LastValue
+	lastValuesLock  sync.RWMutex
+
+	bottomTabs *container.AppTabs
+
 	tableContainer *fyne.Container
 	dashboardStats *fyne.Container
 }
@@ -54,8 +100,26 @@ func NewHomePageHandler(appState *services.AppManager) *homePageHandler {
 	p := &homePageHandler{
 		Key:      HomePageKey,
 		appState: appState,
+
+		throughputSortColumn: throughputColEventsPerSecond,
 	}
 
+	dimensions := make([]string, 0, len(throughputDimensions))
+	for _, d := range throughputDimensions {
+		dimensions = append(dimensions, d.String())
+	}
+	p.throughputDimension = widget.NewRadioGroup(dimensions, func(string) {})
+	p.throughputDimension.Horizontal = true
+	p.throughputDimension.Required = true
+	p.throughputDimension.SetSelected(services.ByDevice.String())
+
+	windows := make([]string, 0, len(services.DefaultRateWindows))
+	for _, w := range services.DefaultRateWindows {
+		windows = append(windows, formatWindow(w))
+	}
+	p.rateWindow = widget.NewSelect(windows, func(string) {})
+	p.resetCountersBtn = widget.NewButtonWithIcon("Reset counters", theme.ViewRefreshIcon(), func() {})
+
 	p.updateTable()
 
 	return p
@@ -63,31 +127,125 @@ func NewHomePageHandler(appState *services.AppManager) *homePageHandler {
 func (p *homePageHandler) SetInitialState() {
 	p.dashboardTableDataMapBinding = &[]binding.DataMap{}
 	p.dashboardTable = p.renderDashboardTable()
+	p.updateTable()
 	p.tableContainer = container.NewMax(p.dashboardTable)
 
+	p.throughputTable = p.renderThroughputTable()
+	p.lastValuesTable = p.renderLastValuesTable()
+	p.bottomTabs = container.NewAppTabs(
+		container.NewTabItem(homeThroughputTab, p.renderThroughputPanel()),
+		container.NewTabItem(homeLastValuesTab, p.lastValuesTable),
+	)
+}
+func (p *homePageHandler) RehydrateSession() {
+	p.rateWindow.Selected = formatWindow(p.appState.GetHomePageRateWindow())
+	for _, tab := range p.bottomTabs.Items {
+		if tab.Text == p.appState.GetHomePageTab() {
+			p.bottomTabs.Select(tab)
+		}
+	}
 }
-func (p *homePageHandler) RehydrateSession() {}
 func (p *homePageHandler) SetupBindings() {
-	eventProcessor := p.appState.GetEventProcessor()
+	p.totalNumberEventsBinding = binding.NewInt()
+	p.totalNumberReadingsBinding = binding.NewInt()
+	p.sinceBinding = binding.NewString()
+	p.updateCounters()
+	p.resetCountersBtn.OnTapped = func() {
+		p.appState.ResetCounters()
+		p.updateStats()
+	}
+
+	p.eventsPerSecondBinding = binding.NewFloat()
+	p.readingsPerSecondBinding = binding.NewFloat()
+	p.peakEventsPerSecondBinding = binding.NewFloat()
+	p.peakReadingsPerSecondBinding = binding.NewFloat()
+	p.rateWindow.OnChanged = func(selected string) {
+		for _, w := range services.DefaultRateWindows {
+			if formatWindow(w) == selected {
+				p.appState.SetHomePageRateWindow(w)
+			}
+		}
+		p.updateRates()
+	}
+	p.updateRates()
+
+	p.throughputDimension.OnChanged = func(string) {
+		p.updateThroughput()
+	}
+	p.throughputTable.OnSelected = func(id widget.TableCellID) {
+		defer p.throughputTable.UnselectAll()
+		if id.Row == 0 {
+			p.sortThroughputBy(id.Col)
+		}
+	}
+	p.updateThroughput()
+
+	p.updateLastValues()
+	p.bottomTabs.OnSelected = func(tab *container.TabItem) {
+		p.appState.SetHomePageTab(tab.Text)
+	}
 
-	p.totalNumberEventsBinding = binding.BindInt(config.Int(eventProcessor.TotalNumberEvents))
-	p.totalNumberReadingsBinding = binding.BindInt(config.Int(eventProcessor.TotalNumberReadings))
+	p.latencyP50Binding = binding.NewString()
+	p.latencyP95Binding = binding.NewString()
+	p.latencyP99Binding = binding.NewString()
+	p.latencyMaxBinding = binding.NewString()
+	p.updateLatency()
 
-	p.eventsPerSecondLastMinute = binding.BindFloat(config.Float(eventProcessor.EventsPerSecondLastMinute))
-	p.readingsPerSecondLastMinute = binding.BindFloat(config.Float(eventProcessor.ReadingsPerSecondLastMinute))
+	p.duplicatesBinding = binding.BindInt(config.Int(int(p.appState.GetDB().GetDuplicatesCount())))
+	p.duplicatesRatioBinding = binding.NewString()
+	p.updateDuplicatesRatio()
 
-	p.dashboardStats = container.NewCenter(container.NewGridWithRows(3,
+	p.anomaliesBinding = binding.NewString()
+	p.updateAnomalies()
+
+	p.dashboardStats = container.NewCenter(container.NewGridWithRows(9,
 		container.NewGridWithColumns(4,
 			widget.NewLabelWithStyle("Total Number of Events", fyne.TextAlignLeading, fyne.TextStyle{Bold: true}),
 			widget.NewLabelWithData(binding.IntToString(p.totalNumberEventsBinding)),
 			widget.NewLabelWithStyle("Total Number of Readings", fyne.TextAlignLeading, fyne.TextStyle{Bold: true}),
 			widget.NewLabelWithData(binding.IntToString(p.totalNumberReadingsBinding)),
 		),
+		container.NewGridWithColumns(4,
+			widget.NewLabelWithStyle("Rates averaged over", fyne.TextAlignLeading, fyne.TextStyle{Bold: true}),
+			p.rateWindow,
+			widget.NewLabelWithData(p.sinceBinding),
+			p.resetCountersBtn,
+		),
 		container.NewGridWithColumns(4,
 			widget.NewLabelWithStyle("Events per second", fyne.TextAlignLeading, fyne.TextStyle{Bold: true}),
-			widget.NewLabelWithData(binding.FloatToString(p.eventsPerSecondLastMinute)),
+			widget.NewLabelWithData(binding.FloatToStringWithFormat(p.eventsPerSecondBinding, "%.2f")),
 			widget.NewLabelWithStyle("Readings per second", fyne.TextAlignLeading, fyne.TextStyle{Bold: true}),
-			widget.NewLabelWithData(binding.FloatToString(p.readingsPerSecondLastMinute)),
+			widget.NewLabelWithData(binding.FloatToStringWithFormat(p.readingsPerSecondBinding, "%.2f")),
+		),
+		container.NewGridWithColumns(4,
+			widget.NewLabelWithStyle("Peak events per second", fyne.TextAlignLeading, fyne.TextStyle{Bold: true}),
+			widget.NewLabelWithData(binding.FloatToStringWithFormat(p.peakEventsPerSecondBinding, "%.2f")),
+			widget.NewLabelWithStyle("Peak readings per second", fyne.TextAlignLeading, fyne.TextStyle{Bold: true}),
+			widget.NewLabelWithData(binding.FloatToStringWithFormat(p.peakReadingsPerSecondBinding, "%.2f")),
+		),
+		container.NewGridWithColumns(4,
+			widget.NewLabelWithStyle("Latency p50", fyne.TextAlignLeading, fyne.TextStyle{Bold: true}),
+			widget.NewLabelWithData(p.latencyP50Binding),
+			widget.NewLabelWithStyle("Latency p95", fyne.TextAlignLeading, fyne.TextStyle{Bold: true}),
+			widget.NewLabelWithData(p.latencyP95Binding),
+		),
+		container.NewGridWithColumns(4,
+			widget.NewLabelWithStyle("Latency p99", fyne.TextAlignLeading, fyne.TextStyle{Bold: true}),
+			widget.NewLabelWithData(p.latencyP99Binding),
+			widget.NewLabelWithStyle("Max latency", fyne.TextAlignLeading, fyne.TextStyle{Bold: true}),
+			widget.NewLabelWithData(p.latencyMaxBinding),
+		),
+		container.NewGridWithColumns(4,
+			widget.NewLabelWithStyle("Duplicate events", fyne.TextAlignLeading, fyne.TextStyle{Bold: true}),
+			widget.NewLabelWithData(binding.IntToString(p.duplicatesBinding)),
+			widget.NewLabelWithStyle("Duplicates ratio", fyne.TextAlignLeading, fyne.TextStyle{Bold: true}),
+			widget.NewLabelWithData(p.duplicatesRatioBinding),
+		),
+		container.NewGridWithColumns(4,
+			widget.NewLabelWithStyle("Anomalous readings", fyne.TextAlignLeading, fyne.TextStyle{Bold: true}),
+			widget.NewLabelWithData(p.anomaliesBinding),
+			layout.NewSpacer(),
+			layout.NewSpacer(),
 		),
 		layout.NewSpacer(),
 	))
@@ -98,11 +256,8 @@ func (p *homePageHandler) OnEventReceived(event dtos.Event) {
 	if p.appState.GetConnectionState() != services.ClientConnected {
 		return
 	}
-	eventProcessor := p.appState.GetEventProcessor()
-	p.totalNumberEventsBinding.Set(eventProcessor.TotalNumberEvents)
-	p.totalNumberReadingsBinding.Set(eventProcessor.TotalNumberReadings)
-	p.eventsPerSecondLastMinute.Set(eventProcessor.EventsPerSecondLastMinute)
-	p.readingsPerSecondLastMinute.Set(eventProcessor.ReadingsPerSecondLastMinute)
+	p.updateStats()
+	p.updateLastValues()
 
 	p.updateTable()
 	if p.dashboardTable != nil {
@@ -111,6 +266,96 @@ func (p *homePageHandler) OnEventReceived(event dtos.Event) {
 
 }
 
+// updateStats refreshes everything that is measured, but the last events
+func (p *homePageHandler) updateStats() {
+	p.updateCounters()
+	p.updateRates()
+	p.updateLatency()
+	p.duplicatesBinding.Set(int(p.appState.GetDB().GetDuplicatesCount()))
+	p.updateDuplicatesRatio()
+	p.updateAnomalies()
+	p.updateThroughput()
+}
+
+func (p *homePageHandler) updateCounters() {
+	stats := p.appState.GetEventProcessor().Stats()
+	p.totalNumberEventsBinding.Set(int(stats.TotalEvents))
+	p.totalNumberReadingsBinding.Set(int(stats.TotalReadings))
+	p.sinceBinding.Set(fmt.Sprintf("Measuring since %v", stats.Since.Format("15:04:05")))
+}
+
+// ratesClockInterval is how often the rates are recomputed, they must fall back to zero when nothing is received
+const ratesClockInterval = time.Second
+
+// RunRatesClock recomputes the rates while Home is shown so that they decay and the peaks
+// settle even when the bus goes quiet, it returns when the context is done
+func (p *homePageHandler) RunRatesClock(ctx context.Context) {
+	ticker := time.NewTicker(ratesClockInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+		if p.appState.GetCurrentPage() != HomePageKey {
+			continue
+		}
+		p.updateRates()
+	}
+}
+
+func (p *homePageHandler) updateRates() {
+	stats, ok := p.appState.GetEventProcessor().RatesOver(p.appState.GetHomePageRateWindow())
+	if !ok {
+		return
+	}
+	p.eventsPerSecondBinding.Set(stats.EventsPerSecond)
+	p.readingsPerSecondBinding.Set(stats.ReadingsPerSecond)
+	p.peakEventsPerSecondBinding.Set(stats.PeakEventsPerSecond)
+	p.peakReadingsPerSecondBinding.Set(stats.PeakReadingsPerSecond)
+}
+
+// updateLatency shows the percentiles of the latest events, "-" until an event with an origin comes in
+func (p *homePageHandler) updateLatency() {
+	stats := p.appState.GetEventProcessor().Latency()
+	set := func(b binding.String, v time.Duration) {
+		if stats.Samples == 0 {
+			b.Set("-")
+			return
+		}
+		b.Set(formatLatency(v))
+	}
+	set(p.latencyP50Binding, stats.P50)
+	set(p.latencyP95Binding, stats.P95)
+	set(p.latencyP99Binding, stats.P99)
+	set(p.latencyMaxBinding, stats.Max)
+}
+
+// formatWindow renders 10s, 1m, 5m rather than 1m0s
+func formatWindow(w time.Duration) string {
+	if w%time.Minute == 0 {
+		return fmt.Sprintf("%dm", w/time.Minute)
+	}
+	return fmt.Sprintf("%ds", w/time.Second)
+}
+
+func (p *homePageHandler) updateAnomalies() {
+	anomalies := p.appState.GetAnomalyDetector()
+	p.anomaliesBinding.Set(fmt.Sprintf("%d (beyond %v sigma)", anomalies.GetTotalAnomaliesCount(), anomalies.GetSigma()))
+}
+
+// updateDuplicatesRatio shows the share of the received events that were dropped as duplicates
+func (p *homePageHandler) updateDuplicatesRatio() {
+	total := p.appState.GetEventProcessor().Stats().TotalEvents
+	if total == 0 {
+		p.duplicatesRatioBinding.Set("-")
+		return
+	}
+	duplicates := p.appState.GetDB().GetDuplicatesCount()
+	p.duplicatesRatioBinding.Set(fmt.Sprintf("%.2f%%", float64(duplicates)*100/float64(total)))
+}
+
 func (p *homePageHandler) updateTable() {
 	sortAsc := fyne.CurrentApp().Preferences().BoolWithFallback(config.PrefEventsTableSortOrderAscending, config.DefaultEventsTableSortOrderAscending)
 
@@ -150,54 +395,47 @@ func (p *homePageHandler) updateTable() {
 func (p *homePageHandler) renderDashboardTable() *widget.Table {
 
 	table := widget.NewTable(
-		func() (int, int) { return 6, 2 },
+		func() (int, int) {
+			p.dashboardTableLock.Lock()
+			defer p.dashboardTableLock.Unlock()
+			return len(*p.dashboardTableDataMapBinding) + 1, len(lastEventsHeaders)
+		},
 		func() fyne.CanvasObject {
 			return widget.NewLabel("")
 		},
 		func(i widget.TableCellID, o fyne.CanvasObject) {
 			label := o.(*widget.Label)
-			switch i.Row {
-			case 0:
-				switch i.Col {
-				// case 0:
-				// 	label.SetText("ID")
-				case 0:
-					label.SetText("Device Name")
-					label.TextStyle = fyne.TextStyle{Bold: true}
-				case 1:
-					label.SetText("Origin Timestamp")
-					label.TextStyle = fyne.TextStyle{Bold: true}
-				default:
-					label.SetText("")
-				}
-
-			default:
-				label.TextStyle = fyne.TextStyle{Bold: false}
-
-				dm := *p.dashboardTableDataMapBinding
-				if dm == nil || i.Row > len(dm) {
-					label.SetText("")
-					break
-				}
-
-				row := dm[i.Row-1]
-
-				switch i.Col {
-				case 0:
-					id, _ := row.GetItem("DeviceName")
-					o.(*widget.Label).Bind(id.(binding.String))
-				case 1:
-					origin, _ := row.GetItem("Origin")
-					v, _ := origin.(binding.Int).Get()
-					o.(*widget.Label).SetText(time.Unix(0, int64(v)).String())
-				default:
-					label.SetText("")
-				}
+			if i.Row == 0 {
+				label.TextStyle = fyne.TextStyle{Bold: true}
+				label.SetText(lastEventsHeaders[i.Col])
+				return
+			}
+			label.TextStyle = fyne.TextStyle{Bold: false}
+
+			p.dashboardTableLock.Lock()
+			dm := *p.dashboardTableDataMapBinding
+			p.dashboardTableLock.Unlock()
+			if i.Row > len(dm) {
+				label.SetText("")
+				return
 			}
 
+			row := dm[i.Row-1]
+			switch i.Col {
+			case lastEventsColDeviceName:
+				label.SetText(getString(row, "DeviceName"))
+			case lastEventsColProfileName:
+				label.SetText(getString(row, "ProfileName"))
+			case lastEventsColReadings:
+				label.SetText(fmt.Sprintf("%d", getInt(row, "ReadingsCount")))
+			case lastEventsColOrigin:
+				label.SetText(time.Unix(0, getInt(row, "Origin")).String())
+			}
 		})
-	table.SetColumnWidth(0, 350)
-	table.SetColumnWidth(1, 350)
+	table.SetColumnWidth(lastEventsColDeviceName, 300)
+	table.SetColumnWidth(lastEventsColProfileName, 250)
+	table.SetColumnWidth(lastEventsColReadings, 100)
+	table.SetColumnWidth(lastEventsColOrigin, 350)
 
 	return table
 }
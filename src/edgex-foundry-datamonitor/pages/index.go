@@ -26,20 +26,31 @@ type Page struct {
 }
 
 var (
-	Pages = map[widget.TreeNodeID]Page{
-		HomePageKey:     {Title: "Home", Intro: "", View: homeScreen},
-		DataPageKey:     {Title: "Data", Intro: "", View: dataScreen},
-		SettingsPageKey: {Title: "Settings", Intro: "", View: settingsScreen},
-	}
+	// Pages is filled in init, the Data page lists the saved searches in it
+	Pages map[widget.TreeNodeID]Page
 
 	//PageIndex  defines how our pages should be laid out in the index tree
 	PageIndex = map[widget.TreeNodeID][]widget.TreeNodeID{
-		"": {HomePageKey, DataPageKey, SettingsPageKey},
+		"": {HomePageKey, DevicesPageKey, DataPageKey, ChartsPageKey, AlertsPageKey, SettingsPageKey},
 	}
 )
 
+func init() {
+	Pages = map[widget.TreeNodeID]Page{
+		HomePageKey:     {Title: "Home", Intro: "", View: homeScreen},
+		DataPageKey:     {Title: "Data", Intro: "", View: dataScreen},
+		DevicesPageKey:  {Title: "Devices", Intro: "", View: devicesScreen},
+		ChartsPageKey:   {Title: "Charts", Intro: "", View: chartsScreen},
+		AlertsPageKey:   {Title: "Alerts", Intro: "", View: alertsScreen},
+		SettingsPageKey: {Title: "Settings", Intro: "", View: settingsScreen},
+	}
+}
+
 const (
 	HomePageKey     widget.TreeNodeID = "home"
 	DataPageKey     widget.TreeNodeID = "data"
+	DevicesPageKey  widget.TreeNodeID = "devices"
+	ChartsPageKey   widget.TreeNodeID = "charts"
+	AlertsPageKey   widget.TreeNodeID = "alerts"
 	SettingsPageKey widget.TreeNodeID = "settings"
 )
@@ -15,6 +15,7 @@
 package pages
 
 import (
+	"context"
 	"fmt"
 	"strconv"
 	"strings"
@@ -25,6 +26,7 @@ import (
 	"fyne.io/fyne/v2/container"
 	"fyne.io/fyne/v2/data/binding"
 	"fyne.io/fyne/v2/data/validation"
+	"fyne.io/fyne/v2/dialog"
 	"fyne.io/fyne/v2/widget"
 	"github.com/deblasis/edgex-foundry-datamonitor/config"
 	"github.com/deblasis/edgex-foundry-datamonitor/data"
@@ -51,6 +53,31 @@ func settingsScreen(win fyne.Window, appState *services.AppManager) fyne.CanvasO
 	dataPageBufferSize.SetPlaceHolder("* required")
 	dataPageBufferSize.Validator = data.MinMaxValidator(config.MinBufferSize, config.MaxBufferSize, data.ErrInvalidBufferSize)
 
+	deduplicateEvents := widget.NewCheckWithData("Drop duplicate events", binding.NewBool())
+	deduplicationWindow := widget.NewEntry()
+	deduplicationWindow.SetPlaceHolder("* required")
+	deduplicationWindow.Validator = data.MinMaxValidator(config.MinDeduplicationWindowSeconds, config.MaxDeduplicationWindowSeconds, data.ErrInvalidDeduplicationWindow)
+
+	staleDeviceFactor := widget.NewEntry()
+	staleDeviceFactor.SetPlaceHolder("* required")
+	staleDeviceFactor.Validator = data.MinMaxValidator(config.MinStaleDeviceFactor, config.MaxStaleDeviceFactor, data.ErrInvalidStaleDeviceFactor)
+	deviceExpectedIntervals := widget.NewEntry()
+	deviceExpectedIntervals.SetPlaceHolder("e.g. Random-Integer-Device=10s, thermostat=5m")
+	deviceExpectedIntervals.Validator = data.ExpectedIntervalsValidator
+
+	lastEventsCount := widget.NewEntry()
+	lastEventsCount.SetPlaceHolder("* required")
+	lastEventsCount.Validator = data.MinMaxValidator(config.MinLastEventsCount, config.MaxLastEventsCount, data.ErrInvalidLastEventsCount)
+
+	anomalySigma := widget.NewEntry()
+	anomalySigma.SetPlaceHolder("* required")
+	anomalySigma.Validator = data.MinMaxFloatValidator(config.MinAnomalySigma, config.MaxAnomalySigma, data.ErrInvalidAnomalySigma)
+
+	serveMetrics := widget.NewCheckWithData("Serve Prometheus metrics", binding.NewBool())
+	metricsPort := widget.NewEntry()
+	metricsPort.SetPlaceHolder("* required")
+	metricsPort.Validator = data.MinMaxValidator(config.MinMetricsPort, config.MaxMetricsPort, data.ErrInvalidMetricsPort)
+
 	//read from settings
 	hostname.SetText(preferences.StringWithFallback(config.PrefRedisHost, config.RedisDefaultHost))
 
@@ -58,6 +85,14 @@ func settingsScreen(win fyne.Window, appState *services.AppManager) fyne.CanvasO
 	shouldConnectAutomatically.SetChecked(preferences.BoolWithFallback(config.PrefShouldConnectAtStartup, config.DefaultShouldConnectAtStartup))
 	eventsSortedAscendingly.SetChecked(preferences.BoolWithFallback(config.PrefEventsTableSortOrderAscending, config.DefaultEventsTableSortOrderAscending))
 	dataPageBufferSize.SetText(fmt.Sprintf("%d", preferences.IntWithFallback(config.PrefBufferSizeInDataPage, config.DefaultBufferSizeInDataPage)))
+	lastEventsCount.SetText(fmt.Sprintf("%d", preferences.IntWithFallback(config.PrefLastEventsCount, config.DefaultLastEventsCount)))
+	deduplicateEvents.SetChecked(preferences.BoolWithFallback(config.PrefDeduplicateEvents, config.DefaultDeduplicateEvents))
+	deduplicationWindow.SetText(fmt.Sprintf("%d", preferences.IntWithFallback(config.PrefDeduplicationWindowSeconds, config.DefaultDeduplicationWindowSeconds)))
+	staleDeviceFactor.SetText(fmt.Sprintf("%d", preferences.IntWithFallback(config.PrefStaleDeviceFactor, config.DefaultStaleDeviceFactor)))
+	deviceExpectedIntervals.SetText(preferences.String(config.PrefDeviceExpectedIntervals))
+	anomalySigma.SetText(strconv.FormatFloat(preferences.FloatWithFallback(config.PrefAnomalySigma, config.DefaultAnomalySigma), 'f', -1, 64))
+	serveMetrics.SetChecked(preferences.BoolWithFallback(config.PrefMetricsEnabled, config.DefaultMetricsEnabled))
+	metricsPort.SetText(fmt.Sprintf("%d", preferences.IntWithFallback(config.PrefMetricsPort, config.DefaultMetricsPort)))
 
 	form := &widget.Form{
 		Items: []*widget.FormItem{
@@ -71,9 +106,25 @@ func settingsScreen(win fyne.Window, appState *services.AppManager) fyne.CanvasO
 			{
 				Text:     "",
 				Widget:   eventsSortedAscendingly,
-				HintText: "",
+				HintText: "By origin, until a column header is tapped in the Data page",
 			},
 			{Text: "Initial buffer size in Data page", Widget: dataPageBufferSize},
+			{Text: "Last events on Home page", Widget: lastEventsCount},
+			{
+				Text:     "",
+				Widget:   deduplicateEvents,
+				HintText: "",
+			},
+			{Text: "Deduplication window", Widget: deduplicationWindow, HintText: "Seconds during which an event id is remembered"},
+			{Text: "Stale device after", Widget: staleDeviceFactor, HintText: "Expected intervals without events before a device is stale"},
+			{Text: "Expected intervals", Widget: deviceExpectedIntervals, HintText: "Devices whose cadence shouldn't be learned"},
+			{Text: "Anomaly threshold", Widget: anomalySigma, HintText: "Standard deviations from the moving mean"},
+			{
+				Text:     "",
+				Widget:   serveMetrics,
+				HintText: "",
+			},
+			{Text: "Metrics port", Widget: metricsPort, HintText: fmt.Sprintf("Scraped at http://<host>:<port>%v", services.MetricsPath)},
 		},
 		OnSubmit: func() {
 			log.Info("Settings form submitted")
@@ -89,6 +140,29 @@ func settingsScreen(win fyne.Window, appState *services.AppManager) fyne.CanvasO
 			bufferSize, _ := strconv.Atoi(dataPageBufferSize.Text)
 			preferences.SetInt(config.PrefBufferSizeInDataPage, bufferSize)
 
+			lastEvents, _ := strconv.Atoi(lastEventsCount.Text)
+			preferences.SetInt(config.PrefLastEventsCount, lastEvents)
+			appState.GetEventProcessor().SetLastEventsCount(lastEvents)
+
+			preferences.SetBool(config.PrefDeduplicateEvents, deduplicateEvents.Checked)
+			window, _ := strconv.Atoi(deduplicationWindow.Text)
+			preferences.SetInt(config.PrefDeduplicationWindowSeconds, window)
+			appState.GetDB().SetDeduplicationWindow(appState.GetConfig().GetDeduplicationWindow())
+
+			factor, _ := strconv.Atoi(staleDeviceFactor.Text)
+			preferences.SetInt(config.PrefStaleDeviceFactor, factor)
+			preferences.SetString(config.PrefDeviceExpectedIntervals, strings.TrimSpace(deviceExpectedIntervals.Text))
+			appState.GetEventProcessor().SetStaleDetection(float64(factor), appState.GetConfig().GetDeviceExpectedIntervals())
+
+			sigma, _ := strconv.ParseFloat(anomalySigma.Text, 64)
+			preferences.SetFloat(config.PrefAnomalySigma, sigma)
+			appState.GetAnomalyDetector().SetSigma(sigma)
+
+			preferences.SetBool(config.PrefMetricsEnabled, serveMetrics.Checked)
+			mPort, _ := strconv.Atoi(metricsPort.Text)
+			preferences.SetInt(config.PrefMetricsPort, mPort)
+			applyMetricsSettings(win, appState)
+
 			a.SendNotification(&fyne.Notification{
 				Title:   "EdgeX Redis Pub/Sub Connection Settings",
 				Content: fmt.Sprintf("%v:%v", hostname.Text, port.Text),
@@ -104,6 +178,14 @@ func settingsScreen(win fyne.Window, appState *services.AppManager) fyne.CanvasO
 
 		shouldConnectAutomatically.SetChecked(config.DefaultShouldConnectAtStartup)
 		eventsSortedAscendingly.SetChecked(config.DefaultEventsTableSortOrderAscending)
+		lastEventsCount.SetText(fmt.Sprintf("%d", config.DefaultLastEventsCount))
+		deduplicateEvents.SetChecked(config.DefaultDeduplicateEvents)
+		deduplicationWindow.SetText(fmt.Sprintf("%d", config.DefaultDeduplicationWindowSeconds))
+		staleDeviceFactor.SetText(fmt.Sprintf("%d", config.DefaultStaleDeviceFactor))
+		deviceExpectedIntervals.SetText("")
+		anomalySigma.SetText(strconv.FormatFloat(config.DefaultAnomalySigma, 'f', -1, 64))
+		serveMetrics.SetChecked(config.DefaultMetricsEnabled)
+		metricsPort.SetText(fmt.Sprintf("%d", config.DefaultMetricsPort))
 
 		hostname.Validate()
 		port.Validate()
@@ -121,3 +203,24 @@ func settingsScreen(win fyne.Window, appState *services.AppManager) fyne.CanvasO
 		))
 
 }
+
+// applyMetricsSettings starts, moves or stops the metrics server as configured
+func applyMetricsSettings(win fyne.Window, appState *services.AppManager) {
+	metrics := appState.GetMetricsServer()
+	if metrics == nil {
+		return
+	}
+	port := appState.GetConfig().GetMetricsPort()
+	if port == metrics.Port() {
+		return
+	}
+	if port == 0 {
+		if err := metrics.Stop(context.Background()); err != nil {
+			log.Errorf("cannot stop the metrics server: %v", err)
+		}
+		return
+	}
+	if err := metrics.Start(port); err != nil {
+		dialog.ShowError(fmt.Errorf("Cannot serve the metrics on port %d: %v", port, err), win)
+	}
+}
@@ -0,0 +1,168 @@
+// Copyright 2021 Alessandro De Blasis <alex@deblasis.net>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+package pages
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"fyne.io/fyne/v2/data/binding"
+	"github.com/deblasis/edgex-foundry-datamonitor/services"
+)
+
+const (
+	// pinColumn toggles the pin when tapped instead of opening the detail
+	pinColumn      = 0
+	pinColumnWidth = 70
+)
+
+func pinText(pinned bool) string {
+	if pinned {
+		return "pinned"
+	}
+	return "pin"
+}
+
+func newEventRow(row services.EventRecord) eventRow {
+	tags, _ := json.MarshalIndent(row.Tags, "", "    ")
+	eventJson, _ := json.MarshalIndent(row.Event, "", "    ")
+	return eventRow{
+		Serial:        row.Serial,
+		Pinned:        row.Pinned,
+		Note:          row.Note,
+		Copies:        row.Copies,
+		Id:            row.Id,
+		DeviceName:    row.DeviceName,
+		ProfileName:   row.ProfileName,
+		Created:       row.Created,
+		Origin:        row.Origin,
+		ReadingsCount: int64(len(row.Readings)),
+		ReceivedAt:    row.ReceivedAt,
+		Latency:       latencyText(row.Latency()),
+		Tags:          string(tags),
+		MatchedIn:     matchedInKeys(row.MatchedIn),
+		Json:          string(eventJson),
+	}
+}
+
+func newReadingRow(row services.ReadingRecord) readingRow {
+	readingJson, _ := json.MarshalIndent(row.BaseReading, "", "    ")
+	return readingRow{
+		Serial:       row.Serial,
+		Pinned:       row.Pinned,
+		Note:         row.Note,
+		EventId:      row.EventId,
+		EventSerial:  row.EventSerial,
+		Id:           row.Id,
+		Created:      row.Created,
+		Origin:       row.Origin,
+		DeviceName:   row.DeviceName,
+		ProfileName:  row.ProfileName,
+		ResourceName: row.ResourceName,
+		ValueType:    row.ValueType,
+		BinaryValue:  string(row.BinaryValue),
+		MediaType:    row.MediaType,
+		Value:        row.Value,
+		ReceivedAt:   row.ReceivedAt,
+		Latency:      latencyText(row.Latency()),
+		MatchedIn:    matchedInKeys(row.MatchedIn),
+		Json:         string(readingJson),
+	}
+}
+
+// formatLatency keeps about three significant digits, a negative latency means that the clocks are skewed
+func formatLatency(d time.Duration) string {
+	abs := d
+	if abs < 0 {
+		abs = -abs
+	}
+	switch {
+	case abs >= time.Second:
+		d = d.Round(time.Millisecond)
+	case abs >= time.Millisecond:
+		d = d.Round(10 * time.Microsecond)
+	default:
+		d = d.Round(time.Microsecond)
+	}
+	return d.String()
+}
+
+func latencyText(latency time.Duration, ok bool) string {
+	if !ok {
+		return ""
+	}
+	return formatLatency(latency)
+}
+
+func hasAnomalies(anomalies *services.AnomalyDetector, event services.EventRecord) bool {
+	for _, r := range event.Readings {
+		if _, ok := anomalies.GetAnomaly(r.Id); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// anomalyText is the z-score of the reading, empty unless it was flagged
+func anomalyText(anomaly services.Anomaly, ok bool) string {
+	if !ok {
+		return ""
+	}
+	return fmt.Sprintf("z %.1f", anomaly.ZScore)
+}
+
+// sortIndicator is appended to the header of the column the table is sorted by
+func sortIndicator(asc bool) string {
+	if asc {
+		return " ^"
+	}
+	return " v"
+}
+
+// copiesText is empty unless the event was received more than once
+func copiesText(copies int64) string {
+	if copies < 2 {
+		return ""
+	}
+	return fmt.Sprintf("x%d", copies)
+}
+
+func getString(row binding.DataMap, key string) string {
+	item, err := row.GetItem(key)
+	if err != nil {
+		return ""
+	}
+	v, _ := item.(binding.String).Get()
+	return v
+}
+
+func getInt(row binding.DataMap, key string) int64 {
+	item, err := row.GetItem(key)
+	if err != nil {
+		return 0
+	}
+	v, _ := item.(binding.Int).Get()
+	return int64(v)
+}
+
+func getBool(row binding.DataMap, key string) bool {
+	item, err := row.GetItem(key)
+	if err != nil {
+		return false
+	}
+	v, _ := item.(binding.Bool).Get()
+	return v
+}
@@ -0,0 +1,216 @@
+// Copyright 2021 Alessandro De Blasis <alex@deblasis.net>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+package services
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/deblasis/edgex-foundry-datamonitor/config"
+)
+
+// Alert is raised when something needs attention and stays in the log after it recovers
+type Alert struct {
+	Id      int64     `json:"id"`
+	Kind    AlertKind `json:"kind"`
+	Source  string    `json:"source"`
+	Message string    `json:"message"`
+
+	RaisedAt time.Time `json:"raisedAt"`
+	// RecoveredAt is zero while the alert is active
+	RecoveredAt time.Time `json:"recoveredAt"`
+}
+
+func (a Alert) Active() bool {
+	return a.RecoveredAt.IsZero()
+}
+
+// AlertSubscriber is notified when an alert is raised or recovers
+type AlertSubscriber func(alert Alert)
+
+// AlertLog keeps the alerts, at most one is active for each kind and source
+type AlertLog struct {
+	alerts      []*Alert
+	active      map[string]*Alert
+	nextId      int64
+	subscribers []AlertSubscriber
+
+	sync.RWMutex
+}
+
+func NewAlertLog() *AlertLog {
+	return &AlertLog{
+		alerts: make([]*Alert, 0),
+		active: map[string]*Alert{},
+	}
+}
+
+func alertKey(kind AlertKind, source string) string {
+	return fmt.Sprintf("%d/%v", kind, source)
+}
+
+// Subscribe registers a function called every time an alert is raised or recovers,
+// it runs on the goroutine that changed the alert
+func (l *AlertLog) Subscribe(subscriber AlertSubscriber) {
+	l.Lock()
+	defer l.Unlock()
+	l.subscribers = append(l.subscribers, subscriber)
+}
+
+// Raise adds an alert unless one is already active for the same kind and source
+func (l *AlertLog) Raise(kind AlertKind, source string, message string, at time.Time) (Alert, bool) {
+	l.Lock()
+	key := alertKey(kind, source)
+	if _, ok := l.active[key]; ok {
+		l.Unlock()
+		return Alert{}, false
+	}
+
+	l.nextId++
+	alert := &Alert{
+		Id:       l.nextId,
+		Kind:     kind,
+		Source:   source,
+		Message:  message,
+		RaisedAt: at,
+	}
+	l.alerts = append(l.alerts, alert)
+	l.active[key] = alert
+	l.trim()
+
+	raised := *alert
+	subscribers := l.subscribers
+	l.Unlock()
+
+	l.notify(subscribers, raised)
+	return raised, true
+}
+
+// Recover marks the active alert for the kind and source as recovered
+func (l *AlertLog) Recover(kind AlertKind, source string, message string, at time.Time) (Alert, bool) {
+	l.Lock()
+	key := alertKey(kind, source)
+	alert, ok := l.active[key]
+	if !ok {
+		l.Unlock()
+		return Alert{}, false
+	}
+	delete(l.active, key)
+	alert.RecoveredAt = at
+	alert.Message = message
+
+	recovered := *alert
+	subscribers := l.subscribers
+	l.Unlock()
+
+	l.notify(subscribers, recovered)
+	return recovered, true
+}
+
+func (l *AlertLog) notify(subscribers []AlertSubscriber, alert Alert) {
+	for _, s := range subscribers {
+		s(alert)
+	}
+}
+
+// trim drops the oldest recovered alerts once the log is full, it must be called holding the lock
+func (l *AlertLog) trim() {
+	excess := len(l.alerts) - config.MaxAlerts
+	if excess <= 0 {
+		return
+	}
+	kept := l.alerts[:0]
+	for _, a := range l.alerts {
+		if excess > 0 && !a.Active() {
+			excess--
+			continue
+		}
+		kept = append(kept, a)
+	}
+	l.alerts = kept
+}
+
+// Get returns the alerts, newest first
+func (l *AlertLog) Get() []Alert {
+	l.RLock()
+	defer l.RUnlock()
+	alerts := make([]Alert, 0, len(l.alerts))
+	for i := len(l.alerts) - 1; i >= 0; i-- {
+		alerts = append(alerts, *l.alerts[i])
+	}
+	return alerts
+}
+
+// ActiveCount returns how many alerts haven't recovered yet
+func (l *AlertLog) ActiveCount() int {
+	l.RLock()
+	defer l.RUnlock()
+	return len(l.active)
+}
+
+// Load puts back the alerts stored by a previous run, newest first as returned by Get.
+// Nothing is watching what raised the ones still active back then, so they are closed as of now
+func (l *AlertLog) Load(alerts []Alert, now time.Time) {
+	l.Lock()
+	defer l.Unlock()
+	l.alerts = make([]*Alert, 0, len(alerts))
+	l.active = map[string]*Alert{}
+	l.nextId = 0
+	for i := len(alerts) - 1; i >= 0; i-- {
+		alert := alerts[i]
+		if alert.Active() {
+			alert.RecoveredAt = now
+		}
+		if alert.Id > l.nextId {
+			l.nextId = alert.Id
+		}
+		l.alerts = append(l.alerts, &alert)
+	}
+	l.trim()
+}
+
+// ParseAlerts decodes the alerts stored in the preferences
+func ParseAlerts(s string) ([]Alert, error) {
+	alerts := make([]Alert, 0)
+	if strings.TrimSpace(s) == "" {
+		return alerts, nil
+	}
+	if err := json.Unmarshal([]byte(s), &alerts); err != nil {
+		return nil, err
+	}
+	return alerts, nil
+}
+
+// FormatAlerts encodes the alerts to be stored in the preferences
+func FormatAlerts(alerts []Alert) string {
+	j, _ := json.Marshal(alerts)
+	return string(j)
+}
+
+// ClearRecovered forgets the alerts that have recovered
+func (l *AlertLog) ClearRecovered() {
+	l.Lock()
+	defer l.Unlock()
+	kept := l.alerts[:0]
+	for _, a := range l.alerts {
+		if a.Active() {
+			kept = append(kept, a)
+		}
+	}
+	l.alerts = kept
+}
@@ -0,0 +1,68 @@
+// Copyright 2021 Alessandro De Blasis <alex@deblasis.net>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+package services
+
+import (
+	"testing"
+	"time"
+
+	"github.com/deblasis/edgex-foundry-datamonitor/config"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_AlertsSurviveARestart(t *testing.T) {
+	now := time.Now().UTC().Truncate(time.Second)
+	alerts := NewAlertLog()
+	alerts.Raise(StaleDeviceAlert, "dev", "quiet", now)
+	alerts.Raise(RuleAlert, "hot", "too hot", now.Add(time.Second))
+	alerts.Recover(StaleDeviceAlert, "dev", "back", now.Add(2*time.Second))
+
+	stored, err := ParseAlerts(FormatAlerts(alerts.Get()))
+	require.NoError(t, err)
+	require.Equal(t, alerts.Get(), stored)
+
+	// the alerts active when the app was closed are closed on load
+	restarted := NewAlertLog()
+	restart := now.Add(time.Minute)
+	restarted.Load(stored, restart)
+	loaded := restarted.Get()
+	require.Len(t, loaded, 2)
+	require.Equal(t, "hot", loaded[0].Source)
+	require.Equal(t, restart, loaded[0].RecoveredAt)
+	require.Equal(t, 0, restarted.ActiveCount())
+
+	// raising again doesn't reuse the ids
+	raised, ok := restarted.Raise(RuleAlert, "hot", "too hot", restart)
+	require.True(t, ok)
+	require.Equal(t, int64(3), raised.Id)
+
+	empty, err := ParseAlerts("")
+	require.NoError(t, err)
+	require.Empty(t, empty)
+	_, err = ParseAlerts("{")
+	require.Error(t, err)
+}
+
+func Test_LoadedAlertsAreCapped(t *testing.T) {
+	stored := make([]Alert, 0, config.MaxAlerts+10)
+	for i := config.MaxAlerts + 10; i > 0; i-- {
+		stored = append(stored, Alert{Id: int64(i), Kind: RuleAlert, Source: "rule"})
+	}
+	alerts := NewAlertLog()
+	alerts.Load(stored, time.Now())
+	loaded := alerts.Get()
+	require.Len(t, loaded, config.MaxAlerts)
+	require.Equal(t, int64(config.MaxAlerts+10), loaded[0].Id)
+}
@@ -0,0 +1,227 @@
+// Copyright 2021 Alessandro De Blasis <alex@deblasis.net>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+package services
+
+import (
+	"math"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/edgexfoundry/go-mod-core-contracts/v2/dtos"
+)
+
+const (
+	// anomalyAlpha is the weight of a new value in the moving mean and variance
+	anomalyAlpha = 0.1
+	// a resource is judged only once the moving stats have seen this many values
+	anomalyWarmup = 20
+)
+
+// Anomaly is a reading whose value is too far from the moving mean of its device and resource
+type Anomaly struct {
+	ReadingId string
+	Device    string
+	Resource  string
+
+	Value  float64
+	Mean   float64
+	StdDev float64
+	ZScore float64
+
+	At time.Time
+}
+
+// movingStats is an exponentially weighted mean and variance
+type movingStats struct {
+	count    int
+	mean     float64
+	variance float64
+}
+
+func (s *movingStats) add(v float64) {
+	s.count++
+	if s.count == 1 {
+		s.mean = v
+		return
+	}
+	diff := v - s.mean
+	incr := anomalyAlpha * diff
+	s.mean += incr
+	s.variance = (1 - anomalyAlpha) * (s.variance + diff*incr)
+}
+
+// zScore returns false while the stats are warming up or the values never varied
+func (s *movingStats) zScore(v float64) (float64, bool) {
+	stdDev := math.Sqrt(s.variance)
+	if s.count < anomalyWarmup || stdDev == 0 {
+		return 0, false
+	}
+	return (v - s.mean) / stdDev, true
+}
+
+// numericValue returns the value of the readings that are a single number
+func numericValue(reading dtos.BaseReading) (float64, bool) {
+	t := reading.ValueType
+	if strings.HasSuffix(t, "Array") || !(strings.HasPrefix(t, "Int") || strings.HasPrefix(t, "Uint") || strings.HasPrefix(t, "Float")) {
+		return 0, false
+	}
+	v, err := strconv.ParseFloat(reading.Value, 64)
+	if err != nil || math.IsNaN(v) || math.IsInf(v, 0) {
+		return 0, false
+	}
+	return v, true
+}
+
+// AnomalyDetector flags the numeric readings whose z-score exceeds sigma,
+// the mean and variance are moving so that a slow drift isn't an anomaly
+type AnomalyDetector struct {
+	sigma float64
+	stats map[string]*movingStats
+
+	// flagged holds the latest anomalies by reading id, order evicts the oldest ones
+	flagged map[string]Anomaly
+	order   []string
+	head    int
+
+	counts map[ThroughputDimension]map[string]int64
+	total  int64
+
+	sync.RWMutex
+}
+
+func NewAnomalyDetector(sigma float64, capacity int) *AnomalyDetector {
+	if capacity < 1 {
+		capacity = 1
+	}
+	return &AnomalyDetector{
+		sigma:   sigma,
+		stats:   map[string]*movingStats{},
+		flagged: map[string]Anomaly{},
+		order:   make([]string, 0, capacity),
+		counts: map[ThroughputDimension]map[string]int64{
+			ByDevice:   {},
+			ByProfile:  {},
+			ByResource: {},
+		},
+	}
+}
+
+func (d *AnomalyDetector) SetSigma(sigma float64) {
+	d.Lock()
+	defer d.Unlock()
+	d.sigma = sigma
+}
+
+func (d *AnomalyDetector) GetSigma() float64 {
+	d.RLock()
+	defer d.RUnlock()
+	return d.sigma
+}
+
+func (d *AnomalyDetector) OnEventReceived(event dtos.Event) {
+	d.OnEventReceivedAt(event, time.Now())
+}
+
+func (d *AnomalyDetector) OnEventReceivedAt(event dtos.Event, receivedAt time.Time) {
+	d.Lock()
+	defer d.Unlock()
+	for _, r := range event.Readings {
+		d.evaluate(r, receivedAt)
+	}
+}
+
+// evaluate must be called holding the lock, the value is judged before it moves the stats
+func (d *AnomalyDetector) evaluate(reading dtos.BaseReading, now time.Time) {
+	v, ok := numericValue(reading)
+	if !ok {
+		return
+	}
+	key := reading.DeviceName + "/" + reading.ResourceName
+	s, ok := d.stats[key]
+	if !ok {
+		s = &movingStats{}
+		d.stats[key] = s
+	}
+
+	if z, ok := s.zScore(v); ok && math.Abs(z) > d.sigma {
+		d.flag(Anomaly{
+			ReadingId: reading.Id,
+			Device:    reading.DeviceName,
+			Resource:  reading.ResourceName,
+			Value:     v,
+			Mean:      s.mean,
+			StdDev:    math.Sqrt(s.variance),
+			ZScore:    z,
+			At:        now,
+		})
+		d.counts[ByDevice][reading.DeviceName]++
+		d.counts[ByProfile][reading.ProfileName]++
+		d.counts[ByResource][reading.ResourceName]++
+		d.total++
+	}
+	s.add(v)
+}
+
+// flag must be called holding the lock
+func (d *AnomalyDetector) flag(a Anomaly) {
+	if _, ok := d.flagged[a.ReadingId]; !ok {
+		if len(d.order) < cap(d.order) {
+			d.order = append(d.order, a.ReadingId)
+		} else if len(d.order) > 0 {
+			delete(d.flagged, d.order[d.head])
+			d.order[d.head] = a.ReadingId
+			d.head = (d.head + 1) % len(d.order)
+		}
+	}
+	d.flagged[a.ReadingId] = a
+}
+
+// GetAnomaly returns the anomaly the reading was flagged with, the oldest ones are forgotten
+// once as many as the capacity have been flagged
+func (d *AnomalyDetector) GetAnomaly(readingId string) (Anomaly, bool) {
+	d.RLock()
+	defer d.RUnlock()
+	a, ok := d.flagged[readingId]
+	return a, ok
+}
+
+// GetAnomaliesCount returns how many anomalies were flagged for each device, profile or resource
+func (d *AnomalyDetector) GetAnomaliesCount(dimension ThroughputDimension) map[string]int64 {
+	d.RLock()
+	defer d.RUnlock()
+	counts := make(map[string]int64, len(d.counts[dimension]))
+	for name, n := range d.counts[dimension] {
+		counts[name] = n
+	}
+	return counts
+}
+
+// ResetCounts starts counting the anomalies from zero, the flagged readings and the moving stats are kept
+func (d *AnomalyDetector) ResetCounts() {
+	d.Lock()
+	defer d.Unlock()
+	for dimension := range d.counts {
+		d.counts[dimension] = map[string]int64{}
+	}
+	d.total = 0
+}
+
+func (d *AnomalyDetector) GetTotalAnomaliesCount() int64 {
+	d.RLock()
+	defer d.RUnlock()
+	return d.total
+}
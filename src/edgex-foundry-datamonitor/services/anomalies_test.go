@@ -0,0 +1,90 @@
+// Copyright 2021 Alessandro De Blasis <alex@deblasis.net>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+package services
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func Test_AnomaliesAreFlaggedBeyondSigma(t *testing.T) {
+	d := NewAnomalyDetector(3, 2)
+	now := time.Now()
+	send := func(device string, id string, value string) {
+		event := readingEvent(device, "temperature", value)
+		event.Readings[0].Id = id
+		event.Readings[0].ValueType = "Float64"
+		d.OnEventReceivedAt(event, now)
+	}
+
+	for i := 0; i < anomalyWarmup; i++ {
+		value := "20"
+		if i%2 == 0 {
+			value = "21"
+		}
+		send("thermo-1", fmt.Sprintf("r%d", i), value)
+	}
+	// nothing is judged while warming up
+	send("thermo-2", "warmup", "1")
+	send("thermo-2", "warmup", "1000")
+	_, ok := d.GetAnomaly("warmup")
+	require.False(t, ok)
+	require.Equal(t, int64(0), d.GetTotalAnomaliesCount())
+
+	send("thermo-1", "normal", "2.05e+01")
+	_, ok = d.GetAnomaly("normal")
+	require.False(t, ok)
+
+	send("thermo-1", "spike", "500")
+	anomaly, ok := d.GetAnomaly("spike")
+	require.True(t, ok)
+	require.Greater(t, anomaly.ZScore, 3.0)
+	require.Equal(t, "temperature", anomaly.Resource)
+	require.Equal(t, int64(1), d.GetTotalAnomaliesCount())
+	require.Equal(t, map[string]int64{"temperature": 1}, d.GetAnomaliesCount(ByResource))
+	require.Equal(t, map[string]int64{"thermo-1": 1}, d.GetAnomaliesCount(ByDevice))
+
+	// the oldest anomalies are forgotten, the counts are kept
+	send("thermo-1", "dip", "-5000")
+	send("thermo-1", "spike2", "1e+06")
+	_, ok = d.GetAnomaly("spike")
+	require.False(t, ok)
+	_, ok = d.GetAnomaly("spike2")
+	require.True(t, ok)
+	require.Equal(t, int64(3), d.GetTotalAnomaliesCount())
+
+	// non numeric values are ignored
+	event := readingEvent("switch", "state", "true")
+	event.Readings[0].ValueType = "Bool"
+	d.OnEventReceivedAt(event, now)
+	require.Empty(t, d.GetAnomaliesCount(ByResource)["state"])
+}
+
+func Test_AnomalySigmaCanBeChanged(t *testing.T) {
+	d := NewAnomalyDetector(10, 10)
+	for i := 0; i < anomalyWarmup; i++ {
+		d.OnEventReceived(readingEvent("device", "resource", fmt.Sprintf("%d", i%2)))
+	}
+	d.OnEventReceived(readingEvent("device", "resource", "3"))
+	require.Equal(t, int64(0), d.GetTotalAnomaliesCount())
+
+	d.SetSigma(1)
+	require.Equal(t, 1.0, d.GetSigma())
+	d.OnEventReceived(readingEvent("device", "resource", "3"))
+	require.Equal(t, int64(1), d.GetTotalAnomaliesCount())
+}
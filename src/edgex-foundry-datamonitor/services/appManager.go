@@ -16,6 +16,7 @@ package services
 
 import (
 	"sync"
+	"time"
 
 	"fyne.io/fyne/v2"
 	"fyne.io/fyne/v2/widget"
@@ -33,13 +34,26 @@ type AppManager struct {
 
 	navBar *fyne.Container
 
-	db *DB
-	ep *EventProcessor
+	db    *DB
+	ep    *EventProcessor
+	rules *RulesEngine
+
+	anomalies *AnomalyDetector
+	metrics   *MetricsServer
+	series    *SeriesStore
+	inventory *DeviceInventory
 
 	pageHandlers map[widget.TreeNodeID]PageHandler
 
 	drawFn func(*fyne.Container)
 
+	// navigate selects a page in the navigation bar
+	navigate func(page widget.TreeNodeID)
+
+	// the filters of the Data page, persisted in the preferences by the page
+	savedSearches []DataFilter
+	filterHistory []DataFilter
+
 	sessionState *SessionState
 }
 
@@ -74,6 +88,59 @@ func (a *AppManager) GetDB() *DB {
 	return a.db
 }
 
+func (a *AppManager) SetRulesEngine(rules *RulesEngine) {
+	a.rules = rules
+}
+
+func (a *AppManager) GetRulesEngine() *RulesEngine {
+	return a.rules
+}
+
+func (a *AppManager) SetAnomalyDetector(anomalies *AnomalyDetector) {
+	a.anomalies = anomalies
+}
+
+func (a *AppManager) GetAnomalyDetector() *AnomalyDetector {
+	return a.anomalies
+}
+
+func (a *AppManager) SetMetricsServer(metrics *MetricsServer) {
+	a.metrics = metrics
+}
+
+func (a *AppManager) GetMetricsServer() *MetricsServer {
+	return a.metrics
+}
+
+func (a *AppManager) SetSeriesStore(series *SeriesStore) {
+	a.series = series
+}
+
+func (a *AppManager) GetSeriesStore() *SeriesStore {
+	return a.series
+}
+
+func (a *AppManager) SetDeviceInventory(inventory *DeviceInventory) {
+	a.inventory = inventory
+}
+
+func (a *AppManager) GetDeviceInventory() *DeviceInventory {
+	return a.inventory
+}
+
+// ResetCounters starts a new measurement period without reconnecting, the buffered data is kept
+func (a *AppManager) ResetCounters() {
+	a.ep.ResetStats()
+	a.db.ResetDuplicatesCount()
+	if a.anomalies != nil {
+		a.anomalies.ResetCounts()
+	}
+}
+
+func (a *AppManager) GetConfig() *config.Config {
+	return a.config
+}
+
 func (a *AppManager) SetCurrentContainer(container *fyne.Container, drawFn func(*fyne.Container)) {
 	a.Lock()
 	defer a.Unlock()
@@ -87,29 +154,78 @@ func (a *AppManager) SetNav(nav *fyne.Container) {
 	a.navBar = nav
 }
 
-func (a *AppManager) Refresh() {
-	refreshNavBar := func() {
-		if a.navBar == nil {
-			return
-		}
-		if a.GetConnectionState() == ClientConnected {
-			a.navBar.Objects[1].(*fyne.Container).Objects[0].Show()
-		} else {
-			a.navBar.Objects[1].(*fyne.Container).Objects[0].Hide()
-		}
-		a.navBar.Refresh()
+func (a *AppManager) SetNavigate(navigate func(page widget.TreeNodeID)) {
+	a.Lock()
+	defer a.Unlock()
+	a.navigate = navigate
+}
+
+// Navigate shows the page as if it was picked in the navigation bar
+func (a *AppManager) Navigate(page widget.TreeNodeID) {
+	a.RLock()
+	navigate := a.navigate
+	a.RUnlock()
+	if navigate != nil {
+		navigate(page)
 	}
+}
+
+func (a *AppManager) Refresh() {
 	refreshContent := func() {
 		if a.drawFn != nil && a.currentContainer != nil {
 			a.drawFn(a.currentContainer)
 		}
 	}
 
-	refreshNavBar()
+	a.RefreshNavBar()
 	refreshContent()
 
 }
 
+// RefreshNavBar redraws the navigation bar, the pages in its tree included
+func (a *AppManager) RefreshNavBar() {
+	if a.navBar == nil {
+		return
+	}
+	if a.GetConnectionState() == ClientConnected {
+		a.navBar.Objects[1].(*fyne.Container).Objects[0].Show()
+	} else {
+		a.navBar.Objects[1].(*fyne.Container).Objects[0].Hide()
+	}
+	a.navBar.Objects[0].Refresh()
+	a.navBar.Refresh()
+}
+
+// SetSavedSearches replaces the named filters of the Data page
+func (a *AppManager) SetSavedSearches(searches []DataFilter) {
+	a.Lock()
+	defer a.Unlock()
+	a.savedSearches = searches
+}
+
+func (a *AppManager) GetSavedSearches() []DataFilter {
+	a.RLock()
+	defer a.RUnlock()
+	searches := make([]DataFilter, len(a.savedSearches))
+	copy(searches, a.savedSearches)
+	return searches
+}
+
+// SetFilterHistory replaces the filters recently applied on the Data page, the latest first
+func (a *AppManager) SetFilterHistory(history []DataFilter) {
+	a.Lock()
+	defer a.Unlock()
+	a.filterHistory = history
+}
+
+func (a *AppManager) GetFilterHistory() []DataFilter {
+	a.RLock()
+	defer a.RUnlock()
+	history := make([]DataFilter, len(a.filterHistory))
+	copy(history, a.filterHistory)
+	return history
+}
+
 func (a *AppManager) GetCurrentContainer() (*fyne.Container, func(*fyne.Container)) {
 	a.RLock()
 	defer a.RUnlock()
@@ -146,10 +262,33 @@ func (a *AppManager) Disconnect() error {
 	return a.client.Disconnect()
 }
 
+// TableSort is the column a table is sorted by and in which direction
+type TableSort struct {
+	Column    int
+	Ascending bool
+}
+
 type SessionState struct {
 	DataPage_SelectedDataType *string
 	DataPage_Search           *string
 	DataPage_BufferSize       *int
+	DataPage_FrozenSnapshot   *Snapshot
+	DataPage_PinnedOnly       bool
+	DataPage_AnomaliesOnly    bool
+	DataPage_Device           string
+	// DataPage_Fields scopes the search to the fields with these keys, empty for every field
+	DataPage_Fields []string
+	// DataPage_Window keeps only what was received in the latest span of time, zero for any time
+	DataPage_Window time.Duration
+	// DataPage_Sort is by data type, the tables are sorted by origin until a header is tapped
+	DataPage_Sort map[string]TableSort
+
+	HomePage_RateWindow time.Duration
+	HomePage_Tab        string
+
+	ChartsPage_Series   []string
+	ChartsPage_Window   time.Duration
+	ChartsPage_PausedAt time.Time
 }
 
 func (a *AppManager) SetDataPageSelectedDataType(dt string) {
@@ -163,6 +302,54 @@ func (a *AppManager) SetDataPageSearch(search string) {
 	defer a.Unlock()
 	a.sessionState.DataPage_Search = config.String(search)
 	a.db.UpdateFilter(search)
+
+	// a frozen view shows what the filter matched at the time, refreezing with the new one
+	if a.sessionState.DataPage_FrozenSnapshot != nil {
+		a.sessionState.DataPage_FrozenSnapshot = a.db.Snapshot()
+	}
+}
+
+// SetDataPageFields looks for the search only in the fields with the given keys, none looks in every field
+func (a *AppManager) SetDataPageFields(keys []string) {
+	a.Lock()
+	defer a.Unlock()
+	a.sessionState.DataPage_Fields = keys
+	a.db.UpdateFilterScope(keys)
+
+	if a.sessionState.DataPage_FrozenSnapshot != nil {
+		a.sessionState.DataPage_FrozenSnapshot = a.db.Snapshot()
+	}
+}
+
+func (a *AppManager) GetDataPageFields() []string {
+	a.RLock()
+	defer a.RUnlock()
+	return a.sessionState.DataPage_Fields
+}
+
+// SetDataPageWindow shows only what was received within the window, zero shows everything
+func (a *AppManager) SetDataPageWindow(window time.Duration) {
+	a.Lock()
+	defer a.Unlock()
+	a.sessionState.DataPage_Window = window
+	a.db.UpdateFilterWindow(window)
+
+	if a.sessionState.DataPage_FrozenSnapshot != nil {
+		a.sessionState.DataPage_FrozenSnapshot = a.db.Snapshot()
+	}
+}
+
+func (a *AppManager) GetDataPageWindow() time.Duration {
+	a.RLock()
+	defer a.RUnlock()
+	return a.sessionState.DataPage_Window
+}
+
+// SetDataPageFrozenSnapshot freezes the Data page on the given snapshot, nil goes back to live data
+func (a *AppManager) SetDataPageFrozenSnapshot(snapshot *Snapshot) {
+	a.Lock()
+	defer a.Unlock()
+	a.sessionState.DataPage_FrozenSnapshot = snapshot
 }
 
 func (a *AppManager) SetDataPageBufferSize(bs int) {
@@ -184,6 +371,154 @@ func (a *AppManager) GetDataPageSearch() *string {
 	return a.sessionState.DataPage_Search
 }
 
+func (a *AppManager) SetDataPagePinnedOnly(pinnedOnly bool) {
+	a.Lock()
+	defer a.Unlock()
+	a.sessionState.DataPage_PinnedOnly = pinnedOnly
+}
+
+func (a *AppManager) SetDataPageAnomaliesOnly(anomaliesOnly bool) {
+	a.Lock()
+	defer a.Unlock()
+	a.sessionState.DataPage_AnomaliesOnly = anomaliesOnly
+}
+
+func (a *AppManager) SetDataPageSort(dataType string, s TableSort) {
+	a.Lock()
+	defer a.Unlock()
+	if a.sessionState.DataPage_Sort == nil {
+		a.sessionState.DataPage_Sort = map[string]TableSort{}
+	}
+	a.sessionState.DataPage_Sort[dataType] = s
+}
+
+// ResetDataPageSort goes back to the default order of the table of the data type
+func (a *AppManager) ResetDataPageSort(dataType string) {
+	a.Lock()
+	defer a.Unlock()
+	delete(a.sessionState.DataPage_Sort, dataType)
+}
+
+func (a *AppManager) GetDataPageSort(dataType string) (TableSort, bool) {
+	a.RLock()
+	defer a.RUnlock()
+	s, ok := a.sessionState.DataPage_Sort[dataType]
+	return s, ok
+}
+
+// SetDataPageDevice shows only the data of the device, an empty name shows every device
+func (a *AppManager) SetDataPageDevice(device string) {
+	a.Lock()
+	defer a.Unlock()
+	a.sessionState.DataPage_Device = device
+}
+
+func (a *AppManager) GetDataPageDevice() string {
+	a.RLock()
+	defer a.RUnlock()
+	return a.sessionState.DataPage_Device
+}
+
+func (a *AppManager) GetDataPageAnomaliesOnly() bool {
+	a.RLock()
+	defer a.RUnlock()
+	return a.sessionState.DataPage_AnomaliesOnly
+}
+
+func (a *AppManager) GetDataPagePinnedOnly() bool {
+	a.RLock()
+	defer a.RUnlock()
+	return a.sessionState.DataPage_PinnedOnly
+}
+
+func (a *AppManager) GetDataPageFrozenSnapshot() *Snapshot {
+	a.RLock()
+	defer a.RUnlock()
+	return a.sessionState.DataPage_FrozenSnapshot
+}
+
+// SetHomePageRateWindow picks the window the rates on the Home page are averaged over
+func (a *AppManager) SetHomePageRateWindow(window time.Duration) {
+	a.Lock()
+	defer a.Unlock()
+	a.sessionState.HomePage_RateWindow = window
+}
+
+func (a *AppManager) GetHomePageRateWindow() time.Duration {
+	a.RLock()
+	defer a.RUnlock()
+	if a.sessionState.HomePage_RateWindow == 0 {
+		return DefaultRateWindow
+	}
+	return a.sessionState.HomePage_RateWindow
+}
+
+func (a *AppManager) SetCurrentPage(page widget.TreeNodeID) {
+	a.Lock()
+	defer a.Unlock()
+	a.CurrentPage = page
+}
+
+// GetCurrentPage returns the page shown, it's safe to call from any goroutine
+func (a *AppManager) GetCurrentPage() widget.TreeNodeID {
+	a.RLock()
+	defer a.RUnlock()
+	return a.CurrentPage
+}
+
+func (a *AppManager) SetHomePageTab(tab string) {
+	a.Lock()
+	defer a.Unlock()
+	a.sessionState.HomePage_Tab = tab
+}
+
+func (a *AppManager) GetHomePageTab() string {
+	a.RLock()
+	defer a.RUnlock()
+	return a.sessionState.HomePage_Tab
+}
+
+func (a *AppManager) SetChartsPageSeries(series []string) {
+	a.Lock()
+	defer a.Unlock()
+	a.sessionState.ChartsPage_Series = series
+}
+
+func (a *AppManager) GetChartsPageSeries() []string {
+	a.RLock()
+	defer a.RUnlock()
+	return a.sessionState.ChartsPage_Series
+}
+
+func (a *AppManager) SetChartsPageWindow(window time.Duration) {
+	a.Lock()
+	defer a.Unlock()
+	a.sessionState.ChartsPage_Window = window
+}
+
+// GetChartsPageWindow defaults to the last minute
+func (a *AppManager) GetChartsPageWindow() time.Duration {
+	a.RLock()
+	defer a.RUnlock()
+	if a.sessionState.ChartsPage_Window == 0 {
+		return time.Minute
+	}
+	return a.sessionState.ChartsPage_Window
+}
+
+// SetChartsPagePausedAt freezes the charts at the given time, the zero time goes back to live data
+func (a *AppManager) SetChartsPagePausedAt(at time.Time) {
+	a.Lock()
+	defer a.Unlock()
+	a.sessionState.ChartsPage_PausedAt = at
+}
+
+func (a *AppManager) GetChartsPagePausedAt() time.Time {
+	a.RLock()
+	defer a.RUnlock()
+	return a.sessionState.ChartsPage_PausedAt
+}
+
 func (a *AppManager) GetDataPageBufferSize() *int {
 	a.RLock()
 	defer a.RUnlock()
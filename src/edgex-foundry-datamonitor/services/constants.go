@@ -29,3 +29,104 @@ const (
 	Paused
 	Running
 )
+
+// OverflowPolicy decides what a listener queue does when it's full
+type OverflowPolicy int
+
+const (
+	// BlockOnOverflow waits for the listener to catch up, slowing down ingestion
+	BlockOnOverflow OverflowPolicy = iota
+	// DropNewestOnOverflow discards the incoming event
+	DropNewestOnOverflow
+	// DropOldestOnOverflow discards the oldest queued event to make room for the incoming one
+	DropOldestOnOverflow
+)
+
+func (o OverflowPolicy) String() string {
+	switch o {
+	case BlockOnOverflow:
+		return "block"
+	case DropNewestOnOverflow:
+		return "drop-newest"
+	case DropOldestOnOverflow:
+		return "drop-oldest"
+	}
+	return "unknown"
+}
+
+// ThroughputDimension is what the traffic is broken down by
+type ThroughputDimension int
+
+const (
+	ByDevice ThroughputDimension = iota
+	ByProfile
+	ByResource
+)
+
+func (d ThroughputDimension) String() string {
+	switch d {
+	case ByDevice:
+		return "Device"
+	case ByProfile:
+		return "Profile"
+	case ByResource:
+		return "Resource"
+	}
+	return "unknown"
+}
+
+// AlertKind tells what raised an alert
+type AlertKind int
+
+const (
+	StaleDeviceAlert AlertKind = iota
+	RuleAlert
+)
+
+func (k AlertKind) String() string {
+	switch k {
+	case StaleDeviceAlert:
+		return "Stale device"
+	case RuleAlert:
+		return "Rule"
+	}
+	return "unknown"
+}
+
+// RuleCondition is what a rule checks on the values of the readings it selects
+type RuleCondition string
+
+const (
+	GreaterThan RuleCondition = ">"
+	LessThan    RuleCondition = "<"
+	EqualTo     RuleCondition = "=="
+	Between     RuleCondition = "between"
+	Changed     RuleCondition = "changed"
+	Missing     RuleCondition = "missing"
+)
+
+var RuleConditions = []RuleCondition{GreaterThan, LessThan, EqualTo, Between, Changed, Missing}
+
+// Trend is where the value of a reading went compared to the previous one
+type Trend int
+
+const (
+	// TrendUnknown is for the first reading and the values that are not a number
+	TrendUnknown Trend = iota
+	TrendUp
+	TrendDown
+	TrendSteady
+)
+
+// String is ASCII as the arrows are missing from the bundled fonts
+func (t Trend) String() string {
+	switch t {
+	case TrendUp:
+		return "^"
+	case TrendDown:
+		return "v"
+	case TrendSteady:
+		return "="
+	}
+	return ""
+}
@@ -17,10 +17,9 @@ package services
 import (
 	"encoding/json"
 	"fmt"
-	"math"
-	"strconv"
-	"strings"
+	"sort"
 	"sync"
+	"time"
 
 	log "github.com/sirupsen/logrus"
 
@@ -36,10 +35,27 @@ type DB struct {
 	matchedEventIds   *matched
 	matchedReadingIds *matched
 
+	// rows in insertion order, the oldest is evicted first
+	eventRows   *rowQueue
+	readingRows *rowQueue
+
+	// pinned rows are taken out of the queues so that they are never evicted
+	pinnedEvents   map[int64]*pin
+	pinnedReadings map[int64]*pin
+
 	eventSerial   int64
 	readingSerial int64
 
+	dedup *deduplicator
+	// extra copies received for the buffered events, by serial
+	eventCopies map[int64]int64
+	duplicates  int64
+
 	filterString string
+	// filterScope are the keys of the fields the filter is looked for in, empty for every field
+	filterScope []string
+	// filterWindow keeps only what was received in the latest span of time, zero for any time
+	filterWindow time.Duration
 	bufferSize   int64
 
 	sync.RWMutex
@@ -50,10 +66,23 @@ type matched struct {
 	sync.RWMutex
 }
 
+func (m *matched) has(serial int64) bool {
+	m.RLock()
+	defer m.RUnlock()
+	_, ok := m.Serials[serial]
+	return ok
+}
+
 func NewDB(filterCadenceMs int64) *DB {
 	db := &DB{
-		eventSerial:   math.MinInt64 + config.MaxBufferSize,
-		readingSerial: math.MinInt64 + config.MaxBufferSize,
+		eventRows:   newRowQueue(),
+		readingRows: newRowQueue(),
+
+		pinnedEvents:   map[int64]*pin{},
+		pinnedReadings: map[int64]*pin{},
+
+		dedup:       newDeduplicator(),
+		eventCopies: map[int64]int64{},
 
 		bufferSize: config.DefaultBufferSizeInDataPage,
 
@@ -77,96 +106,180 @@ func (db *DB) UpdateBufferSize(newBufferSize int64) {
 	db.evictOldReadings()
 }
 
+// GetBufferSize returns how many events, and as many readings, are kept in the buffer
+func (db *DB) GetBufferSize() int64 {
+	db.RLock()
+	defer db.RUnlock()
+	return db.bufferSize
+}
+
 func (db *DB) UpdateFilter(filter string) {
 	db.Lock()
 	defer db.Unlock()
 	db.filterString = filter
 
-	db.refreshIndex(db.events, "event_id", stringType)
-	db.refreshIndex(db.events, "event_deviceName", stringType)
-	db.refreshIndex(db.events, "event_profileName", stringType)
-	db.refreshIndex(db.events, "event_created", intType)
-	db.refreshIndex(db.events, "event_origin", intType)
-	db.refreshIndex(db.events, "event_tags", stringType)
-
-	db.refreshIndex(db.readings, "event_id", stringType)
-	db.refreshIndex(db.readings, "event_deviceName", stringType)
-	db.refreshIndex(db.readings, "event_profileName", stringType)
-	db.refreshIndex(db.readings, "event_created", intType)
-	db.refreshIndex(db.readings, "event_origin", intType)
-	db.refreshIndex(db.readings, "event_tags", stringType)
-
-	db.refreshIndex(db.readings, "reading_id", stringType)
-	db.refreshIndex(db.readings, "reading_created", intType)
-	db.refreshIndex(db.readings, "reading_origin", intType)
-	db.refreshIndex(db.readings, "reading_deviceName", stringType)
-	db.refreshIndex(db.readings, "reading_profileName", stringType)
-	db.refreshIndex(db.readings, "reading_valueType", stringType)
-	db.refreshIndex(db.readings, "reading_binaryValue", byteArrType)
-	db.refreshIndex(db.readings, "reading_mediaType", stringType)
-	db.refreshIndex(db.readings, "reading_value", stringType)
+	for _, f := range EventFilterFields {
+		db.refreshIndex(db.events, f)
+	}
+	for _, f := range ReadingFilterFields {
+		db.refreshIndex(db.readings, f)
+	}
 
 	db.filter()
 
 }
 
+// GetFilter returns what the events and readings are being filtered by
+func (db *DB) GetFilter() string {
+	db.RLock()
+	defer db.RUnlock()
+	return db.filterString
+}
+
+// UpdateFilterScope looks for the filter only in the fields with the given keys, none looks in every field
+func (db *DB) UpdateFilterScope(keys []string) {
+	db.Lock()
+	defer db.Unlock()
+	db.filterScope = make([]string, len(keys))
+	copy(db.filterScope, keys)
+
+	db.filter()
+}
+
+// UpdateFilterWindow keeps only the events and readings received within the window, zero keeps them all
+func (db *DB) UpdateFilterWindow(window time.Duration) {
+	db.Lock()
+	defer db.Unlock()
+	db.filterWindow = window
+}
+
+// filtered narrows the query down to the rows passing the filter, it must be called holding the lock
+func (db *DB) filtered(txn *column.Txn) *column.Txn {
+	if db.filterString != "" {
+		txn = txn.With("matching_serial_idx")
+	}
+	if db.filterWindow > 0 {
+		since := time.Now().Add(-db.filterWindow).UnixNano()
+		txn = txn.WithInt("event_receivedAt", func(v int64) bool {
+			return v >= since
+		})
+	}
+	return txn
+}
+
 func (db *DB) GetEventsCount() int64 {
+	db.RLock()
+	defer db.RUnlock()
 	var count int64
 	db.events.Query(func(txn *column.Txn) error {
-		if db.filterString == "" {
-			count = int64(txn.Count())
-		} else {
-			count = int64(txn.With("matching_serial_idx").Count())
-		}
+		count = int64(db.filtered(txn).Count())
 		return nil
 	})
 	return count
 }
 
+// GetTotalEventsCount returns how many events are taking space in the buffer, pinned ones excluded
 func (db *DB) GetTotalEventsCount() int64 {
-	var count int64
-	db.events.Query(func(txn *column.Txn) error {
-		count = int64(txn.Count())
-		return nil
-	})
-	return count
+	db.RLock()
+	defer db.RUnlock()
+	return int64(db.eventRows.len())
 }
 
 func (db *DB) GetReadingsCount() int64 {
+	db.RLock()
+	defer db.RUnlock()
 	var count int64
 	db.readings.Query(func(txn *column.Txn) error {
-		if db.filterString == "" {
-			count = int64(txn.Count())
-		} else {
-			count = int64(txn.With("matching_serial_idx").Count())
-		}
+		count = int64(db.filtered(txn).Count())
 		return nil
 	})
 	return count
 }
 
+// GetTotalReadingsCount returns how many readings are taking space in the buffer, pinned ones excluded
 func (db *DB) GetTotalReadingsCount() int64 {
-	var count int64
-	db.readings.Query(func(txn *column.Txn) error {
-		count = int64(txn.Count())
+	db.RLock()
+	defer db.RUnlock()
+	return int64(db.readingRows.len())
+}
+
+// EventRecord is an event as buffered in the DB
+type EventRecord struct {
+	dtos.Event
+	Serial int64 `json:"serial"`
+	// ReceivedAt is when the EventProcessor got the event, in nanoseconds
+	ReceivedAt int64 `json:"receivedAt"`
+	// Copies is how many times the event was received, duplicates included
+	Copies int64  `json:"copies"`
+	Pinned bool   `json:"pinned"`
+	Note   string `json:"note,omitempty"`
+	// MatchedIn are the fields the filter was found in
+	MatchedIn []FilterField `json:"-"`
+}
+
+// ReadingRecord is a reading as buffered in the DB along with the id of its parent event
+type ReadingRecord struct {
+	dtos.BaseReading
+	Serial  int64  `json:"serial"`
+	EventId string `json:"eventId"`
+	// EventSerial identifies the copy of the event the reading came with, an id can be buffered more than once
+	EventSerial int64 `json:"eventSerial"`
+	// ReceivedAt is when the EventProcessor got the parent event, in nanoseconds
+	ReceivedAt int64  `json:"receivedAt"`
+	Pinned     bool   `json:"pinned"`
+	Note       string `json:"note,omitempty"`
+	// MatchedIn are the fields the filter was found in, the ones of the parent event included
+	MatchedIn []FilterField `json:"-"`
+}
+
+// Latency is the time between the origin of the event and its receipt
+func (r EventRecord) Latency() (time.Duration, bool) {
+	return Latency(r.Origin, r.ReceivedAt)
+}
+
+// Latency is the time between the origin of the reading and the receipt of its event
+func (r ReadingRecord) Latency() (time.Duration, bool) {
+	return Latency(r.Origin, r.ReceivedAt)
+}
+
+func (db *DB) GetEvents() []EventRecord {
+	db.RLock()
+	defer db.RUnlock()
+	return db.getEvents(true)
+}
+
+func (db *DB) getEvents(filtered bool) []EventRecord {
+	events := make([]EventRecord, 0)
+
+	mapFunc := func(v column.Selector) {
+		events = append(events, db.eventFromSelector(v))
+	}
+
+	db.events.Query(func(txn *column.Txn) error {
+
+		if filtered {
+			txn = db.filtered(txn)
+		}
+		txn.Select(mapFunc)
 		return nil
 	})
-	return count
+	return events
 }
 
-func (db *DB) GetEvents() []dtos.Event {
-	events := make([]dtos.Event, 0)
+func (db *DB) eventFromSelector(v column.Selector) EventRecord {
+	var (
+		readings []dtos.BaseReading
+		tags     map[string]string
+	)
 
-	mapFunc := func(v column.Selector) {
-		var (
-			readings []dtos.BaseReading
-			tags     map[string]string
-		)
+	json.Unmarshal([]byte(v.StringAt("event_readings")), &readings)
+	json.Unmarshal([]byte(v.StringAt("event_tags")), &tags)
 
-		json.Unmarshal([]byte(v.StringAt("event_readings")), &readings)
-		json.Unmarshal([]byte(v.StringAt("event_tags")), &tags)
+	serial := v.IntAt("serial")
+	pin, pinned := db.pinnedEvents[serial]
 
-		events = append(events, dtos.Event{
+	record := EventRecord{
+		Event: dtos.Event{
 			Id:          v.StringAt("event_id"),
 			DeviceName:  v.StringAt("event_deviceName"),
 			ProfileName: v.StringAt("event_profileName"),
@@ -174,28 +287,57 @@ func (db *DB) GetEvents() []dtos.Event {
 			Origin:      v.IntAt("event_origin"),
 			Readings:    readings,
 			Tags:        tags,
-		})
+		},
+		Serial:     serial,
+		ReceivedAt: v.IntAt("event_receivedAt"),
+		Copies:     db.eventCopies[serial] + 1,
+		Pinned:     pinned,
+		MatchedIn:  db.matchedIn(v, serial, ScopeFilterFields(EventFilterFields, db.filterScope), db.matchedEventIds),
+	}
+	if pinned {
+		record.Note = pin.note
+	}
+	return record
+}
 
+// matchedIn finds the fields the filter is in, only for the rows being read rather than on every filtering
+func (db *DB) matchedIn(v column.Selector, serial int64, fields []FilterField, m *matched) []FilterField {
+	if db.filterString == "" || !m.has(serial) {
+		return nil
 	}
+	return matchedFields(v, fields, db.filterString)
+}
 
-	db.events.Query(func(txn *column.Txn) error {
+func (db *DB) GetReadings() []ReadingRecord {
+	db.RLock()
+	defer db.RUnlock()
+	return db.getReadings(true)
+}
+
+func (db *DB) getReadings(filtered bool) []ReadingRecord {
+	readings := make([]ReadingRecord, 0)
 
-		if db.filterString == "" {
-			txn.Select(mapFunc)
-		} else {
-			txn.With("matching_serial_idx").Select(mapFunc)
+	mapFunc := func(v column.Selector) {
+		readings = append(readings, db.readingFromSelector(v))
+	}
+
+	db.readings.Query(func(txn *column.Txn) error {
+
+		if filtered {
+			txn = db.filtered(txn)
 		}
+		txn.Select(mapFunc)
 		return nil
 	})
-	return events
+	return readings
 }
 
-func (db *DB) GetReadings() []dtos.BaseReading {
-	readings := make([]dtos.BaseReading, 0)
-
-	mapFunc := func(v column.Selector) {
+func (db *DB) readingFromSelector(v column.Selector) ReadingRecord {
+	serial := v.IntAt("serial")
+	pin, pinned := db.pinnedReadings[serial]
 
-		readings = append(readings, dtos.BaseReading{
+	record := ReadingRecord{
+		BaseReading: dtos.BaseReading{
 			Id:           v.StringAt("reading_id"),
 			Created:      v.IntAt("reading_created"),
 			Origin:       v.IntAt("reading_origin"),
@@ -210,39 +352,160 @@ func (db *DB) GetReadings() []dtos.BaseReading {
 			SimpleReading: dtos.SimpleReading{
 				Value: v.StringAt("reading_value"),
 			},
+		},
+		Serial:      serial,
+		EventId:     v.StringAt("event_id"),
+		EventSerial: v.IntAt("event_serial"),
+		ReceivedAt:  v.IntAt("event_receivedAt"),
+		Pinned:      pinned,
+		MatchedIn:   db.matchedIn(v, serial, ScopeFilterFields(ReadingFilterFields, db.filterScope), db.matchedReadingIds),
+	}
+	if pinned {
+		record.Note = pin.note
+	}
+	return record
+}
+
+// GetEventById returns the latest buffered event with the given id, pinned ones included
+func (db *DB) GetEventById(id string) (EventRecord, bool) {
+	db.RLock()
+	defer db.RUnlock()
+
+	var (
+		event EventRecord
+		found bool
+	)
+	db.events.Query(func(txn *column.Txn) error {
+		txn.WithString("event_id", func(v string) bool {
+			return v == id
+		}).Select(func(v column.Selector) {
+			if serial := v.IntAt("serial"); !found || serial > event.Serial {
+				event = db.eventFromSelector(v)
+				found = true
+			}
+		})
+		return nil
+	})
+	return event, found
+}
+
+// GetEventBySerial returns the buffered event with the given serial, pinned ones included
+func (db *DB) GetEventBySerial(serial int64) (EventRecord, bool) {
+	db.RLock()
+	defer db.RUnlock()
+
+	var (
+		event EventRecord
+		found bool
+	)
+	db.events.Query(func(txn *column.Txn) error {
+		txn.WithInt("serial", func(v int64) bool {
+			return v == serial
+		}).Select(func(v column.Selector) {
+			event = db.eventFromSelector(v)
+			found = true
 		})
+		return nil
+	})
+	return event, found
+}
 
-	}
+// GetReadingsByEventSerial returns the buffered readings that came with the event with the given serial,
+// regardless of the filter. The other copies of the event, if any, have their own
+func (db *DB) GetReadingsByEventSerial(eventSerial int64) []ReadingRecord {
+	db.RLock()
+	defer db.RUnlock()
 
+	readings := make([]ReadingRecord, 0)
 	db.readings.Query(func(txn *column.Txn) error {
-
-		if db.filterString == "" {
-			txn.Select(mapFunc)
-		} else {
-			txn.With("matching_serial_idx").Select(mapFunc)
-		}
+		txn.WithInt("event_serial", func(v int64) bool {
+			return v == eventSerial
+		}).Select(func(v column.Selector) {
+			readings = append(readings, db.readingFromSelector(v))
+		})
 		return nil
 	})
+	sort.Slice(readings, func(i, j int) bool {
+		return readings[i].Serial < readings[j].Serial
+	})
 	return readings
 }
 
+// Snapshot is a point-in-time copy of the buffered events and readings matching the filter
+type Snapshot struct {
+	Filter   string
+	Events   []EventRecord
+	Readings []ReadingRecord
+
+	eventSerial   int64
+	readingSerial int64
+}
+
+// Snapshot copies the events and readings currently matching the filter,
+// ingestion carries on unaffected
+func (db *DB) Snapshot() *Snapshot {
+	db.RLock()
+	defer db.RUnlock()
+	return &Snapshot{
+		Filter:   db.filterString,
+		Events:   db.getEvents(true),
+		Readings: db.getReadings(true),
+
+		eventSerial:   db.eventSerial,
+		readingSerial: db.readingSerial,
+	}
+}
+
+// WithEventPinned returns a copy of the snapshot with the pin of the event changed, the snapshot is left untouched
+func (s *Snapshot) WithEventPinned(serial int64, pinned bool) *Snapshot {
+	c := *s
+	c.Events = make([]EventRecord, len(s.Events))
+	copy(c.Events, s.Events)
+	for i := range c.Events {
+		if c.Events[i].Serial == serial {
+			c.Events[i].Pinned = pinned
+		}
+	}
+	return &c
+}
+
+// WithReadingPinned returns a copy of the snapshot with the pin of the reading changed, the snapshot is left untouched
+func (s *Snapshot) WithReadingPinned(serial int64, pinned bool) *Snapshot {
+	c := *s
+	c.Readings = make([]ReadingRecord, len(s.Readings))
+	copy(c.Readings, s.Readings)
+	for i := range c.Readings {
+		if c.Readings[i].Serial == serial {
+			c.Readings[i].Pinned = pinned
+		}
+	}
+	return &c
+}
+
+// CountIngestedSince returns how many events and readings have been received after the snapshot was taken
+func (db *DB) CountIngestedSince(s *Snapshot) (events int64, readings int64) {
+	db.RLock()
+	defer db.RUnlock()
+	return db.eventSerial - s.eventSerial, db.readingSerial - s.readingSerial
+}
+
 func filterMatchesIndex(field string) string {
 	return fmt.Sprintf("%v_idx", field)
 }
 
-func (db *DB) refreshIndex(c *column.Collection, fieldName string, t indexType) {
-	c.DropIndex(filterMatchesIndex(fieldName))
-	c.CreateIndex(filterMatchesIndex(fieldName), fieldName, func(r column.Reader) bool {
+func (db *DB) refreshIndex(c *column.Collection, f FilterField) {
+	c.DropIndex(filterMatchesIndex(f.Key))
+	c.CreateIndex(filterMatchesIndex(f.Key), f.Key, func(r column.Reader) bool {
 
 		//Loose search, assuming it's case insensitive...
 
-		switch t {
+		switch f.t {
 		case stringType, byteArrType:
-			return strings.Contains(strings.ToLower(r.String()), strings.ToLower(db.filterString))
+			return LooseMatch(r.String(), db.filterString)
 		case intType:
-			return strings.Contains(strings.ToLower(fmt.Sprintf("%v", r.Int())), strings.ToLower(db.filterString))
+			return LooseMatch(fmt.Sprintf("%v", r.Int()), db.filterString)
 		default:
-			log.Fatalf("unhandled type %v in refreshIndex", t)
+			log.Fatalf("unhandled type %v in refreshIndex", f.t)
 		}
 		return false
 	})
@@ -257,15 +520,13 @@ func (db *DB) refreshMatchingIndex(c *column.Collection, fieldName string, t ind
 			db.matchedEventIds.RLock()
 			defer db.matchedEventIds.RUnlock()
 
-			serial, _ := strconv.Atoi(r.String())
-			_, matching := db.matchedEventIds.Serials[int64(serial)]
+			_, matching := db.matchedEventIds.Serials[int64(r.Int())]
 			return matching
 		case isMatchingReadingType:
 			db.matchedReadingIds.RLock()
 			defer db.matchedReadingIds.RUnlock()
 
-			serial, _ := strconv.Atoi(r.String())
-			_, matching := db.matchedReadingIds.Serials[int64(serial)]
+			_, matching := db.matchedReadingIds.Serials[int64(r.Int())]
 			return matching
 		default:
 			log.Fatalf("unhandled type %v in refreshMatchingIndex", t)
@@ -294,61 +555,12 @@ func (db *DB) filter() {
 	wg.Add(2)
 	go func() {
 		defer wg.Done()
-		db.events.Query(func(txn *column.Txn) error {
-			txn.With(filterMatchesIndex("event_id")).
-				Union(filterMatchesIndex("event_deviceName")).
-				Union(filterMatchesIndex("event_profileName")).
-				Union(filterMatchesIndex("event_created")).
-				Union(filterMatchesIndex("event_origin")).
-				Union(filterMatchesIndex("event_tags")).
-				Select(func(v column.Selector) {
-					serial, err := strconv.Atoi(v.ValueAt("serial").(string))
-					if err != nil {
-						return
-					}
-					db.matchedEventIds.Lock()
-					defer db.matchedEventIds.Unlock()
-
-					db.matchedEventIds.Serials[int64(serial)] = struct{}{}
-
-				})
-
-			return nil
-		})
+		db.matchFields(db.events, ScopeFilterFields(EventFilterFields, db.filterScope), db.matchedEventIds)
 	}()
 
 	go func() {
 		defer wg.Done()
-		db.readings.Query(func(txn *column.Txn) error {
-			txn.With(filterMatchesIndex("event_id")).
-				Union(filterMatchesIndex("event_deviceName")).
-				Union(filterMatchesIndex("event_profileName")).
-				Union(filterMatchesIndex("event_created")).
-				Union(filterMatchesIndex("event_origin")).
-				Union(filterMatchesIndex("event_tags")).
-				Union(filterMatchesIndex("reading_id")).
-				Union(filterMatchesIndex("reading_created")).
-				Union(filterMatchesIndex("reading_origin")).
-				Union(filterMatchesIndex("reading_deviceName")).
-				Union(filterMatchesIndex("reading_resourceName")).
-				Union(filterMatchesIndex("reading_profileName")).
-				Union(filterMatchesIndex("reading_valueType")).
-				Union(filterMatchesIndex("reading_binaryValue")).
-				Union(filterMatchesIndex("reading_mediaType")).
-				Union(filterMatchesIndex("reading_value")).
-				Select(func(v column.Selector) {
-					serial, err := strconv.Atoi(v.ValueAt("serial").(string))
-					if err != nil {
-						return
-					}
-					db.matchedReadingIds.Lock()
-					defer db.matchedReadingIds.Unlock()
-
-					db.matchedReadingIds.Serials[int64(serial)] = struct{}{}
-				})
-
-			return nil
-		})
+		db.matchFields(db.readings, ScopeFilterFields(ReadingFilterFields, db.filterScope), db.matchedReadingIds)
 	}()
 
 	wg.Wait()
@@ -358,79 +570,88 @@ func (db *DB) filter() {
 
 }
 
+// matchFields collects the rows having the filter in any of the fields
+func (db *DB) matchFields(c *column.Collection, fields []FilterField, m *matched) {
+	if len(fields) == 0 {
+		return
+	}
+	c.Query(func(txn *column.Txn) error {
+		txn = txn.With(filterMatchesIndex(fields[0].Key))
+		for _, f := range fields[1:] {
+			txn = txn.Union(filterMatchesIndex(f.Key))
+		}
+		txn.Select(func(v column.Selector) {
+			m.Lock()
+			defer m.Unlock()
+
+			m.Serials[v.IntAt("serial")] = struct{}{}
+		})
+
+		return nil
+	})
+}
+
 func (db *DB) OnEventReceived(event dtos.Event) {
+	db.OnEventReceivedAt(event, time.Now())
+}
+
+// OnEventReceivedAt buffers the event along with the time it was received by the EventProcessor
+func (db *DB) OnEventReceivedAt(event dtos.Event, receivedAt time.Time) {
+	db.Lock()
+	defer db.Unlock()
+
+	if db.isDuplicate(event, receivedAt) {
+		return
+	}
 
 	eSerial := db.nextEventSerial()
+	idx := db.events.InsertObject(eventToMap(event, eSerial, receivedAt.UnixNano()))
+	db.eventRows.push(eSerial, idx)
+	db.rememberEvent(event, eSerial, receivedAt)
 
-	db.events.InsertObject(eventToMap(event, eSerial))
 	for _, reading := range event.Readings {
 		rSerial := db.nextReadingSerial()
-		db.readings.InsertObject(readingToMap(event, reading, rSerial))
+		idx := db.readings.InsertObject(readingToMap(event, reading, eSerial, rSerial, receivedAt.UnixNano()))
+		db.readingRows.push(rSerial, idx)
 	}
 
-	var wg sync.WaitGroup
-	wg.Add(3)
-	go func() {
-		defer wg.Done()
-		db.evictOldEvents()
-	}()
-
-	go func() {
-		defer wg.Done()
-		db.evictOldReadings()
-	}()
-
-	go func() {
-		defer wg.Done()
-		db.filter()
-	}()
-
-	wg.Wait()
-
+	db.evictOldEvents()
+	db.evictOldReadings()
+	db.filter()
 }
 
+// evictOldEvents drops the oldest events until they fit in the buffer
 func (db *DB) evictOldEvents() {
-	db.events.Query(func(txn *column.Txn) error {
-		txn.WithValue("serial", func(v interface{}) bool {
-			serial, _ := strconv.Atoi(v.(string))
-			return int64(serial) <= db.lastEventSerial()-db.bufferSize
-		}).Range("serial", func(v column.Cursor) {
-			serial, _ := strconv.Atoi(v.Selector.ValueAt("serial").(string))
-			db.matchedEventIds.Lock()
-			defer db.matchedEventIds.Unlock()
-			delete(db.matchedEventIds.Serials, int64(serial))
-
-			v.Delete()
-		})
-
-		return nil
-	})
+	for int64(db.eventRows.len()) > db.bufferSize {
+		serial, idx := db.eventRows.pop()
+		db.events.DeleteAt(idx)
+		delete(db.eventCopies, serial)
+
+		db.matchedEventIds.Lock()
+		delete(db.matchedEventIds.Serials, serial)
+		db.matchedEventIds.Unlock()
+	}
 }
 
+// evictOldReadings drops the oldest readings until they fit in the buffer
 func (db *DB) evictOldReadings() {
-	db.readings.Query(func(txn *column.Txn) error {
-		txn.WithValue("serial", func(v interface{}) bool {
-			serial, _ := strconv.Atoi(v.(string))
-			return int64(serial) <= db.lastReadingSerial()-db.bufferSize
-		}).Range("serial", func(v column.Cursor) {
-			serial, _ := strconv.Atoi(v.Selector.ValueAt("serial").(string))
-			db.matchedReadingIds.Lock()
-			defer db.matchedReadingIds.Unlock()
-			delete(db.matchedReadingIds.Serials, int64(serial))
-
-			v.Delete()
-		})
-		return nil
-	})
+	for int64(db.readingRows.len()) > db.bufferSize {
+		serial, idx := db.readingRows.pop()
+		db.readings.DeleteAt(idx)
+
+		db.matchedReadingIds.Lock()
+		delete(db.matchedReadingIds.Serials, serial)
+		db.matchedReadingIds.Unlock()
+	}
 }
 
-func eventToMap(event dtos.Event, serial int64) map[string]interface{} {
+func eventToMap(event dtos.Event, serial int64, receivedAt int64) map[string]interface{} {
 
 	tagsJson, _ := json.Marshal(event.Tags)
 	readingsJson, _ := json.Marshal(event.Readings)
 
 	m := map[string]interface{}{
-		"serial": fmt.Sprintf("%v", serial),
+		"serial": serial,
 
 		"event_id":            event.Id,
 		"event_deviceName":    event.DeviceName,
@@ -440,17 +661,18 @@ func eventToMap(event dtos.Event, serial int64) map[string]interface{} {
 		"event_readingsCount": len(event.Readings),
 		"event_readings":      readingsJson,
 		"event_tags":          string(tagsJson),
+		"event_receivedAt":    receivedAt,
 	}
 
 	return m
 }
 
-func readingToMap(event dtos.Event, reading dtos.BaseReading, serial int64) map[string]interface{} {
+func readingToMap(event dtos.Event, reading dtos.BaseReading, eventSerial int64, serial int64, receivedAt int64) map[string]interface{} {
 
 	tags, _ := json.Marshal(event.Tags)
 
 	m := map[string]interface{}{
-		"serial": fmt.Sprintf("%v", serial),
+		"serial": serial,
 
 		"event_id":          event.Id,
 		"event_deviceName":  event.DeviceName,
@@ -458,6 +680,8 @@ func readingToMap(event dtos.Event, reading dtos.BaseReading, serial int64) map[
 		"event_created":     event.Created,
 		"event_origin":      event.Origin,
 		"event_tags":        string(tags),
+		"event_receivedAt":  receivedAt,
+		"event_serial":      eventSerial,
 
 		"reading_id":           reading.Id,
 		"reading_created":      reading.Created,
@@ -477,12 +701,12 @@ func readingToMap(event dtos.Event, reading dtos.BaseReading, serial int64) map[
 func (db *DB) initCollections() {
 	eventsCollection := column.NewCollection()
 
-	eventsCollection.CreateColumn("serial", column.ForKey())
+	eventsCollection.CreateColumn("serial", column.ForInt64())
 	setupEventFields(eventsCollection)
 	db.events = eventsCollection
 
 	readingsCollection := column.NewCollection()
-	readingsCollection.CreateColumn("serial", column.ForKey())
+	readingsCollection.CreateColumn("serial", column.ForInt64())
 	setupEventFields(readingsCollection)
 	setupReadingFields(readingsCollection)
 	db.readings = readingsCollection
@@ -497,9 +721,13 @@ func setupEventFields(c *column.Collection) {
 	c.CreateColumn("event_readings", column.ForString())
 	c.CreateColumn("event_readingCount", column.ForInt64())
 	c.CreateColumn("event_tags", column.ForString())
+	c.CreateColumn("event_receivedAt", column.ForInt64())
 }
 
 func setupReadingFields(c *column.Collection) {
+	// the copy of the parent event it came with
+	c.CreateColumn("event_serial", column.ForInt64())
+
 	// BaseReading
 	c.CreateColumn("reading_id", column.ForString())
 	c.CreateColumn("reading_created", column.ForInt64())
@@ -516,26 +744,84 @@ func setupReadingFields(c *column.Collection) {
 	c.CreateColumn("reading_value", column.ForString())
 }
 
-func (db *DB) lastEventSerial() int64 {
-	return db.eventSerial
-}
+// nextEventSerial must be called holding the lock
 func (db *DB) nextEventSerial() int64 {
-	db.Lock()
-	defer db.Unlock()
-	next := db.eventSerial + 1
-	db.eventSerial = next
-	return next
+	db.eventSerial++
+	return db.eventSerial
 }
 
-func (db *DB) lastReadingSerial() int64 {
+// nextReadingSerial must be called holding the lock
+func (db *DB) nextReadingSerial() int64 {
+	db.readingSerial++
 	return db.readingSerial
 }
-func (db *DB) nextReadingSerial() int64 {
-	db.Lock()
-	defer db.Unlock()
-	next := db.readingSerial + 1
-	db.readingSerial = next
-	return next
+
+// rowQueue keeps the rows of a collection in insertion order so that
+// the oldest one can be found without scanning the collection
+type rowQueue struct {
+	serials []int64
+	indexes []uint32
+	head    int
+}
+
+func newRowQueue() *rowQueue {
+	return &rowQueue{
+		serials: make([]int64, 0),
+		indexes: make([]uint32, 0),
+	}
+}
+
+func (q *rowQueue) push(serial int64, idx uint32) {
+	q.serials = append(q.serials, serial)
+	q.indexes = append(q.indexes, idx)
+}
+
+// pop removes the oldest row, the caller must check len() first
+func (q *rowQueue) pop() (int64, uint32) {
+	serial, idx := q.serials[q.head], q.indexes[q.head]
+	q.head++
+
+	// compacting once the popped rows outnumber the live ones keeps pop amortized O(1)
+	if q.head > len(q.serials)/2 {
+		q.serials = append(q.serials[:0], q.serials[q.head:]...)
+		q.indexes = append(q.indexes[:0], q.indexes[q.head:]...)
+		q.head = 0
+	}
+	return serial, idx
+}
+
+func (q *rowQueue) len() int {
+	return len(q.serials) - q.head
+}
+
+// position returns where the serial is, or should be, since serials are pushed in increasing order
+func (q *rowQueue) position(serial int64) int {
+	return q.head + sort.Search(q.len(), func(i int) bool {
+		return q.serials[q.head+i] >= serial
+	})
+}
+
+// remove takes the row out of the queue wherever it is
+func (q *rowQueue) remove(serial int64) (uint32, bool) {
+	i := q.position(serial)
+	if i == len(q.serials) || q.serials[i] != serial {
+		return 0, false
+	}
+	idx := q.indexes[i]
+	q.serials = append(q.serials[:i], q.serials[i+1:]...)
+	q.indexes = append(q.indexes[:i], q.indexes[i+1:]...)
+	return idx, true
+}
+
+// insert puts back a row that was removed, keeping the insertion order
+func (q *rowQueue) insert(serial int64, idx uint32) {
+	i := q.position(serial)
+	q.serials = append(q.serials, 0)
+	q.indexes = append(q.indexes, 0)
+	copy(q.serials[i+1:], q.serials[i:])
+	copy(q.indexes[i+1:], q.indexes[i:])
+	q.serials[i] = serial
+	q.indexes[i] = idx
 }
 
 type indexType int
@@ -16,9 +16,12 @@ package services
 
 import (
 	"testing"
+	"time"
 
+	"github.com/deblasis/edgex-foundry-datamonitor/config"
 	"github.com/edgexfoundry/go-mod-core-contracts/v2/dtos"
 	"github.com/edgexfoundry/go-mod-core-contracts/v2/dtos/common"
+	"github.com/kelindar/column"
 	"github.com/stretchr/testify/require"
 )
 
@@ -110,6 +113,235 @@ func Test_IngestEvents(t *testing.T) {
 
 }
 
+func Test_EvictionKeepsNewestRows(t *testing.T) {
+	db := NewDB(1000)
+	db.UpdateBufferSize(3)
+
+	for i := 0; i < 10; i++ {
+		db.OnEventReceived(dummyEvent())
+	}
+
+	serials := []int64{}
+	db.events.Query(func(txn *column.Txn) error {
+		txn.Select(func(v column.Selector) {
+			serials = append(serials, v.IntAt("serial"))
+		})
+		return nil
+	})
+	require.ElementsMatch(t, []int64{8, 9, 10}, serials)
+	require.Equal(t, 3, db.eventRows.len())
+	require.Equal(t, 3, db.readingRows.len())
+
+	db.UpdateBufferSize(1)
+	require.Equal(t, 1, db.events.Count())
+	require.Equal(t, 1, db.readings.Count())
+}
+
+func Test_SnapshotIsStable(t *testing.T) {
+	db := NewDB(1000)
+	db.OnEventReceived(dummyEvent())
+	db.OnEventReceived(interestingEvent())
+	db.UpdateFilter("interesting")
+
+	snapshot := db.Snapshot()
+	require.Equal(t, "interesting", snapshot.Filter)
+	require.Len(t, snapshot.Events, 1)
+	require.Len(t, snapshot.Readings, 2)
+
+	db.OnEventReceived(interestingEvent())
+	db.OnEventReceived(dummyEvent())
+
+	require.Len(t, snapshot.Events, 1)
+	require.Len(t, db.GetEvents(), 2)
+
+	events, readings := db.CountIngestedSince(snapshot)
+	require.Equal(t, int64(2), events)
+	require.Equal(t, int64(3), readings)
+}
+
+func Test_PinnedRowsSurviveEviction(t *testing.T) {
+	db := NewDB(1000)
+	db.UpdateBufferSize(2)
+
+	db.OnEventReceived(interestingEvent())
+	evts := db.GetEvents()
+	require.Len(t, evts, 1)
+	rdngs := db.GetReadings()
+	require.Len(t, rdngs, 2)
+	require.Equal(t, "interesting_id", rdngs[0].EventId)
+
+	require.True(t, db.PinEvent(evts[0].Serial))
+	require.True(t, db.PinReading(rdngs[0].Serial))
+	require.True(t, db.SetEventNote(evts[0].Serial, "look at this"))
+	require.False(t, db.SetEventNote(12345, "not pinned"))
+
+	for i := 0; i < 5; i++ {
+		db.OnEventReceived(dummyEvent())
+	}
+
+	// the buffer is full of dummies, pinned rows don't take space in it
+	require.Equal(t, int64(2), db.GetTotalEventsCount())
+	require.Equal(t, int64(2), db.GetTotalReadingsCount())
+	require.Len(t, db.GetEvents(), 3)
+
+	pinnedEvents := db.GetPinnedEvents()
+	require.Len(t, pinnedEvents, 1)
+	require.Equal(t, "interesting_id", pinnedEvents[0].Id)
+	require.True(t, pinnedEvents[0].Pinned)
+	require.Equal(t, "look at this", pinnedEvents[0].Note)
+
+	pinnedReadings := db.GetPinnedReadings()
+	require.Len(t, pinnedReadings, 1)
+	require.Equal(t, "interesting_reading_id", pinnedReadings[0].Id)
+
+	export := db.Export()
+	require.Len(t, export.PinnedEvents, 1)
+	require.Len(t, export.PinnedReadings, 1)
+	require.Len(t, export.Events, 3)
+
+	// once unpinned, the old event is the first one to go
+	require.True(t, db.UnpinEvent(evts[0].Serial))
+	require.Empty(t, db.GetPinnedEvents())
+	require.Len(t, db.GetEvents(), 2)
+	require.False(t, db.PinEvent(evts[0].Serial))
+}
+
+func Test_EventsAndReadingsAreLinked(t *testing.T) {
+	db := NewDB(1000)
+	db.OnEventReceived(dummyEvent())
+	db.OnEventReceived(interestingEvent())
+	db.OnEventReceived(dummyEvent())
+
+	event, ok := db.GetEventById("interesting_id")
+	require.True(t, ok)
+	require.Equal(t, "interesting_device", event.DeviceName)
+	require.Len(t, event.Readings, 2)
+
+	readings := db.GetReadingsByEventSerial(event.Serial)
+	require.Len(t, readings, 2)
+	for _, r := range readings {
+		require.Equal(t, event.Id, r.EventId)
+		require.Equal(t, event.Serial, r.EventSerial)
+	}
+	parent, ok := db.GetEventBySerial(readings[0].EventSerial)
+	require.True(t, ok)
+	require.Equal(t, event.Id, parent.Id)
+
+	// without deduplication the same id can be buffered twice, the latest wins
+	// and each copy only links to the readings it came with
+	event, ok = db.GetEventById("event_id")
+	require.True(t, ok)
+	require.Equal(t, int64(3), event.Serial)
+	require.Len(t, db.GetReadingsByEventSerial(event.Serial), 1)
+	require.Len(t, db.GetReadingsByEventSerial(1), 1)
+	require.NotEqual(t, db.GetReadingsByEventSerial(1)[0].Serial, db.GetReadingsByEventSerial(3)[0].Serial)
+
+	// the filter doesn't hide the relationship
+	db.UpdateFilter("interesting")
+	require.Len(t, db.GetReadingsByEventSerial(event.Serial), 1)
+
+	_, ok = db.GetEventById("missing")
+	require.False(t, ok)
+	_, ok = db.GetEventBySerial(42)
+	require.False(t, ok)
+	require.Empty(t, db.GetReadingsByEventSerial(42))
+}
+
+func Test_DuplicateEventsAreCounted(t *testing.T) {
+	db := NewDB(1000)
+	db.OnEventReceived(dummyEvent())
+	db.OnEventReceived(dummyEvent())
+	require.Len(t, db.GetEvents(), 2, "deduplication is disabled by default")
+
+	db.SetDeduplicationWindow(time.Minute)
+	db.OnEventReceived(interestingEvent())
+	db.OnEventReceived(interestingEvent())
+	db.OnEventReceived(interestingEvent())
+	db.OnEventReceived(dummyEvent())
+
+	evts := db.GetEvents()
+	require.Len(t, evts, 4)
+	require.Equal(t, "interesting_id", evts[2].Id)
+	require.Equal(t, int64(3), evts[2].Copies)
+	require.Equal(t, int64(1), evts[3].Copies)
+	require.Len(t, db.GetReadings(), 5)
+	require.Equal(t, int64(2), db.GetDuplicatesCount())
+
+	// the metric keeps counting after the first copy has been evicted
+	db.UpdateBufferSize(1)
+	db.OnEventReceived(interestingEvent())
+	require.Equal(t, int64(3), db.GetDuplicatesCount())
+	require.Equal(t, "event_id", db.GetEvents()[0].Id)
+
+	db.SetDeduplicationWindow(0)
+	db.OnEventReceived(interestingEvent())
+	require.Equal(t, "interesting_id", db.GetEvents()[0].Id)
+	require.Equal(t, int64(3), db.GetDuplicatesCount())
+}
+
+func Test_DeduplicatorForgetsOldIds(t *testing.T) {
+	d := newDeduplicator()
+	d.window = time.Second
+	start := time.Now()
+
+	d.remember("a", 1, start)
+	d.remember("b", 2, start.Add(500*time.Millisecond))
+
+	serial, ok := d.lookup("a", start.Add(time.Second))
+	require.True(t, ok)
+	require.Equal(t, int64(1), serial)
+
+	_, ok = d.lookup("a", start.Add(1100*time.Millisecond))
+	require.False(t, ok)
+	_, ok = d.lookup("b", start.Add(1100*time.Millisecond))
+	require.True(t, ok)
+	require.Len(t, d.byId, 1)
+}
+
+// newFullDB returns a DB whose buffer is filled up to config.MaxBufferSize
+func newFullDB(b *testing.B) *DB {
+	db := NewDB(1000)
+	db.UpdateBufferSize(config.MaxBufferSize)
+	event := dummyEvent()
+	for i := 0; i < config.MaxBufferSize; i++ {
+		db.OnEventReceived(event)
+	}
+	require.Equal(b, config.MaxBufferSize, db.events.Count())
+	return db
+}
+
+func BenchmarkDB_InsertWithFullBuffer(b *testing.B) {
+	db := newFullDB(b)
+	event := dummyEvent()
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		db.OnEventReceived(event)
+	}
+}
+
+func BenchmarkDB_EvictWithFullBuffer(b *testing.B) {
+	db := newFullDB(b)
+	event := dummyEvent()
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		b.StopTimer()
+		db.Lock()
+		eSerial := db.nextEventSerial()
+		db.eventRows.push(eSerial, db.events.InsertObject(eventToMap(event, eSerial, 0)))
+		rSerial := db.nextReadingSerial()
+		db.readingRows.push(rSerial, db.readings.InsertObject(readingToMap(event, event.Readings[0], eSerial, rSerial, 0)))
+		b.StartTimer()
+
+		db.evictOldEvents()
+		db.evictOldReadings()
+		db.Unlock()
+	}
+}
+
 func dummyEvent() dtos.Event {
 	return dtos.Event{
 		Versionable: common.Versionable{},
@@ -188,3 +420,18 @@ func interestingEvent() dtos.Event {
 		Tags: map[string]string{},
 	}
 }
+
+func Test_SnapshotWithPinnedIsACopy(t *testing.T) {
+	db := NewDB(1000)
+	db.OnEventReceived(dummyEvent())
+	snapshot := db.Snapshot()
+
+	pinned := snapshot.WithEventPinned(snapshot.Events[0].Serial, true)
+	require.True(t, pinned.Events[0].Pinned)
+	require.False(t, snapshot.Events[0].Pinned)
+
+	pinned = snapshot.WithReadingPinned(snapshot.Readings[0].Serial, true)
+	require.True(t, pinned.Readings[0].Pinned)
+	require.False(t, snapshot.Readings[0].Pinned)
+	require.Equal(t, snapshot.Filter, pinned.Filter)
+}
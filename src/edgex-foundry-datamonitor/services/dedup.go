@@ -0,0 +1,154 @@
+// Copyright 2021 Alessandro De Blasis <alex@deblasis.net>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+package services
+
+import (
+	"time"
+
+	"github.com/edgexfoundry/go-mod-core-contracts/v2/dtos"
+)
+
+type seenEvent struct {
+	id     string
+	serial int64
+	seenAt time.Time
+}
+
+// deduplicator remembers the event ids received within the window,
+// oldest first so that expiring them doesn't need a scan
+type deduplicator struct {
+	window time.Duration
+	byId   map[string]*seenEvent
+	order  []*seenEvent
+	head   int
+}
+
+func newDeduplicator() *deduplicator {
+	return &deduplicator{
+		byId:  map[string]*seenEvent{},
+		order: make([]*seenEvent, 0),
+	}
+}
+
+func (d *deduplicator) enabled() bool {
+	return d.window > 0
+}
+
+// lookup returns the serial of the first copy of the event if it was seen within the window
+func (d *deduplicator) lookup(id string, now time.Time) (int64, bool) {
+	d.expire(now)
+	seen, ok := d.byId[id]
+	if !ok {
+		return 0, false
+	}
+	return seen.serial, true
+}
+
+func (d *deduplicator) remember(id string, serial int64, now time.Time) {
+	seen := &seenEvent{
+		id:     id,
+		serial: serial,
+		seenAt: now,
+	}
+	d.byId[id] = seen
+	d.order = append(d.order, seen)
+}
+
+func (d *deduplicator) expire(now time.Time) {
+	for d.head < len(d.order) && now.Sub(d.order[d.head].seenAt) > d.window {
+		seen := d.order[d.head]
+		d.order[d.head] = nil
+		d.head++
+		if d.byId[seen.id] == seen {
+			delete(d.byId, seen.id)
+		}
+	}
+	if d.head > len(d.order)/2 {
+		d.order = append(d.order[:0], d.order[d.head:]...)
+		d.head = 0
+	}
+}
+
+func (d *deduplicator) reset() {
+	d.byId = map[string]*seenEvent{}
+	d.order = d.order[:0]
+	d.head = 0
+}
+
+// SetDeduplicationWindow drops the events whose id was already received within the window,
+// a zero window disables deduplication
+func (db *DB) SetDeduplicationWindow(window time.Duration) {
+	db.Lock()
+	defer db.Unlock()
+	if window <= 0 {
+		window = 0
+		db.dedup.reset()
+	}
+	db.dedup.window = window
+}
+
+// GetDeduplicationWindow returns the current window, zero when deduplication is disabled
+func (db *DB) GetDeduplicationWindow() time.Duration {
+	db.RLock()
+	defer db.RUnlock()
+	return db.dedup.window
+}
+
+// GetDuplicatesCount returns how many duplicate events have been dropped so far
+func (db *DB) GetDuplicatesCount() int64 {
+	db.RLock()
+	defer db.RUnlock()
+	return db.duplicates
+}
+
+// ResetDuplicatesCount starts counting the duplicates from zero, the copies of the buffered events are kept
+func (db *DB) ResetDuplicatesCount() {
+	db.Lock()
+	defer db.Unlock()
+	db.duplicates = 0
+}
+
+// isDuplicate must be called holding the lock, it accounts for the copy if the event was already received
+func (db *DB) isDuplicate(event dtos.Event, now time.Time) bool {
+	if !db.dedup.enabled() || event.Id == "" {
+		return false
+	}
+	serial, ok := db.dedup.lookup(event.Id, now)
+	if !ok {
+		return false
+	}
+	db.duplicates++
+	// the first copy might have been evicted already, the metric is still accounted for
+	if db.eventRowBuffered(serial) {
+		db.eventCopies[serial]++
+	}
+	return true
+}
+
+// rememberEvent must be called holding the lock once the event has been inserted
+func (db *DB) rememberEvent(event dtos.Event, serial int64, now time.Time) {
+	if !db.dedup.enabled() || event.Id == "" {
+		return
+	}
+	db.dedup.remember(event.Id, serial, now)
+}
+
+func (db *DB) eventRowBuffered(serial int64) bool {
+	if _, ok := db.pinnedEvents[serial]; ok {
+		return true
+	}
+	i := db.eventRows.position(serial)
+	return i < len(db.eventRows.serials) && db.eventRows.serials[i] == serial
+}
@@ -15,143 +15,249 @@
 package services
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
 	"runtime"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	log "github.com/sirupsen/logrus"
 
-	"github.com/asecurityteam/rolling"
 	"github.com/deblasis/edgex-foundry-datamonitor/config"
 	"github.com/edgexfoundry/go-mod-core-contracts/v2/dtos"
 )
 
 type EventProcessor struct {
+	// accessed atomically, kept first for 64-bit alignment
+	totalEvents   int64
+	totalReadings int64
+	parseFailures int64
+	// since is when the measurement period started, in nanoseconds
+	since int64
+
 	eventsChannel <-chan *dtos.Event
 
 	state chan processorState
 
-	eventReceivedChannel   chan struct{}
-	readingReceivedChannel chan struct{}
-
-	TotalNumberEvents   int
-	TotalNumberReadings int
-
-	EventsPerSecondLastMinute   float64
-	ReadingsPerSecondLastMinute float64
+	// cancel stops the processing goroutine started by Start, done is closed once it's gone
+	cancel context.CancelFunc
+	done   chan struct{}
 
 	LastEvents shortMemoryEventsSlicer
 
-	eventListeners []EventListener
+	rates      *rateTracker
+	throughput *throughputTracker
+	latency    *latencyTracker
+	lastValues *lastValuesTracker
+	stale      *staleDetector
+	alerts     *AlertLog
+
+	eventListeners     []*listenerQueue
+	eventListenersLock sync.RWMutex
 
 	sync.RWMutex
 }
 
 func NewEventProcessor(eventsChannel chan *dtos.Event) *EventProcessor {
 	return &EventProcessor{
+		since: time.Now().UnixNano(),
+
 		eventsChannel: eventsChannel,
 
 		state: make(chan processorState, 1),
+		done:  make(chan struct{}),
 
-		eventListeners: make([]EventListener, 0),
+		eventListeners: make([]*listenerQueue, 0),
 
-		eventReceivedChannel:   make(chan struct{}, config.MaxBufferSize),
-		readingReceivedChannel: make(chan struct{}, config.MaxBufferSize),
-		LastEvents:             newTopNEventSlicer(5),
+		LastEvents: newTopNEventSlicer(config.DefaultLastEventsCount),
+		rates:      newRateTracker(DefaultRateWindows),
+		throughput: newThroughputTracker(),
+		latency:    newLatencyTracker(),
+		lastValues: newLastValuesTracker(),
+		stale:      newStaleDetector(config.DefaultStaleDeviceFactor),
+		alerts:     NewAlertLog(),
 	}
 }
 
+// ErrAlreadyStarted is returned when Start is called more than once
+var ErrAlreadyStarted = errors.New("the events processor has already been started")
+
 func (ep *EventProcessor) Activate() {
+	ep.setState(Running)
+}
+
+func (ep *EventProcessor) Deactivate() {
+	ep.setState(Paused)
+}
+
+func (ep *EventProcessor) setState(state processorState) {
 	ep.Lock()
 	defer ep.Unlock()
-	ep.state <- Running
+	select {
+	case ep.state <- state:
+	case <-ep.done:
+	}
 }
 
-func (ep *EventProcessor) Deactivate() {
+// Start processes the events in the background until ctx is done or Stop is called
+func (ep *EventProcessor) Start(ctx context.Context) error {
 	ep.Lock()
 	defer ep.Unlock()
-	ep.state <- Paused
+	if ep.cancel != nil {
+		return ErrAlreadyStarted
+	}
+	ctx, ep.cancel = context.WithCancel(ctx)
+	go ep.run(ctx)
+	return nil
 }
 
+// Stop stops processing, the events already received are delivered to the listeners before
+// their goroutines exit. If ctx is done first, what is still pending is discarded and ctx's error returned
+func (ep *EventProcessor) Stop(ctx context.Context) error {
+	ep.Lock()
+	if ep.cancel == nil {
+		// never started, the listeners might still have events queued all the same
+		var runCtx context.Context
+		runCtx, ep.cancel = context.WithCancel(context.Background())
+		go ep.run(runCtx)
+	}
+	cancel := ep.cancel
+	ep.Unlock()
+
+	cancel()
+
+	select {
+	case <-ep.done:
+		return nil
+	case <-ctx.Done():
+		ep.abortListeners()
+		return ctx.Err()
+	}
+}
+
+// Done is closed once the processor has stopped and its goroutines have exited
+func (ep *EventProcessor) Done() <-chan struct{} {
+	return ep.done
+}
+
+func (ep *EventProcessor) listeners() []*listenerQueue {
+	ep.eventListenersLock.RLock()
+	defer ep.eventListenersLock.RUnlock()
+	listeners := make([]*listenerQueue, len(ep.eventListeners))
+	copy(listeners, ep.eventListeners)
+	return listeners
+}
+
+// closeListeners waits for the listeners to receive the events still queued for them
+func (ep *EventProcessor) closeListeners() {
+	for _, q := range ep.listeners() {
+		q.close()
+	}
+}
+
+// abortListeners discards the events still queued for the listeners
+func (ep *EventProcessor) abortListeners() {
+	for _, q := range ep.listeners() {
+		q.abort()
+	}
+}
+
+// AttachListener attaches a listener using DefaultListenerOptions
 func (ep *EventProcessor) AttachListener(listener EventListener) {
-	ep.eventListeners = append(ep.eventListeners, listener)
+	ep.AttachListenerWithOptions(listener, DefaultListenerOptions)
 }
 
-func (ep *EventProcessor) processEvent(event *dtos.Event) {
+// AttachListenerWithOptions attaches a listener that will be notified from its own goroutine,
+// options decide how many events can be queued for it and what happens when the queue is full
+func (ep *EventProcessor) AttachListenerWithOptions(listener EventListener, options ListenerOptions) {
+	ep.eventListenersLock.Lock()
+	defer ep.eventListenersLock.Unlock()
+	ep.eventListeners = append(ep.eventListeners, newListenerQueue(listener, options))
+}
 
-	for _, listener := range ep.eventListeners {
-		listener.OnEventReceived(*event)
+// DetachListener stops notifying the listener, events still queued for it are discarded.
+// It returns false if the listener wasn't attached
+func (ep *EventProcessor) DetachListener(listener EventListener) bool {
+	ep.eventListenersLock.Lock()
+	var detached *listenerQueue
+	for i, q := range ep.eventListeners {
+		if q.listener == listener {
+			detached = q
+			ep.eventListeners = append(ep.eventListeners[:i:i], ep.eventListeners[i+1:]...)
+			break
+		}
 	}
+	ep.eventListenersLock.Unlock()
 
-	ep.eventReceivedChannel <- struct{}{}
-	ep.TotalNumberEvents++
-	ep.TotalNumberReadings += len(event.Readings)
+	if detached == nil {
+		return false
+	}
+	detached.stop()
+	return true
+}
 
-	ep.LastEvents.Add(event)
+// ListenerStats returns the delivery metrics of the attached listeners
+func (ep *EventProcessor) ListenerStats() []ListenerStats {
+	ep.eventListenersLock.RLock()
+	defer ep.eventListenersLock.RUnlock()
 
-	for range event.Readings {
-		ep.readingReceivedChannel <- struct{}{}
+	stats := make([]ListenerStats, 0, len(ep.eventListeners))
+	for _, q := range ep.eventListeners {
+		stats = append(stats, q.stats())
 	}
+	return stats
 }
 
-func (ep *EventProcessor) Run() {
+func (ep *EventProcessor) processEvent(event *dtos.Event) {
 
-	timeWindow := rolling.NewWindow(1000 * 60)
-	rollingEventsCounter := rolling.NewTimePolicy(timeWindow, time.Millisecond)
-	rollingReadingsCounter := rolling.NewTimePolicy(timeWindow, time.Millisecond)
+	// accounting first, so that the listeners find the metrics up to date
+	atomic.AddInt64(&ep.totalEvents, 1)
+	atomic.AddInt64(&ep.totalReadings, int64(len(event.Readings)))
 
-	go func() {
-		for range ep.eventReceivedChannel {
-			// if we get more events than we can process, the Append below panics, catching it, needs investigation
-			defer func() {
-				if err := recover(); err != nil {
-					log.Errorf("panic occurred:", err)
-				}
-			}()
-			rollingEventsCounter.Append(1)
-		}
-	}()
-
-	go func() {
-		for range ep.readingReceivedChannel {
-			// if we get more events than we can process, the Append below panics, catching it, needs investigation
-			defer func() {
-				if err := recover(); err != nil {
-					log.Errorf("panic occurred:", err)
-				}
-			}()
-			rollingReadingsCounter.Append(1)
-		}
-	}()
+	ep.LastEvents.Add(event)
 
-	go func() {
-		for range time.Tick(time.Millisecond * 200) {
-			eventsPerMinute := rollingEventsCounter.Reduce(rolling.Sum)
-			ep.EventsPerSecondLastMinute = eventsPerMinute / 60
-		}
-	}()
+	now := time.Now()
+	ep.rates.track(now, int64(len(event.Readings)))
+	ep.throughput.track(event, now)
+	ep.latency.track(event, now)
+	ep.lastValues.track(event, now)
+	ep.deviceSeen(event.DeviceName, now)
 
-	go func() {
-		for range time.Tick(time.Millisecond * 200) {
-			readingsPerMinute := rollingReadingsCounter.Reduce(rolling.Sum)
-			ep.ReadingsPerSecondLastMinute = readingsPerMinute / 60
-		}
-	}()
+	for _, q := range ep.listeners() {
+		q.enqueue(*event, now)
+	}
+}
+
+func (ep *EventProcessor) run(ctx context.Context) {
+	defer close(ep.done)
 
 	state := Running
 
+	staleCheck := time.NewTicker(staleCheckInterval)
+	defer staleCheck.Stop()
+
 	for {
 
 		select {
 
+		case <-ctx.Done():
+			ep.drain(state)
+			ep.closeListeners()
+			log.Info("EventsProcessor: Stopped")
+			return
+
 		case state = <-ep.state:
 			switch state {
 			case Stopped:
+				ep.drain(Running)
+				ep.closeListeners()
 				log.Info("EventsProcessor: Stopped")
 				return
 			case Running:
 				log.Info("EventsProcessor: Running")
+				ep.stale.resume(time.Now())
 			case Paused:
 				log.Info("EventsProcessor: Paused")
 			}
@@ -160,11 +266,31 @@ func (ep *EventProcessor) Run() {
 
 			runtime.Gosched()
 
-			if state == Paused {
+			if state == Paused || event == nil {
 				break
 			}
 
 			ep.processEvent(event)
+
+		case now := <-staleCheck.C:
+			if state == Running {
+				ep.checkStaleDevices(now)
+			}
+		}
+	}
+}
+
+// drain processes the events already in the channel, they are discarded while paused
+func (ep *EventProcessor) drain(state processorState) {
+	for {
+		select {
+		case event := <-ep.eventsChannel:
+			if state == Paused || event == nil {
+				continue
+			}
+			ep.processEvent(event)
+		default:
+			return
 		}
 	}
 }
@@ -172,6 +298,7 @@ func (ep *EventProcessor) Run() {
 type topNEvents struct {
 	n      int
 	events []*dtos.Event
+	sync.RWMutex
 }
 
 func newTopNEventSlicer(n int) *topNEvents {
@@ -182,19 +309,35 @@ func newTopNEventSlicer(n int) *topNEvents {
 }
 
 func (t *topNEvents) Add(e *dtos.Event) {
-	if len(t.events) == t.n {
-		t.events = t.events[1:]
+	t.Lock()
+	defer t.Unlock()
+	if len(t.events) >= t.n {
+		t.events = t.events[len(t.events)-t.n+1:]
 	}
 
 	t.events = append(t.events, e)
 }
 
+// Resize keeps the newest events that fit
+func (t *topNEvents) Resize(n int) {
+	t.Lock()
+	defer t.Unlock()
+	t.n = n
+	if len(t.events) > n {
+		t.events = t.events[len(t.events)-n:]
+	}
+}
+
 func (l *topNEvents) Get() []*dtos.Event {
-	return l.events
+	l.RLock()
+	defer l.RUnlock()
+	events := make([]*dtos.Event, len(l.events))
+	copy(events, l.events)
+	return events
 }
 
 func (l *topNEvents) GetJson() string {
-	j, _ := json.Marshal(l.events)
+	j, _ := json.Marshal(l.Get())
 	return string(j)
 }
 
@@ -202,9 +345,16 @@ type shortMemoryEventsSlicer interface {
 	Add(e *dtos.Event)
 	Get() []*dtos.Event
 	GetJson() string
+	Resize(n int)
 }
 
 type EventListeners []EventListener
 type EventListener interface {
 	OnEventReceived(event dtos.Event)
 }
+
+// ReceiptListener can be implemented by an EventListener that needs to know
+// when the event was received, it's then called instead of OnEventReceived
+type ReceiptListener interface {
+	OnEventReceivedAt(event dtos.Event, receivedAt time.Time)
+}
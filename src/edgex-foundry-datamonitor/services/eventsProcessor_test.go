@@ -0,0 +1,213 @@
+// Copyright 2021 Alessandro De Blasis <alex@deblasis.net>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+package services
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/edgexfoundry/go-mod-core-contracts/v2/dtos"
+	"github.com/stretchr/testify/require"
+)
+
+type recordingListener struct {
+	sync.Mutex
+	received []string
+	gate     chan struct{}
+}
+
+func newRecordingListener() *recordingListener {
+	return &recordingListener{}
+}
+
+func newBlockedListener() *recordingListener {
+	return &recordingListener{gate: make(chan struct{})}
+}
+
+func (l *recordingListener) OnEventReceived(event dtos.Event) {
+	if l.gate != nil {
+		<-l.gate
+	}
+	l.Lock()
+	defer l.Unlock()
+	l.received = append(l.received, event.Id)
+}
+
+func (l *recordingListener) Received() []string {
+	l.Lock()
+	defer l.Unlock()
+	return append([]string{}, l.received...)
+}
+
+func eventWithId(id string) *dtos.Event {
+	e := dummyEvent()
+	e.Id = id
+	return &e
+}
+
+func Test_SlowListenerDoesNotBlockOthers(t *testing.T) {
+	ep := NewEventProcessor(make(chan *dtos.Event))
+
+	slow := newBlockedListener()
+	fast := newRecordingListener()
+	ep.AttachListenerWithOptions(slow, ListenerOptions{QueueSize: 1, Overflow: DropNewestOnOverflow})
+	ep.AttachListener(fast)
+
+	for _, id := range []string{"1", "2", "3"} {
+		ep.processEvent(eventWithId(id))
+	}
+
+	require.Eventually(t, func() bool {
+		return len(fast.Received()) == 3
+	}, time.Second, 10*time.Millisecond)
+	require.Empty(t, slow.Received())
+
+	close(slow.gate)
+	require.True(t, ep.DetachListener(slow))
+	require.True(t, ep.DetachListener(fast))
+}
+
+func Test_ListenerOverflowPolicies(t *testing.T) {
+	tests := []struct {
+		name     string
+		overflow OverflowPolicy
+		want     []string
+	}{
+		{
+			name:     "drop newest keeps the queued events",
+			overflow: DropNewestOnOverflow,
+			want:     []string{"1", "2", "3"},
+		},
+		{
+			name:     "drop oldest keeps the latest events",
+			overflow: DropOldestOnOverflow,
+			want:     []string{"1", "4", "5"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ep := NewEventProcessor(make(chan *dtos.Event))
+			l := newBlockedListener()
+			ep.AttachListenerWithOptions(l, ListenerOptions{QueueSize: 2, Overflow: tt.overflow})
+
+			// the first one is picked up by the listener goroutine and stays there until the gate opens
+			ep.processEvent(eventWithId("1"))
+			require.Eventually(t, func() bool {
+				return ep.ListenerStats()[0].QueueDepth == 0
+			}, time.Second, time.Millisecond)
+
+			for _, id := range []string{"2", "3", "4", "5"} {
+				ep.processEvent(eventWithId(id))
+			}
+
+			stats := ep.ListenerStats()[0]
+			require.Equal(t, 2, stats.QueueDepth)
+			require.Equal(t, 2, stats.QueueCapacity)
+			require.Equal(t, uint64(2), stats.Dropped)
+			require.Equal(t, "*services.recordingListener", stats.Name)
+
+			close(l.gate)
+			require.Eventually(t, func() bool {
+				return len(l.Received()) == len(tt.want)
+			}, time.Second, 10*time.Millisecond)
+			require.Equal(t, tt.want, l.Received())
+			require.Equal(t, uint64(3), ep.ListenerStats()[0].Delivered)
+		})
+	}
+}
+
+func Test_DetachListener(t *testing.T) {
+	ep := NewEventProcessor(make(chan *dtos.Event))
+	l := newRecordingListener()
+	ep.AttachListener(l)
+
+	ep.processEvent(eventWithId("1"))
+	require.Eventually(t, func() bool {
+		return len(l.Received()) == 1
+	}, time.Second, 10*time.Millisecond)
+
+	require.True(t, ep.DetachListener(l))
+	require.False(t, ep.DetachListener(l))
+	require.Empty(t, ep.ListenerStats())
+
+	ep.processEvent(eventWithId("2"))
+	time.Sleep(50 * time.Millisecond)
+	require.Equal(t, []string{"1"}, l.Received())
+}
+
+func Test_StopDrainsPendingEvents(t *testing.T) {
+	events := make(chan *dtos.Event, 10)
+	ep := NewEventProcessor(events)
+	l := newRecordingListener()
+	ep.AttachListener(l)
+
+	require.NoError(t, ep.Start(context.Background()))
+	require.ErrorIs(t, ep.Start(context.Background()), ErrAlreadyStarted)
+
+	// the nil ones come from payloads that could not be parsed
+	events <- nil
+	for _, id := range []string{"1", "2", "3"} {
+		events <- eventWithId(id)
+	}
+	require.NoError(t, ep.Stop(context.Background()))
+	require.Equal(t, []string{"1", "2", "3"}, l.Received())
+
+	// the listeners' goroutines are gone too
+	<-ep.Done()
+	for _, q := range ep.listeners() {
+		<-q.stopped
+	}
+
+	// stopping twice is harmless, so is changing state afterwards
+	require.NoError(t, ep.Stop(context.Background()))
+	ep.Deactivate()
+	ep.Activate()
+}
+
+func Test_StopGivesUpOnAStuckListener(t *testing.T) {
+	ep := NewEventProcessor(make(chan *dtos.Event))
+	l := newBlockedListener()
+	ep.AttachListener(l)
+	require.NoError(t, ep.Start(context.Background()))
+
+	ep.processEvent(eventWithId("1"))
+	ep.processEvent(eventWithId("2"))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	require.ErrorIs(t, ep.Stop(ctx), context.DeadlineExceeded)
+
+	// once the listener returns, the event still queued is discarded
+	close(l.gate)
+	<-ep.Done()
+	require.Equal(t, []string{"1"}, l.Received())
+}
+
+func Test_CancellingTheContextStopsTheProcessor(t *testing.T) {
+	events := make(chan *dtos.Event, 1)
+	ep := NewEventProcessor(events)
+	l := newRecordingListener()
+	ep.AttachListener(l)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	require.NoError(t, ep.Start(ctx))
+	events <- eventWithId("1")
+	cancel()
+
+	<-ep.Done()
+	require.Equal(t, []string{"1"}, l.Received())
+}
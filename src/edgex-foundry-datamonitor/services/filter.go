@@ -0,0 +1,137 @@
+// Copyright 2021 Alessandro De Blasis <alex@deblasis.net>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+package services
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/kelindar/column"
+)
+
+// FilterField is a field that the loose search of the Data page looks into
+type FilterField struct {
+	// Key is the column of the collection that stores the field
+	Key string
+	// Name is how the field is called in the Data page
+	Name string
+	t    indexType
+}
+
+// EventFilterFields are the fields an event is matched on
+var EventFilterFields = []FilterField{
+	{Key: "event_id", Name: "id", t: stringType},
+	{Key: "event_deviceName", Name: "device name", t: stringType},
+	{Key: "event_profileName", Name: "profile name", t: stringType},
+	{Key: "event_created", Name: "created", t: intType},
+	{Key: "event_origin", Name: "origin", t: intType},
+	{Key: "event_tags", Name: "tags", t: stringType},
+}
+
+// ReadingFilterFields are the fields a reading is matched on, the ones of its parent event included
+var ReadingFilterFields = []FilterField{
+	{Key: "event_id", Name: "event id", t: stringType},
+	{Key: "event_deviceName", Name: "event device name", t: stringType},
+	{Key: "event_profileName", Name: "event profile name", t: stringType},
+	{Key: "event_created", Name: "event created", t: intType},
+	{Key: "event_origin", Name: "event origin", t: intType},
+	{Key: "event_tags", Name: "event tags", t: stringType},
+
+	{Key: "reading_id", Name: "id", t: stringType},
+	{Key: "reading_created", Name: "created", t: intType},
+	{Key: "reading_origin", Name: "origin", t: intType},
+	{Key: "reading_deviceName", Name: "device name", t: stringType},
+	{Key: "reading_resourceName", Name: "resource name", t: stringType},
+	{Key: "reading_profileName", Name: "profile name", t: stringType},
+	{Key: "reading_valueType", Name: "value type", t: stringType},
+	{Key: "reading_binaryValue", Name: "binary value", t: byteArrType},
+	{Key: "reading_mediaType", Name: "media type", t: stringType},
+	{Key: "reading_value", Name: "value", t: stringType},
+}
+
+// ScopeFilterFields keeps the fields with the given keys, all of them when no key is given.
+// None is kept when the keys are of fields of the other data type
+func ScopeFilterFields(fields []FilterField, keys []string) []FilterField {
+	if len(keys) == 0 {
+		return fields
+	}
+	scoped := make([]FilterField, 0, len(keys))
+	for _, f := range fields {
+		for _, k := range keys {
+			if f.Key == k {
+				scoped = append(scoped, f)
+				break
+			}
+		}
+	}
+	return scoped
+}
+
+// FilterFieldNames lists the names of the fields, e.g. "event tags, origin"
+func FilterFieldNames(fields []FilterField) string {
+	names := make([]string, len(fields))
+	for i, f := range fields {
+		names[i] = f.Name
+	}
+	return strings.Join(names, ", ")
+}
+
+// LooseMatch is the search of the Data page, case insensitive
+func LooseMatch(text string, filter string) bool {
+	return strings.Contains(strings.ToLower(text), strings.ToLower(filter))
+}
+
+// MatchRanges returns where the filter is found in the text, as pairs of byte offsets like
+// regexp.FindAllStringIndex does. Nothing is returned for an empty filter
+func MatchRanges(text string, filter string) [][]int {
+	if filter == "" {
+		return nil
+	}
+	lowerText, lowerFilter := strings.ToLower(text), strings.ToLower(filter)
+	// lowering some runes changes their length and the offsets would be off
+	if len(lowerText) != len(text) {
+		return nil
+	}
+
+	var ranges [][]int
+	for start := 0; start < len(lowerText); {
+		i := strings.Index(lowerText[start:], lowerFilter)
+		if i < 0 {
+			break
+		}
+		ranges = append(ranges, []int{start + i, start + i + len(lowerFilter)})
+		start += i + len(lowerFilter)
+	}
+	return ranges
+}
+
+// matches tells whether the row selected has the filter in the field
+func (f FilterField) matches(v column.Selector, filter string) bool {
+	if f.t == intType {
+		return LooseMatch(fmt.Sprintf("%v", v.IntAt(f.Key)), filter)
+	}
+	return LooseMatch(v.StringAt(f.Key), filter)
+}
+
+// matchedFields returns the fields of the selected row that have the filter in them
+func matchedFields(v column.Selector, fields []FilterField, filter string) []FilterField {
+	matched := make([]FilterField, 0)
+	for _, f := range fields {
+		if f.matches(v, filter) {
+			matched = append(matched, f)
+		}
+	}
+	return matched
+}
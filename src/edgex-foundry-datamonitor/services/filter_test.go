@@ -0,0 +1,76 @@
+// Copyright 2021 Alessandro De Blasis <alex@deblasis.net>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+package services
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func Test_MatchRanges(t *testing.T) {
+	require.Nil(t, MatchRanges("anything", ""))
+	require.Nil(t, MatchRanges("anything", "nothing"))
+
+	// case insensitive, every occurrence, without overlapping
+	require.Equal(t, [][]int{{0, 4}, {9, 13}}, MatchRanges("Temp and temp", "TEMP"))
+	require.Equal(t, [][]int{{0, 2}, {2, 4}}, MatchRanges("aaaa", "aa"))
+
+	// offsets are in bytes
+	text := "température 21°C"
+	ranges := MatchRanges(text, "°c")
+	require.Len(t, ranges, 1)
+	require.Equal(t, "°C", text[ranges[0][0]:ranges[0][1]])
+}
+
+func Test_MatchedInFields(t *testing.T) {
+	db := NewDB(1000)
+	db.OnEventReceived(dummyEvent())
+	interesting := interestingEvent()
+	interesting.Tags = map[string]string{"site": "plant-42"}
+	db.OnEventReceived(interesting)
+
+	// matched only through the tags of the parent event
+	db.UpdateFilter("PLANT-42")
+	require.Equal(t, "PLANT-42", db.GetFilter())
+	evts := db.GetEvents()
+	require.Len(t, evts, 1)
+	require.Equal(t, "tags", FilterFieldNames(evts[0].MatchedIn))
+
+	rdngs := db.GetReadings()
+	require.Len(t, rdngs, 2)
+	for _, r := range rdngs {
+		require.Equal(t, "event tags", FilterFieldNames(r.MatchedIn))
+	}
+
+	// the resource name is searched along with the other fields of the readings
+	db.UpdateFilter("interesting_resource")
+	rdngs = db.GetReadings()
+	require.NotEmpty(t, rdngs)
+	require.Equal(t, "resource name", FilterFieldNames(rdngs[0].MatchedIn))
+
+	// timestamps are matched on their value
+	db.UpdateFilter("2")
+	for _, r := range db.GetReadings() {
+		require.Contains(t, FilterFieldNames(r.MatchedIn), "event origin")
+		require.Contains(t, FilterFieldNames(r.MatchedIn), "origin")
+	}
+
+	// the rows that are no longer filtered don't carry matches around
+	db.UpdateFilter("")
+	for _, e := range db.GetEvents() {
+		require.Empty(t, e.MatchedIn)
+	}
+}
@@ -0,0 +1,181 @@
+// Copyright 2021 Alessandro De Blasis <alex@deblasis.net>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+package services
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/edgexfoundry/go-mod-core-contracts/v2/dtos"
+)
+
+// ResourceInfo is a resource of a device along with the value types its readings came with
+type ResourceInfo struct {
+	Name       string
+	ValueTypes []string
+}
+
+// DeviceInfo is what was learned about a device from its events
+type DeviceInfo struct {
+	Name      string
+	Profiles  []string
+	Resources []ResourceInfo
+
+	FirstSeen time.Time
+	LastSeen  time.Time
+
+	TotalEvents   int64
+	TotalReadings int64
+	// EventsPerSecond is the rate over the last minute
+	EventsPerSecond float64
+
+	// Tags holds the latest value of every tag the events of the device came with
+	Tags map[string]string
+}
+
+type inventoryEntry struct {
+	info      DeviceInfo
+	profiles  map[string]struct{}
+	resources map[string]map[string]struct{}
+	events    *rollingCounter
+}
+
+// DeviceInventory is an EventListener that builds the list of the devices seen on the bus
+type DeviceInventory struct {
+	devices map[string]*inventoryEntry
+	sync.Mutex
+}
+
+func NewDeviceInventory() *DeviceInventory {
+	return &DeviceInventory{
+		devices: map[string]*inventoryEntry{},
+	}
+}
+
+func (inv *DeviceInventory) OnEventReceived(event dtos.Event) {
+	inv.OnEventReceivedAt(event, time.Now())
+}
+
+func (inv *DeviceInventory) OnEventReceivedAt(event dtos.Event, receivedAt time.Time) {
+	inv.Lock()
+	defer inv.Unlock()
+
+	e := inv.entry(event.DeviceName, receivedAt)
+	e.seen(event.ProfileName, receivedAt)
+	e.info.TotalEvents++
+	e.events.add(receivedAt, 1)
+	for k, v := range event.Tags {
+		e.info.Tags[k] = v
+	}
+
+	for _, r := range event.Readings {
+		device := r.DeviceName
+		if device == "" {
+			device = event.DeviceName
+		}
+		re := e
+		if device != event.DeviceName {
+			re = inv.entry(device, receivedAt)
+			re.seen(r.ProfileName, receivedAt)
+		}
+		re.info.TotalReadings++
+		types, ok := re.resources[r.ResourceName]
+		if !ok {
+			types = map[string]struct{}{}
+			re.resources[r.ResourceName] = types
+		}
+		types[r.ValueType] = struct{}{}
+	}
+}
+
+// entry must be called holding the lock
+func (inv *DeviceInventory) entry(name string, now time.Time) *inventoryEntry {
+	e, ok := inv.devices[name]
+	if !ok {
+		e = &inventoryEntry{
+			info: DeviceInfo{
+				Name:      name,
+				FirstSeen: now,
+				Tags:      map[string]string{},
+			},
+			profiles:  map[string]struct{}{},
+			resources: map[string]map[string]struct{}{},
+			events:    newRollingCounter(throughputWindowSeconds),
+		}
+		inv.devices[name] = e
+	}
+	return e
+}
+
+func (e *inventoryEntry) seen(profile string, now time.Time) {
+	if profile != "" {
+		e.profiles[profile] = struct{}{}
+	}
+	if now.After(e.info.LastSeen) {
+		e.info.LastSeen = now
+	}
+}
+
+func (e *inventoryEntry) snapshot(now time.Time) DeviceInfo {
+	info := e.info
+	info.EventsPerSecond = e.events.perSecond(now, throughputWindowSeconds)
+
+	info.Profiles = sortedSet(e.profiles)
+	info.Resources = make([]ResourceInfo, 0, len(e.resources))
+	for name, types := range e.resources {
+		info.Resources = append(info.Resources, ResourceInfo{
+			Name:       name,
+			ValueTypes: sortedSet(types),
+		})
+	}
+	sort.Slice(info.Resources, func(i, j int) bool {
+		return info.Resources[i].Name < info.Resources[j].Name
+	})
+	info.Tags = make(map[string]string, len(e.info.Tags))
+	for k, v := range e.info.Tags {
+		info.Tags[k] = v
+	}
+	return info
+}
+
+func sortedSet(set map[string]struct{}) []string {
+	keys := make([]string, 0, len(set))
+	for k := range set {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// Devices returns the devices seen so far sorted by name
+func (inv *DeviceInventory) Devices() []DeviceInfo {
+	return inv.DevicesAt(time.Now())
+}
+
+// DevicesAt computes the rates as of now
+func (inv *DeviceInventory) DevicesAt(now time.Time) []DeviceInfo {
+	// the rolling counters move forward when read
+	inv.Lock()
+	defer inv.Unlock()
+	devices := make([]DeviceInfo, 0, len(inv.devices))
+	for _, e := range inv.devices {
+		devices = append(devices, e.snapshot(now))
+	}
+	sort.Slice(devices, func(i, j int) bool {
+		return devices[i].Name < devices[j].Name
+	})
+	return devices
+}
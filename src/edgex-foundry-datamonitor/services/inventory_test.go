@@ -0,0 +1,67 @@
+// Copyright 2021 Alessandro De Blasis <alex@deblasis.net>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+package services
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func Test_DeviceInventory(t *testing.T) {
+	inv := NewDeviceInventory()
+	start := time.Unix(1000, 0)
+
+	event := readingEvent("thermostat", "temp", "20")
+	event.Tags = map[string]string{"site": "north"}
+	inv.OnEventReceivedAt(event, start)
+
+	event = readingEvent("thermostat", "mode", "eco")
+	event.Readings[0].ValueType = "String"
+	event.Tags = map[string]string{"site": "south", "floor": "1"}
+	inv.OnEventReceivedAt(event, start.Add(time.Second))
+
+	event = readingEvent("thermostat", "temp", "21")
+	event.Readings[0].ValueType = "Float32"
+	inv.OnEventReceivedAt(event, start.Add(2*time.Second))
+
+	inv.OnEventReceivedAt(readingEvent("boiler", "pressure", "3"), start.Add(3*time.Second))
+
+	devices := inv.DevicesAt(start.Add(3 * time.Second))
+	require.Len(t, devices, 2)
+	require.Equal(t, "boiler", devices[0].Name)
+
+	thermostat := devices[1]
+	require.Equal(t, "thermostat", thermostat.Name)
+	require.Equal(t, []string{"profile"}, thermostat.Profiles)
+	require.Equal(t, []ResourceInfo{
+		{Name: "mode", ValueTypes: []string{"String"}},
+		{Name: "temp", ValueTypes: []string{"Float32", "Int32"}},
+	}, thermostat.Resources)
+	require.Equal(t, start, thermostat.FirstSeen)
+	require.Equal(t, start.Add(2*time.Second), thermostat.LastSeen)
+	require.Equal(t, int64(3), thermostat.TotalEvents)
+	require.Equal(t, int64(3), thermostat.TotalReadings)
+	require.Equal(t, 3.0/throughputWindowSeconds, thermostat.EventsPerSecond)
+	require.Equal(t, map[string]string{"site": "south", "floor": "1"}, thermostat.Tags)
+
+	// the snapshot is a copy
+	thermostat.Tags["site"] = "west"
+	require.Equal(t, "south", inv.DevicesAt(start.Add(3 * time.Second))[1].Tags["site"])
+
+	// the rate goes down once the device is quiet
+	require.Equal(t, 0.0, inv.DevicesAt(start.Add(2 * time.Minute))[1].EventsPerSecond)
+}
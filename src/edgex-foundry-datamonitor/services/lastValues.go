@@ -0,0 +1,129 @@
+// Copyright 2021 Alessandro De Blasis <alex@deblasis.net>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+package services
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/edgexfoundry/go-mod-core-contracts/v2/dtos"
+)
+
+// LastValue is the latest reading of a resource of a device
+type LastValue struct {
+	Device    string
+	Resource  string
+	ValueType string
+	// Value is empty for binary readings, MediaType tells what they carry
+	Value     string
+	MediaType string
+	Origin    int64
+
+	ReceivedAt time.Time
+	Trend      Trend
+}
+
+type lastValueKey struct {
+	device   string
+	resource string
+}
+
+// lastValuesTracker remembers the latest reading of every device and resource seen so far
+type lastValuesTracker struct {
+	values map[lastValueKey]*LastValue
+	sync.RWMutex
+}
+
+func newLastValuesTracker() *lastValuesTracker {
+	return &lastValuesTracker{
+		values: map[lastValueKey]*LastValue{},
+	}
+}
+
+func (t *lastValuesTracker) track(event *dtos.Event, now time.Time) {
+	t.Lock()
+	defer t.Unlock()
+	for _, r := range event.Readings {
+		device := r.DeviceName
+		if device == "" {
+			device = event.DeviceName
+		}
+		key := lastValueKey{device: device, resource: r.ResourceName}
+
+		trend := TrendUnknown
+		if previous, ok := t.values[key]; ok {
+			trend = trendOf(previous, r)
+		}
+		t.values[key] = &LastValue{
+			Device:     device,
+			Resource:   r.ResourceName,
+			ValueType:  r.ValueType,
+			Value:      r.Value,
+			MediaType:  r.MediaType,
+			Origin:     r.Origin,
+			ReceivedAt: now,
+			Trend:      trend,
+		}
+	}
+}
+
+func trendOf(previous *LastValue, reading dtos.BaseReading) Trend {
+	before, ok := numericValue(dtos.BaseReading{ValueType: previous.ValueType, SimpleReading: dtos.SimpleReading{Value: previous.Value}})
+	if !ok {
+		return TrendUnknown
+	}
+	after, ok := numericValue(reading)
+	if !ok {
+		return TrendUnknown
+	}
+	switch {
+	case after > before:
+		return TrendUp
+	case after < before:
+		return TrendDown
+	}
+	return TrendSteady
+}
+
+// get returns the last values sorted by device and resource
+func (t *lastValuesTracker) get() []LastValue {
+	t.RLock()
+	defer t.RUnlock()
+	values := make([]LastValue, 0, len(t.values))
+	for _, v := range t.values {
+		values = append(values, *v)
+	}
+	sort.Slice(values, func(i, j int) bool {
+		if values[i].Device != values[j].Device {
+			return values[i].Device < values[j].Device
+		}
+		return values[i].Resource < values[j].Resource
+	})
+	return values
+}
+
+// LastValues returns the latest reading of every device and resource, sorted by device and resource
+func (ep *EventProcessor) LastValues() []LastValue {
+	return ep.lastValues.get()
+}
+
+// SetLastEventsCount changes how many of the latest events are kept in LastEvents, the oldest ones go first
+func (ep *EventProcessor) SetLastEventsCount(n int) {
+	if n < 1 {
+		n = 1
+	}
+	ep.LastEvents.Resize(n)
+}
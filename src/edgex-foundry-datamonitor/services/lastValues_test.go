@@ -0,0 +1,91 @@
+// Copyright 2021 Alessandro De Blasis <alex@deblasis.net>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+package services
+
+import (
+	"testing"
+
+	"github.com/edgexfoundry/go-mod-core-contracts/v2/dtos"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_LastValuesAndTrends(t *testing.T) {
+	ep := NewEventProcessor(make(chan *dtos.Event))
+
+	send := func(device, resource, value string) {
+		event := readingEvent(device, resource, value)
+		ep.processEvent(&event)
+	}
+	send("thermostat", "temp", "20")
+	send("boiler", "pressure", "3")
+	send("thermostat", "temp", "21.5")
+	send("thermostat", "humidity", "40")
+	send("thermostat", "humidity", "40")
+	send("boiler", "pressure", "2")
+
+	values := ep.LastValues()
+	require.Len(t, values, 3)
+
+	require.Equal(t, "boiler", values[0].Device)
+	require.Equal(t, "2", values[0].Value)
+	require.Equal(t, TrendDown, values[0].Trend)
+
+	require.Equal(t, "humidity", values[1].Resource)
+	require.Equal(t, TrendSteady, values[1].Trend)
+
+	require.Equal(t, "temp", values[2].Resource)
+	require.Equal(t, "21.5", values[2].Value)
+	require.Equal(t, "Int32", values[2].ValueType)
+	require.Equal(t, TrendUp, values[2].Trend)
+
+	// the trend of a value that isn't a number is unknown
+	send("thermostat", "temp", "n/a")
+	require.Equal(t, TrendUnknown, ep.LastValues()[2].Trend)
+	send("thermostat", "temp", "22")
+	require.Equal(t, TrendUnknown, ep.LastValues()[2].Trend)
+
+	// the last values are not a measurement, they survive a reset
+	ep.ResetStats()
+	require.Len(t, ep.LastValues(), 3)
+}
+
+func Test_LastEventsCount(t *testing.T) {
+	ep := NewEventProcessor(make(chan *dtos.Event))
+	send := func(n int) {
+		for i := 0; i < n; i++ {
+			event := dummyEvent()
+			event.Origin = int64(i)
+			ep.processEvent(&event)
+		}
+	}
+
+	send(10)
+	require.Len(t, ep.LastEvents.Get(), 5)
+
+	ep.SetLastEventsCount(8)
+	send(10)
+	events := ep.LastEvents.Get()
+	require.Len(t, events, 8)
+	require.Equal(t, int64(9), events[7].Origin)
+
+	// shrinking keeps the newest
+	ep.SetLastEventsCount(3)
+	events = ep.LastEvents.Get()
+	require.Len(t, events, 3)
+	require.Equal(t, int64(7), events[0].Origin)
+
+	send(1)
+	require.Len(t, ep.LastEvents.Get(), 3)
+}
@@ -0,0 +1,206 @@
+// Copyright 2021 Alessandro De Blasis <alex@deblasis.net>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+package services
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/edgexfoundry/go-mod-core-contracts/v2/dtos"
+)
+
+const (
+	// latencySampleSize is how many of the latest events the overall percentiles are computed on
+	latencySampleSize = 1024
+	// latencySampleSizePerKey is the same for every device, profile and resource
+	latencySampleSizePerKey = 128
+)
+
+// LatencyStats summarises the time between the origin of the events and their receipt,
+// negative values mean that the clocks of the device service and ours are skewed
+type LatencyStats struct {
+	Samples int
+	P50     time.Duration
+	P95     time.Duration
+	P99     time.Duration
+	Max     time.Duration
+}
+
+// LatencyBuckets are the upper bounds of the latency histograms
+var LatencyBuckets = []time.Duration{
+	time.Millisecond,
+	5 * time.Millisecond,
+	10 * time.Millisecond,
+	25 * time.Millisecond,
+	50 * time.Millisecond,
+	100 * time.Millisecond,
+	250 * time.Millisecond,
+	500 * time.Millisecond,
+	time.Second,
+	2500 * time.Millisecond,
+	5 * time.Second,
+	10 * time.Second,
+}
+
+// LatencyHistogram counts every latency measured, unlike LatencyStats that only looks at the latest ones.
+// Counts[i] is how many were up to LatencyBuckets[i], the last one counts those beyond the buckets
+type LatencyHistogram struct {
+	Counts []uint64
+	Count  uint64
+	Sum    time.Duration
+}
+
+type latencyHistogram struct {
+	LatencyHistogram
+}
+
+func newLatencyHistogram() *latencyHistogram {
+	return &latencyHistogram{
+		LatencyHistogram: LatencyHistogram{
+			Counts: make([]uint64, len(LatencyBuckets)+1),
+		},
+	}
+}
+
+func (h *latencyHistogram) add(d time.Duration) {
+	i := sort.Search(len(LatencyBuckets), func(i int) bool {
+		return d <= LatencyBuckets[i]
+	})
+	h.Counts[i]++
+	h.Count++
+	h.Sum += d
+}
+
+func (h *latencyHistogram) snapshot() LatencyHistogram {
+	counts := make([]uint64, len(h.Counts))
+	copy(counts, h.Counts)
+	return LatencyHistogram{
+		Counts: counts,
+		Count:  h.Count,
+		Sum:    h.Sum,
+	}
+}
+
+// Latency returns how long it took for the data to get here, zero when the origin is unknown
+func Latency(origin int64, receivedAt int64) (time.Duration, bool) {
+	if origin == 0 || receivedAt == 0 {
+		return 0, false
+	}
+	return time.Duration(receivedAt - origin), true
+}
+
+// latencySamples keeps the latest latencies in a ring
+type latencySamples struct {
+	values []time.Duration
+	next   int
+	full   bool
+}
+
+func newLatencySamples(size int) *latencySamples {
+	return &latencySamples{
+		values: make([]time.Duration, size),
+	}
+}
+
+func (s *latencySamples) add(d time.Duration) {
+	s.values[s.next] = d
+	s.next++
+	if s.next == len(s.values) {
+		s.next = 0
+		s.full = true
+	}
+}
+
+func (s *latencySamples) stats() LatencyStats {
+	n := s.next
+	if s.full {
+		n = len(s.values)
+	}
+	if n == 0 {
+		return LatencyStats{}
+	}
+
+	sorted := make([]time.Duration, n)
+	copy(sorted, s.values[:n])
+	sort.Slice(sorted, func(i, j int) bool {
+		return sorted[i] < sorted[j]
+	})
+
+	return LatencyStats{
+		Samples: n,
+		P50:     percentile(sorted, 50),
+		P95:     percentile(sorted, 95),
+		P99:     percentile(sorted, 99),
+		Max:     sorted[n-1],
+	}
+}
+
+// percentile uses the nearest rank on sorted values
+func percentile(sorted []time.Duration, p int) time.Duration {
+	rank := (p*len(sorted) + 99) / 100
+	if rank < 1 {
+		rank = 1
+	}
+	return sorted[rank-1]
+}
+
+type latencyTracker struct {
+	samples   *latencySamples
+	histogram *latencyHistogram
+	sync.Mutex
+}
+
+func newLatencyTracker() *latencyTracker {
+	return &latencyTracker{
+		samples:   newLatencySamples(latencySampleSize),
+		histogram: newLatencyHistogram(),
+	}
+}
+
+func (t *latencyTracker) track(event *dtos.Event, receivedAt time.Time) {
+	latency, ok := Latency(event.Origin, receivedAt.UnixNano())
+	if !ok {
+		return
+	}
+	t.Lock()
+	defer t.Unlock()
+	t.samples.add(latency)
+	t.histogram.add(latency)
+}
+
+func (t *latencyTracker) reset() {
+	t.Lock()
+	defer t.Unlock()
+	t.samples = newLatencySamples(latencySampleSize)
+	t.histogram = newLatencyHistogram()
+}
+
+func (t *latencyTracker) histogramSnapshot() LatencyHistogram {
+	t.Lock()
+	defer t.Unlock()
+	return t.histogram.snapshot()
+}
+
+func (t *latencyTracker) stats() LatencyStats {
+	t.Lock()
+	defer t.Unlock()
+	return t.samples.stats()
+}
+
+// Latency returns the percentiles of the latency of the latest events
+func (ep *EventProcessor) Latency() LatencyStats {
+	return ep.latency.stats()
+}
@@ -0,0 +1,94 @@
+// Copyright 2021 Alessandro De Blasis <alex@deblasis.net>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+package services
+
+import (
+	"testing"
+	"time"
+
+	"github.com/edgexfoundry/go-mod-core-contracts/v2/dtos"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_LatencyPercentiles(t *testing.T) {
+	s := newLatencySamples(100)
+	require.Equal(t, LatencyStats{}, s.stats())
+
+	for i := 100; i >= 1; i-- {
+		s.add(time.Duration(i) * time.Millisecond)
+	}
+	stats := s.stats()
+	require.Equal(t, 100, stats.Samples)
+	require.Equal(t, 50*time.Millisecond, stats.P50)
+	require.Equal(t, 95*time.Millisecond, stats.P95)
+	require.Equal(t, 99*time.Millisecond, stats.P99)
+	require.Equal(t, 100*time.Millisecond, stats.Max)
+
+	// the oldest samples make room for the new ones
+	for i := 0; i < 100; i++ {
+		s.add(-time.Second)
+	}
+	stats = s.stats()
+	require.Equal(t, -time.Second, stats.P99)
+}
+
+type receiptListener struct {
+	receivedAt chan time.Time
+}
+
+func (l *receiptListener) OnEventReceived(event dtos.Event) {
+	panic("OnEventReceivedAt should be preferred")
+}
+
+func (l *receiptListener) OnEventReceivedAt(event dtos.Event, receivedAt time.Time) {
+	l.receivedAt <- receivedAt
+}
+
+func Test_LatencyIsMeasuredOnReceipt(t *testing.T) {
+	ep := NewEventProcessor(make(chan *dtos.Event))
+	l := &receiptListener{receivedAt: make(chan time.Time, 1)}
+	ep.AttachListener(l)
+
+	db := NewDB(1000)
+	event := dummyEvent()
+	event.Origin = time.Now().Add(-time.Second).UnixNano()
+	ep.processEvent(&event)
+
+	receivedAt := <-l.receivedAt
+	db.OnEventReceivedAt(event, receivedAt)
+
+	stats := ep.Latency()
+	require.Equal(t, 1, stats.Samples)
+	require.GreaterOrEqual(t, int64(stats.P50), int64(time.Second))
+
+	devices := ep.Throughput(ByDevice)
+	require.Equal(t, stats.P50, devices[0].Latency.P50)
+
+	evts := db.GetEvents()
+	require.Equal(t, receivedAt.UnixNano(), evts[0].ReceivedAt)
+	latency, ok := evts[0].Latency()
+	require.True(t, ok)
+	require.Equal(t, stats.P50, latency)
+
+	// the reading has its own origin
+	rdngs := db.GetReadings()
+	latency, ok = rdngs[0].Latency()
+	require.True(t, ok)
+	require.Equal(t, time.Duration(receivedAt.UnixNano()-event.Readings[0].Origin), latency)
+
+	_, ok = Latency(0, receivedAt.UnixNano())
+	require.False(t, ok)
+	require.True(t, ep.DetachListener(l))
+}
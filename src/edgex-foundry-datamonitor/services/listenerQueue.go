@@ -0,0 +1,237 @@
+// Copyright 2021 Alessandro De Blasis <alex@deblasis.net>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+package services
+
+import (
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/deblasis/edgex-foundry-datamonitor/config"
+	"github.com/edgexfoundry/go-mod-core-contracts/v2/dtos"
+)
+
+// ListenerOptions configures the queue sitting in front of an EventListener
+type ListenerOptions struct {
+	// QueueSize is the maximum number of events waiting to be delivered
+	QueueSize int
+	// Overflow decides what happens when the queue is full
+	Overflow OverflowPolicy
+}
+
+// DefaultListenerOptions are used by AttachListener
+var DefaultListenerOptions = ListenerOptions{
+	QueueSize: config.DefaultListenerQueueSize,
+	Overflow:  DropOldestOnOverflow,
+}
+
+// ListenerStats is a snapshot of the delivery metrics of a single listener
+type ListenerStats struct {
+	Name          string
+	Overflow      OverflowPolicy
+	QueueDepth    int
+	QueueCapacity int
+	Delivered     uint64
+	Dropped       uint64
+	// LastLag is the time the last delivered event spent in the queue
+	LastLag time.Duration
+	// MaxLag is the longest time an event spent in the queue
+	MaxLag time.Duration
+}
+
+type queuedEvent struct {
+	event      dtos.Event
+	receivedAt time.Time
+	queuedAt   time.Time
+}
+
+// listenerQueue delivers events to a listener from its own goroutine
+// so that a slow listener cannot hold back the others
+type listenerQueue struct {
+	// accessed atomically, kept first for 64-bit alignment
+	delivered uint64
+	dropped   uint64
+	lastLag   int64
+	maxLag    int64
+
+	listener EventListener
+	options  ListenerOptions
+
+	queue   chan queuedEvent
+	closing chan struct{}
+	done    chan struct{}
+	stopped chan struct{}
+
+	closeOnce sync.Once
+	stopOnce  sync.Once
+}
+
+func newListenerQueue(listener EventListener, options ListenerOptions) *listenerQueue {
+	if options.QueueSize < 1 {
+		options.QueueSize = 1
+	}
+	q := &listenerQueue{
+		listener: listener,
+		options:  options,
+
+		queue:   make(chan queuedEvent, options.QueueSize),
+		closing: make(chan struct{}),
+		done:    make(chan struct{}),
+		stopped: make(chan struct{}),
+	}
+	go q.run()
+	return q
+}
+
+func (q *listenerQueue) run() {
+	defer close(q.stopped)
+	for {
+		// a stopped queue must not pick another event, even if there are some ready
+		select {
+		case <-q.done:
+			return
+		default:
+		}
+		select {
+		case <-q.done:
+			return
+		case <-q.closing:
+			q.drain()
+			return
+		case item := <-q.queue:
+			q.deliver(item)
+		}
+	}
+}
+
+// drain delivers what is left in the queue, unless the queue is stopped meanwhile
+func (q *listenerQueue) drain() {
+	for {
+		select {
+		case <-q.done:
+			return
+		default:
+		}
+		select {
+		case item := <-q.queue:
+			q.deliver(item)
+		default:
+			return
+		}
+	}
+}
+
+func (q *listenerQueue) deliver(item queuedEvent) {
+	defer func() {
+		if err := recover(); err != nil {
+			log.Errorf("listener %v panicked: %v", q.name(), err)
+		}
+	}()
+
+	lag := int64(time.Since(item.queuedAt))
+	atomic.StoreInt64(&q.lastLag, lag)
+	for {
+		max := atomic.LoadInt64(&q.maxLag)
+		if lag <= max || atomic.CompareAndSwapInt64(&q.maxLag, max, lag) {
+			break
+		}
+	}
+
+	if l, ok := q.listener.(ReceiptListener); ok {
+		l.OnEventReceivedAt(item.event, item.receivedAt)
+	} else {
+		q.listener.OnEventReceived(item.event)
+	}
+	atomic.AddUint64(&q.delivered, 1)
+}
+
+func (q *listenerQueue) enqueue(event dtos.Event, receivedAt time.Time) {
+	item := queuedEvent{
+		event:      event,
+		receivedAt: receivedAt,
+		queuedAt:   time.Now(),
+	}
+
+	switch q.options.Overflow {
+	case BlockOnOverflow:
+		select {
+		case q.queue <- item:
+		case <-q.stopped:
+		}
+	case DropNewestOnOverflow:
+		select {
+		case q.queue <- item:
+		default:
+			atomic.AddUint64(&q.dropped, 1)
+		}
+	case DropOldestOnOverflow:
+		for {
+			select {
+			case q.queue <- item:
+				return
+			default:
+			}
+			// making room, the listener might have already done it for us
+			select {
+			case <-q.queue:
+				atomic.AddUint64(&q.dropped, 1)
+			default:
+			}
+		}
+	default:
+		log.Fatalf("unhandled overflow policy %v", q.options.Overflow)
+	}
+}
+
+// close delivers the pending events and then terminates the delivery goroutine
+func (q *listenerQueue) close() {
+	q.closeOnce.Do(func() {
+		close(q.closing)
+	})
+	<-q.stopped
+}
+
+// stop terminates the delivery goroutine, pending events are discarded
+func (q *listenerQueue) stop() {
+	q.abort()
+	<-q.stopped
+}
+
+// abort tells the delivery goroutine to exit as soon as the listener returns, without waiting for it
+func (q *listenerQueue) abort() {
+	q.stopOnce.Do(func() {
+		close(q.done)
+	})
+}
+
+func (q *listenerQueue) name() string {
+	return fmt.Sprintf("%T", q.listener)
+}
+
+func (q *listenerQueue) stats() ListenerStats {
+	return ListenerStats{
+		Name:          q.name(),
+		Overflow:      q.options.Overflow,
+		QueueDepth:    len(q.queue),
+		QueueCapacity: cap(q.queue),
+		Delivered:     atomic.LoadUint64(&q.delivered),
+		Dropped:       atomic.LoadUint64(&q.dropped),
+		LastLag:       time.Duration(atomic.LoadInt64(&q.lastLag)),
+		MaxLag:        time.Duration(atomic.LoadInt64(&q.maxLag)),
+	}
+}
@@ -0,0 +1,245 @@
+// Copyright 2021 Alessandro De Blasis <alex@deblasis.net>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+package services
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	// MetricsPath is where the metrics are served in the Prometheus text format
+	MetricsPath = "/metrics"
+
+	metricsPrefix = "edgex_datamonitor_"
+)
+
+// MetricsServer exposes what the monitor sees on the bus as Prometheus metrics, it's stopped until started
+type MetricsServer struct {
+	ep *EventProcessor
+	db *DB
+
+	server   *http.Server
+	listener net.Listener
+	sync.Mutex
+}
+
+func NewMetricsServer(ep *EventProcessor, db *DB) *MetricsServer {
+	return &MetricsServer{
+		ep: ep,
+		db: db,
+	}
+}
+
+// Start serves the metrics on the port, a server already running is stopped first.
+// The port is bound before returning, so that it being taken is reported to the caller
+func (m *MetricsServer) Start(port int) error {
+	if err := m.Stop(context.Background()); err != nil {
+		return err
+	}
+
+	listener, err := net.Listen("tcp", fmt.Sprintf(":%d", port))
+	if err != nil {
+		return err
+	}
+
+	mux := http.NewServeMux()
+	mux.Handle(MetricsPath, m)
+	server := &http.Server{
+		Handler:           mux,
+		ReadHeaderTimeout: 5 * time.Second,
+	}
+
+	m.Lock()
+	m.server = server
+	m.listener = listener
+	m.Unlock()
+
+	go func() {
+		if err := server.Serve(listener); err != nil && err != http.ErrServerClosed {
+			log.Errorf("MetricsServer: %v", err)
+		}
+	}()
+	log.Infof("MetricsServer: serving on %v%v", listener.Addr(), MetricsPath)
+	return nil
+}
+
+// Stop waits for the scrapes in flight until the context expires, it does nothing if the server isn't running
+func (m *MetricsServer) Stop(ctx context.Context) error {
+	m.Lock()
+	server := m.server
+	m.server = nil
+	m.listener = nil
+	m.Unlock()
+
+	if server == nil {
+		return nil
+	}
+	log.Info("MetricsServer: stopping")
+	return server.Shutdown(ctx)
+}
+
+// Port returns the port being served, zero when the server isn't running
+func (m *MetricsServer) Port() int {
+	m.Lock()
+	defer m.Unlock()
+	if m.listener == nil {
+		return 0
+	}
+	return m.listener.Addr().(*net.TCPAddr).Port
+}
+
+func (m *MetricsServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		w.Header().Set("Allow", "GET, HEAD")
+		http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+		return
+	}
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	m.WriteMetrics(w)
+}
+
+// WriteMetrics writes a snapshot of the metrics in the Prometheus text exposition format
+func (m *MetricsServer) WriteMetrics(w io.Writer) {
+	mw := &metricsWriter{w: w}
+	stats := m.ep.Stats()
+
+	mw.family("events_total", "counter", "Events received since the start of the measurement period")
+	mw.sample("events_total", nil, float64(stats.TotalEvents))
+	mw.family("readings_total", "counter", "Readings received since the start of the measurement period")
+	mw.sample("readings_total", nil, float64(stats.TotalReadings))
+	mw.family("parse_failures_total", "counter", "Payloads that could not be parsed into events")
+	mw.sample("parse_failures_total", nil, float64(stats.ParseFailures))
+
+	mw.family("ingestion_queue_depth", "gauge", "Events waiting to be processed")
+	mw.sample("ingestion_queue_depth", nil, float64(stats.QueueDepth))
+	mw.family("ingestion_queue_capacity", "gauge", "Events that can wait to be processed")
+	mw.sample("ingestion_queue_capacity", nil, float64(stats.QueueCapacity))
+
+	listeners := m.ep.ListenerStats()
+	mw.family("listener_queue_depth", "gauge", "Events waiting to be delivered to the listener")
+	for _, l := range listeners {
+		mw.sample("listener_queue_depth", labels{"listener", l.Name}, float64(l.QueueDepth))
+	}
+	mw.family("listener_queue_capacity", "gauge", "Events that can wait to be delivered to the listener")
+	for _, l := range listeners {
+		mw.sample("listener_queue_capacity", labels{"listener", l.Name}, float64(l.QueueCapacity))
+	}
+	mw.family("listener_delivered_total", "counter", "Events delivered to the listener")
+	for _, l := range listeners {
+		mw.sample("listener_delivered_total", labels{"listener", l.Name}, float64(l.Delivered))
+	}
+	mw.family("listener_dropped_total", "counter", "Events dropped because the queue of the listener was full")
+	for _, l := range listeners {
+		mw.sample("listener_dropped_total", labels{"listener", l.Name}, float64(l.Dropped))
+	}
+
+	mw.family("db_buffer_size", "gauge", "Events, and as many readings, the buffer can hold")
+	mw.sample("db_buffer_size", nil, float64(m.db.GetBufferSize()))
+	mw.family("db_buffered_events", "gauge", "Events taking space in the buffer")
+	mw.sample("db_buffered_events", nil, float64(m.db.GetTotalEventsCount()))
+	mw.family("db_buffered_readings", "gauge", "Readings taking space in the buffer")
+	mw.sample("db_buffered_readings", nil, float64(m.db.GetTotalReadingsCount()))
+	mw.family("duplicate_events_total", "counter", "Duplicate events dropped by the buffer")
+	mw.sample("duplicate_events_total", nil, float64(m.db.GetDuplicatesCount()))
+
+	devices := m.ep.Throughput(ByDevice)
+	sort.Slice(devices, func(i, j int) bool {
+		return devices[i].Name < devices[j].Name
+	})
+	mw.family("device_events_per_second", "gauge", "Events per second of the device over the last minute")
+	for _, d := range devices {
+		mw.sample("device_events_per_second", labels{"device", d.Name}, d.EventsPerSecond)
+	}
+	mw.family("device_readings_per_second", "gauge", "Readings per second of the device over the last minute")
+	for _, d := range devices {
+		mw.sample("device_readings_per_second", labels{"device", d.Name}, d.ReadingsPerSecond)
+	}
+	mw.family("device_events_total", "counter", "Events received from the device")
+	for _, d := range devices {
+		mw.sample("device_events_total", labels{"device", d.Name}, float64(d.TotalEvents))
+	}
+	mw.family("device_readings_total", "counter", "Readings received from the device")
+	for _, d := range devices {
+		mw.sample("device_readings_total", labels{"device", d.Name}, float64(d.TotalReadings))
+	}
+
+	mw.family("event_latency_seconds", "histogram", "Time between the origin of the events and their receipt")
+	mw.histogram("event_latency_seconds", nil, stats.LatencyHistogram)
+	mw.family("device_latency_seconds", "histogram", "Time between the origin of the events of the device and their receipt")
+	for _, d := range devices {
+		mw.histogram("device_latency_seconds", labels{"device", d.Name}, d.LatencyHistogram)
+	}
+}
+
+// labels are name and value pairs
+type labels []string
+
+type metricsWriter struct {
+	w io.Writer
+}
+
+func (mw *metricsWriter) family(name, kind, help string) {
+	fmt.Fprintf(mw.w, "# HELP %s%s %s\n", metricsPrefix, name, help)
+	fmt.Fprintf(mw.w, "# TYPE %s%s %s\n", metricsPrefix, name, kind)
+}
+
+func (mw *metricsWriter) sample(name string, l labels, value float64) {
+	fmt.Fprintf(mw.w, "%s%s%s %s\n", metricsPrefix, name, formatLabels(l), formatMetricValue(value))
+}
+
+func (mw *metricsWriter) histogram(name string, l labels, h LatencyHistogram) {
+	var cumulative uint64
+	for i, bound := range LatencyBuckets {
+		if i < len(h.Counts) {
+			cumulative += h.Counts[i]
+		}
+		mw.sample(name+"_bucket", append(l[:len(l):len(l)], "le", formatMetricValue(bound.Seconds())), float64(cumulative))
+	}
+	mw.sample(name+"_bucket", append(l[:len(l):len(l)], "le", "+Inf"), float64(h.Count))
+	mw.sample(name+"_sum", l, h.Sum.Seconds())
+	mw.sample(name+"_count", l, float64(h.Count))
+}
+
+func formatLabels(l labels) string {
+	if len(l) == 0 {
+		return ""
+	}
+	pairs := make([]string, 0, len(l)/2)
+	for i := 0; i+1 < len(l); i += 2 {
+		pairs = append(pairs, fmt.Sprintf("%s=\"%s\"", l[i], escapeLabelValue(l[i+1])))
+	}
+	return "{" + strings.Join(pairs, ",") + "}"
+}
+
+var labelValueEscaper = strings.NewReplacer(`\`, `\\`, "\n", `\n`, `"`, `\"`)
+
+func escapeLabelValue(s string) string {
+	return labelValueEscaper.Replace(s)
+}
+
+func formatMetricValue(v float64) string {
+	return strconv.FormatFloat(v, 'g', -1, 64)
+}
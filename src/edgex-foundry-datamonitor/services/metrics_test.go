@@ -0,0 +1,99 @@
+// Copyright 2021 Alessandro De Blasis <alex@deblasis.net>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+package services
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/edgexfoundry/go-mod-core-contracts/v2/dtos"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_MetricsExposition(t *testing.T) {
+	ep := NewEventProcessor(make(chan *dtos.Event, 8))
+	db := NewDB(0)
+	db.UpdateBufferSize(10)
+
+	now := time.Now()
+	for _, latency := range []time.Duration{3 * time.Millisecond, 200 * time.Millisecond, time.Minute} {
+		event := readingEvent(`dev"1`, "temp", "20")
+		event.Origin = now.Add(-latency).UnixNano()
+		ep.processEvent(&event)
+		db.OnEventReceivedAt(event, now)
+	}
+	ep.CountParseFailure()
+
+	rec := httptest.NewRecorder()
+	NewMetricsServer(ep, db).ServeHTTP(rec, httptest.NewRequest(http.MethodGet, MetricsPath, nil))
+	require.Equal(t, http.StatusOK, rec.Code)
+	body := rec.Body.String()
+
+	require.Contains(t, body, "# TYPE edgex_datamonitor_events_total counter\nedgex_datamonitor_events_total 3\n")
+	require.Contains(t, body, "edgex_datamonitor_readings_total 3\n")
+	require.Contains(t, body, "edgex_datamonitor_parse_failures_total 1\n")
+	require.Contains(t, body, "edgex_datamonitor_ingestion_queue_capacity 8\n")
+	require.Contains(t, body, "edgex_datamonitor_db_buffer_size 10\n")
+	require.Contains(t, body, "edgex_datamonitor_db_buffered_events 3\n")
+	require.Contains(t, body, `edgex_datamonitor_device_events_total{device="dev\"1"} 3`+"\n")
+
+	// buckets are cumulative, the one minute latency is beyond all of them
+	require.Contains(t, body, "# TYPE edgex_datamonitor_event_latency_seconds histogram\n")
+	require.Contains(t, body, `edgex_datamonitor_event_latency_seconds_bucket{le="0.001"} 0`+"\n")
+	require.Contains(t, body, `edgex_datamonitor_event_latency_seconds_bucket{le="0.005"} 1`+"\n")
+	require.Contains(t, body, `edgex_datamonitor_event_latency_seconds_bucket{le="0.25"} 2`+"\n")
+	require.Contains(t, body, `edgex_datamonitor_event_latency_seconds_bucket{le="10"} 2`+"\n")
+	require.Contains(t, body, `edgex_datamonitor_event_latency_seconds_bucket{le="+Inf"} 3`+"\n")
+	require.Contains(t, body, "edgex_datamonitor_event_latency_seconds_count 3\n")
+	require.Contains(t, body, `edgex_datamonitor_device_latency_seconds_bucket{device="dev\"1",le="+Inf"} 3`+"\n")
+
+	rec = httptest.NewRecorder()
+	NewMetricsServer(ep, db).ServeHTTP(rec, httptest.NewRequest(http.MethodPost, MetricsPath, nil))
+	require.Equal(t, http.StatusMethodNotAllowed, rec.Code)
+}
+
+func Test_MetricsServerStartAndStop(t *testing.T) {
+	ep := NewEventProcessor(make(chan *dtos.Event))
+	m := NewMetricsServer(ep, NewDB(0))
+	require.Equal(t, 0, m.Port())
+
+	// port zero picks a free one
+	require.NoError(t, m.Start(0))
+	port := m.Port()
+	require.NotZero(t, port)
+
+	resp, err := http.Get(fmt.Sprintf("http://localhost:%d%v", port, MetricsPath))
+	require.NoError(t, err)
+	body, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	require.NoError(t, err)
+	require.Contains(t, string(body), "edgex_datamonitor_events_total 0\n")
+
+	// the port is taken while the server runs
+	other := NewMetricsServer(ep, NewDB(0))
+	require.Error(t, other.Start(port))
+
+	require.NoError(t, m.Stop(context.Background()))
+	require.Equal(t, 0, m.Port())
+	require.NoError(t, m.Stop(context.Background()))
+
+	require.NoError(t, other.Start(port))
+	require.NoError(t, other.Stop(context.Background()))
+}
@@ -0,0 +1,171 @@
+// Copyright 2021 Alessandro De Blasis <alex@deblasis.net>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+package services
+
+import (
+	"sort"
+	"time"
+
+	"github.com/kelindar/column"
+)
+
+type pin struct {
+	index uint32
+	note  string
+}
+
+// PinEvent exempts the event from eviction, it returns false if the event is no longer buffered
+func (db *DB) PinEvent(serial int64) bool {
+	db.Lock()
+	defer db.Unlock()
+	return pinRow(db.eventRows, db.pinnedEvents, serial)
+}
+
+// UnpinEvent puts the event back in the buffer, where it might be evicted straight away if it's old
+func (db *DB) UnpinEvent(serial int64) bool {
+	db.Lock()
+	defer db.Unlock()
+	if !unpinRow(db.eventRows, db.pinnedEvents, serial) {
+		return false
+	}
+	db.evictOldEvents()
+	return true
+}
+
+// PinReading exempts the reading from eviction, it returns false if the reading is no longer buffered
+func (db *DB) PinReading(serial int64) bool {
+	db.Lock()
+	defer db.Unlock()
+	return pinRow(db.readingRows, db.pinnedReadings, serial)
+}
+
+// UnpinReading puts the reading back in the buffer, where it might be evicted straight away if it's old
+func (db *DB) UnpinReading(serial int64) bool {
+	db.Lock()
+	defer db.Unlock()
+	if !unpinRow(db.readingRows, db.pinnedReadings, serial) {
+		return false
+	}
+	db.evictOldReadings()
+	return true
+}
+
+// SetEventNote annotates a pinned event
+func (db *DB) SetEventNote(serial int64, note string) bool {
+	db.Lock()
+	defer db.Unlock()
+	p, ok := db.pinnedEvents[serial]
+	if ok {
+		p.note = note
+	}
+	return ok
+}
+
+// SetReadingNote annotates a pinned reading
+func (db *DB) SetReadingNote(serial int64, note string) bool {
+	db.Lock()
+	defer db.Unlock()
+	p, ok := db.pinnedReadings[serial]
+	if ok {
+		p.note = note
+	}
+	return ok
+}
+
+// GetPinnedEvents returns the pinned events regardless of the filter, oldest first
+func (db *DB) GetPinnedEvents() []EventRecord {
+	db.RLock()
+	defer db.RUnlock()
+	return db.getPinnedEvents()
+}
+
+func (db *DB) getPinnedEvents() []EventRecord {
+	events := make([]EventRecord, 0, len(db.pinnedEvents))
+	for _, p := range db.pinnedEvents {
+		db.events.SelectAt(p.index, func(v column.Selector) {
+			events = append(events, db.eventFromSelector(v))
+		})
+	}
+	sort.Slice(events, func(i, j int) bool {
+		return events[i].Serial < events[j].Serial
+	})
+	return events
+}
+
+// GetPinnedReadings returns the pinned readings regardless of the filter, oldest first
+func (db *DB) GetPinnedReadings() []ReadingRecord {
+	db.RLock()
+	defer db.RUnlock()
+	return db.getPinnedReadings()
+}
+
+func (db *DB) getPinnedReadings() []ReadingRecord {
+	readings := make([]ReadingRecord, 0, len(db.pinnedReadings))
+	for _, p := range db.pinnedReadings {
+		db.readings.SelectAt(p.index, func(v column.Selector) {
+			readings = append(readings, db.readingFromSelector(v))
+		})
+	}
+	sort.Slice(readings, func(i, j int) bool {
+		return readings[i].Serial < readings[j].Serial
+	})
+	return readings
+}
+
+// SessionExport is what gets saved when exporting the session
+type SessionExport struct {
+	ExportedAt     int64           `json:"exportedAt"`
+	Filter         string          `json:"filter,omitempty"`
+	Events         []EventRecord   `json:"events"`
+	Readings       []ReadingRecord `json:"readings"`
+	PinnedEvents   []EventRecord   `json:"pinnedEvents"`
+	PinnedReadings []ReadingRecord `json:"pinnedReadings"`
+}
+
+// Export returns everything that is buffered, ignoring the filter, along with the pinned rows
+func (db *DB) Export() SessionExport {
+	db.RLock()
+	defer db.RUnlock()
+	return SessionExport{
+		ExportedAt:     time.Now().UnixNano(),
+		Filter:         db.filterString,
+		Events:         db.getEvents(false),
+		Readings:       db.getReadings(false),
+		PinnedEvents:   db.getPinnedEvents(),
+		PinnedReadings: db.getPinnedReadings(),
+	}
+}
+
+func pinRow(q *rowQueue, pinned map[int64]*pin, serial int64) bool {
+	if _, ok := pinned[serial]; ok {
+		return true
+	}
+	idx, ok := q.remove(serial)
+	if !ok {
+		return false
+	}
+	pinned[serial] = &pin{index: idx}
+	return true
+}
+
+func unpinRow(q *rowQueue, pinned map[int64]*pin, serial int64) bool {
+	p, ok := pinned[serial]
+	if !ok {
+		return false
+	}
+	delete(pinned, serial)
+	q.insert(serial, p.index)
+	return true
+}
@@ -0,0 +1,205 @@
+// Copyright 2021 Alessandro De Blasis <alex@deblasis.net>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+package services
+
+import (
+	"sync"
+	"time"
+)
+
+// DefaultRateWindows are the windows the rates are averaged over, a short one makes bursts visible
+var DefaultRateWindows = []time.Duration{
+	10 * time.Second,
+	time.Minute,
+	5 * time.Minute,
+	15 * time.Minute,
+}
+
+// DefaultRateWindow is the window used when none has been picked
+const DefaultRateWindow = time.Minute
+
+// RateStats is a snapshot of the rates averaged over a window
+type RateStats struct {
+	Window time.Duration
+
+	EventsPerSecond   float64
+	ReadingsPerSecond float64
+
+	// the highest rates the window has averaged so far
+	PeakEventsPerSecond   float64
+	PeakReadingsPerSecond float64
+}
+
+// rollingCounter keeps one bucket per second, so it can answer for any window up to its size
+type rollingCounter struct {
+	buckets    []int64
+	lastSecond int64
+}
+
+func newRollingCounter(seconds int) *rollingCounter {
+	return &rollingCounter{
+		buckets: make([]int64, seconds),
+	}
+}
+
+func (c *rollingCounter) add(now time.Time, n int64) {
+	c.advance(now.Unix())
+	c.buckets[c.index(now.Unix())] += n
+}
+
+// perSecond averages the seconds up to now, the current one included
+func (c *rollingCounter) perSecond(now time.Time, seconds int) float64 {
+	end := now.Unix()
+	c.advance(end)
+	size := int64(len(c.buckets))
+	var sum int64
+	for s := end; s > end-int64(seconds) && s > c.lastSecond-size; s-- {
+		sum += c.buckets[c.index(s)]
+	}
+	return float64(sum) / float64(seconds)
+}
+
+func (c *rollingCounter) index(second int64) int64 {
+	size := int64(len(c.buckets))
+	return (second%size + size) % size
+}
+
+// advance clears the buckets of the seconds that went by since the last call
+func (c *rollingCounter) advance(second int64) {
+	if second <= c.lastSecond {
+		return
+	}
+	size := int64(len(c.buckets))
+	elapsed := second - c.lastSecond
+	if elapsed > size {
+		elapsed = size
+	}
+	for i := int64(1); i <= elapsed; i++ {
+		c.buckets[c.index(second-elapsed+i)] = 0
+	}
+	c.lastSecond = second
+}
+
+// rateTracker averages events and readings over several windows at once
+type rateTracker struct {
+	windows  []time.Duration
+	events   *rollingCounter
+	readings *rollingCounter
+
+	peakEvents   []float64
+	peakReadings []float64
+	// peaks are checked when a second is over, once its bucket is complete
+	peaksCheckedAt int64
+
+	sync.Mutex
+}
+
+func newRateTracker(windows []time.Duration) *rateTracker {
+	longest := 1
+	for _, w := range windows {
+		if s := windowSeconds(w); s > longest {
+			longest = s
+		}
+	}
+	return &rateTracker{
+		windows:      windows,
+		events:       newRollingCounter(longest),
+		readings:     newRollingCounter(longest),
+		peakEvents:   make([]float64, len(windows)),
+		peakReadings: make([]float64, len(windows)),
+	}
+}
+
+// reset forgets the rates and the peaks
+func (t *rateTracker) reset() {
+	t.Lock()
+	defer t.Unlock()
+	t.events = newRollingCounter(len(t.events.buckets))
+	t.readings = newRollingCounter(len(t.readings.buckets))
+	t.peakEvents = make([]float64, len(t.windows))
+	t.peakReadings = make([]float64, len(t.windows))
+	t.peaksCheckedAt = 0
+}
+
+func windowSeconds(w time.Duration) int {
+	s := int(w / time.Second)
+	if s < 1 {
+		return 1
+	}
+	return s
+}
+
+func (t *rateTracker) track(now time.Time, readings int64) {
+	t.Lock()
+	defer t.Unlock()
+	t.updatePeaks(now)
+	t.events.add(now, 1)
+	t.readings.add(now, readings)
+}
+
+// updatePeaks must be called holding the lock
+func (t *rateTracker) updatePeaks(now time.Time) {
+	if now.Unix() <= t.peaksCheckedAt {
+		return
+	}
+	t.peaksCheckedAt = now.Unix()
+
+	// nothing was counted after the last written second, so the windows ending there
+	// are the busiest since the previous check
+	last := time.Unix(t.events.lastSecond, 0)
+	for i, w := range t.windows {
+		seconds := windowSeconds(w)
+		if e := t.events.perSecond(last, seconds); e > t.peakEvents[i] {
+			t.peakEvents[i] = e
+		}
+		if r := t.readings.perSecond(last, seconds); r > t.peakReadings[i] {
+			t.peakReadings[i] = r
+		}
+	}
+}
+
+func (t *rateTracker) snapshot(now time.Time) []RateStats {
+	t.Lock()
+	defer t.Unlock()
+	t.updatePeaks(now)
+
+	stats := make([]RateStats, 0, len(t.windows))
+	for i, w := range t.windows {
+		seconds := windowSeconds(w)
+		stats = append(stats, RateStats{
+			Window:                w,
+			EventsPerSecond:       t.events.perSecond(now, seconds),
+			ReadingsPerSecond:     t.readings.perSecond(now, seconds),
+			PeakEventsPerSecond:   t.peakEvents[i],
+			PeakReadingsPerSecond: t.peakReadings[i],
+		})
+	}
+	return stats
+}
+
+// Rates returns the rates averaged over each of the tracked windows, in the order they were configured
+func (ep *EventProcessor) Rates() []RateStats {
+	return ep.rates.snapshot(time.Now())
+}
+
+// RatesOver returns the rates averaged over the window, which must be one of the tracked ones
+func (ep *EventProcessor) RatesOver(window time.Duration) (RateStats, bool) {
+	for _, s := range ep.Rates() {
+		if s.Window == window {
+			return s, true
+		}
+	}
+	return RateStats{}, false
+}
@@ -0,0 +1,67 @@
+// Copyright 2021 Alessandro De Blasis <alex@deblasis.net>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+package services
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func Test_RollingCounterWindow(t *testing.T) {
+	c := newRollingCounter(60)
+	start := time.Unix(2000, 0)
+
+	c.add(start, 30)
+	c.add(start.Add(30*time.Second), 30)
+	require.Equal(t, 1.0, c.perSecond(start.Add(59*time.Second), 60))
+	require.Equal(t, 0.5, c.perSecond(start.Add(60*time.Second), 60))
+	require.Equal(t, 3.0, c.perSecond(start.Add(39*time.Second), 10))
+	require.Equal(t, 0.0, c.perSecond(start.Add(40*time.Second), 10))
+	require.Equal(t, 0.0, c.perSecond(start.Add(5*time.Minute), 60))
+}
+
+func Test_RatesOverSeveralWindows(t *testing.T) {
+	tracker := newRateTracker([]time.Duration{10 * time.Second, time.Minute})
+	start := time.Unix(3000, 0)
+
+	// a one second burst of 100 events, 2 readings each
+	for i := 0; i < 100; i++ {
+		tracker.track(start, 2)
+	}
+
+	stats := tracker.snapshot(start.Add(5 * time.Second))
+	require.Len(t, stats, 2)
+	require.Equal(t, 10*time.Second, stats[0].Window)
+	require.Equal(t, 10.0, stats[0].EventsPerSecond)
+	require.Equal(t, 20.0, stats[0].ReadingsPerSecond)
+	require.InDelta(t, 100.0/60, stats[1].EventsPerSecond, 0.0001)
+
+	// the burst is gone from the short window but its peak is remembered
+	stats = tracker.snapshot(start.Add(30 * time.Second))
+	require.Equal(t, 0.0, stats[0].EventsPerSecond)
+	require.Equal(t, 10.0, stats[0].PeakEventsPerSecond)
+	require.Equal(t, 20.0, stats[0].PeakReadingsPerSecond)
+	require.InDelta(t, 100.0/60, stats[1].PeakEventsPerSecond, 0.0001)
+
+	// a quieter period doesn't lower the peaks
+	for s := 31; s < 50; s++ {
+		tracker.track(start.Add(time.Duration(s)*time.Second), 1)
+	}
+	stats = tracker.snapshot(start.Add(49 * time.Second))
+	require.Equal(t, 1.0, stats[0].EventsPerSecond)
+	require.Equal(t, 10.0, stats[0].PeakEventsPerSecond)
+}
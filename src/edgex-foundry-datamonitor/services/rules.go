@@ -0,0 +1,436 @@
+// Copyright 2021 Alessandro De Blasis <alex@deblasis.net>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"path"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/edgexfoundry/go-mod-core-contracts/v2/dtos"
+)
+
+const ruleCheckInterval = time.Second
+
+// Rule watches the values of the readings of the selected devices and resources,
+// it fires when its condition has held for HoldSeconds
+type Rule struct {
+	Name    string `json:"name"`
+	Enabled bool   `json:"enabled"`
+
+	// Device and Resource are glob patterns, empty matches anything
+	Device   string `json:"device"`
+	Resource string `json:"resource"`
+
+	Condition RuleCondition `json:"condition"`
+	// Value is the threshold of > and <, the expected value of == and the lower bound of between
+	Value string `json:"value,omitempty"`
+	// Upper is the upper bound of between
+	Upper string `json:"upper,omitempty"`
+
+	// HoldSeconds is how long the condition must hold before the rule fires,
+	// for missing it's how long the readings can be missing
+	HoldSeconds int `json:"holdSeconds"`
+}
+
+func (r Rule) Hold() time.Duration {
+	return time.Duration(r.HoldSeconds) * time.Second
+}
+
+func (r Rule) Validate() error {
+	if strings.TrimSpace(r.Name) == "" {
+		return errors.New("The name should not be empty")
+	}
+	for _, pattern := range []string{r.Device, r.Resource} {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return fmt.Errorf("%q is not a valid pattern", pattern)
+		}
+	}
+	if r.HoldSeconds < 0 {
+		return errors.New("The hold duration should not be negative")
+	}
+
+	switch r.Condition {
+	case GreaterThan, LessThan:
+		if _, err := strconv.ParseFloat(r.Value, 64); err != nil {
+			return fmt.Errorf("%q is not a number", r.Value)
+		}
+	case EqualTo:
+		if r.Value == "" {
+			return errors.New("The expected value should not be empty")
+		}
+	case Between:
+		lower, err := strconv.ParseFloat(r.Value, 64)
+		if err != nil {
+			return fmt.Errorf("%q is not a number", r.Value)
+		}
+		upper, err := strconv.ParseFloat(r.Upper, 64)
+		if err != nil {
+			return fmt.Errorf("%q is not a number", r.Upper)
+		}
+		if lower > upper {
+			return errors.New("The lower bound should not exceed the upper one")
+		}
+	case Changed:
+	case Missing:
+		if r.HoldSeconds == 0 {
+			return errors.New("The readings can't be missing for zero seconds")
+		}
+	default:
+		return fmt.Errorf("%q is not a condition", r.Condition)
+	}
+	return nil
+}
+
+// Description tells what the rule checks, e.g. "temperature between 10 and 30 for 1m0s"
+func (r Rule) Description() string {
+	var condition string
+	switch r.Condition {
+	case Between:
+		condition = fmt.Sprintf("between %v and %v", r.Value, r.Upper)
+	case Changed:
+		condition = "changed"
+	case Missing:
+		return fmt.Sprintf("%v missing for %v", r.selector(), r.Hold())
+	default:
+		condition = fmt.Sprintf("%v %v", r.Condition, r.Value)
+	}
+	if r.HoldSeconds > 0 {
+		return fmt.Sprintf("%v %v for %v", r.selector(), condition, r.Hold())
+	}
+	return fmt.Sprintf("%v %v", r.selector(), condition)
+}
+
+func (r Rule) selector() string {
+	device, resource := r.Device, r.Resource
+	if device == "" {
+		device = "*"
+	}
+	if resource == "" {
+		resource = "*"
+	}
+	return device + "/" + resource
+}
+
+func (r Rule) matches(reading dtos.BaseReading) bool {
+	return globMatch(r.Device, reading.DeviceName) && globMatch(r.Resource, reading.ResourceName)
+}
+
+func globMatch(pattern, name string) bool {
+	if pattern == "" {
+		return true
+	}
+	ok, _ := path.Match(pattern, name)
+	return ok
+}
+
+// holds checks the condition on a value, previous is the last value of the same device and resource
+func (r Rule) holds(value string, previous *string) bool {
+	switch r.Condition {
+	case EqualTo:
+		if v, err := strconv.ParseFloat(value, 64); err == nil {
+			if expected, err := strconv.ParseFloat(r.Value, 64); err == nil {
+				return v == expected
+			}
+		}
+		return value == r.Value
+	case Changed:
+		return previous != nil && *previous != value
+	}
+
+	v, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		return false
+	}
+	threshold, _ := strconv.ParseFloat(r.Value, 64)
+	switch r.Condition {
+	case GreaterThan:
+		return v > threshold
+	case LessThan:
+		return v < threshold
+	case Between:
+		upper, _ := strconv.ParseFloat(r.Upper, 64)
+		return v >= threshold && v <= upper
+	}
+	return false
+}
+
+// ParseRules reads the rules stored in the preferences
+func ParseRules(s string) ([]Rule, error) {
+	rules := make([]Rule, 0)
+	if strings.TrimSpace(s) == "" {
+		return rules, nil
+	}
+	if err := json.Unmarshal([]byte(s), &rules); err != nil {
+		return nil, err
+	}
+	return rules, nil
+}
+
+// FormatRules encodes the rules to be stored in the preferences
+func FormatRules(rules []Rule) string {
+	j, _ := json.Marshal(rules)
+	return string(j)
+}
+
+// ruleState follows a rule on a single device and resource
+type ruleState struct {
+	rule     int
+	device   string
+	resource string
+
+	// since is when the condition started holding, zero while it doesn't
+	since time.Time
+	value string
+	// previous is the last value seen, for changed
+	previous *string
+	firing   bool
+}
+
+func stateKey(rule int, device, resource string) string {
+	return fmt.Sprintf("%d/%v/%v", rule, device, resource)
+}
+
+func (s *ruleState) source(rule Rule) string {
+	return fmt.Sprintf("%v (%v/%v)", rule.Name, s.device, s.resource)
+}
+
+// RulesEngine evaluates the rules on every reading received and raises an alert when one fires
+type RulesEngine struct {
+	rules  []Rule
+	states map[string]*ruleState
+	// lastMatch is when each rule last matched a reading, for missing
+	lastMatch []time.Time
+	missing   []bool
+
+	alerts *AlertLog
+
+	sync.Mutex
+}
+
+func NewRulesEngine(alerts *AlertLog) *RulesEngine {
+	e := &RulesEngine{
+		alerts: alerts,
+	}
+	e.SetRules([]Rule{}, time.Now())
+	return e
+}
+
+// SetRules replaces the rules. The ones that are unchanged, same name and definition, keep
+// their state and alerts, the alerts of the ones that were removed or changed recover
+func (e *RulesEngine) SetRules(rules []Rule, now time.Time) {
+	e.Lock()
+	previous := e.rules
+	states := e.states
+	lastMatch := e.lastMatch
+	missing := e.missing
+
+	e.rules = make([]Rule, len(rules))
+	copy(e.rules, rules)
+	e.states = map[string]*ruleState{}
+	e.lastMatch = make([]time.Time, len(rules))
+	e.missing = make([]bool, len(rules))
+
+	// kept maps the index of each unchanged rule to its new one
+	kept := make(map[int]int)
+	taken := make([]bool, len(rules))
+	for i, rule := range previous {
+		for j := range rules {
+			if !taken[j] && rules[j] == rule {
+				taken[j] = true
+				kept[i] = j
+				break
+			}
+		}
+	}
+	for j := range rules {
+		e.lastMatch[j] = now
+	}
+	for i, j := range kept {
+		e.lastMatch[j] = lastMatch[i]
+		e.missing[j] = missing[i]
+	}
+
+	recovered := make([]*ruleState, 0)
+	for _, s := range states {
+		j, ok := kept[s.rule]
+		if !ok {
+			if s.firing {
+				recovered = append(recovered, s)
+			}
+			continue
+		}
+		s.rule = j
+		e.states[stateKey(j, s.device, s.resource)] = s
+	}
+	e.Unlock()
+
+	for _, s := range recovered {
+		e.alerts.Recover(RuleAlert, s.source(previous[s.rule]), fmt.Sprintf("%v is no longer evaluated", previous[s.rule].Name), now)
+	}
+	for i, m := range missing {
+		if _, ok := kept[i]; m && !ok {
+			e.alerts.Recover(RuleAlert, previous[i].Name, fmt.Sprintf("%v is no longer evaluated", previous[i].Name), now)
+		}
+	}
+}
+
+// Rules returns a copy of the rules being evaluated
+func (e *RulesEngine) Rules() []Rule {
+	e.Lock()
+	defer e.Unlock()
+	rules := make([]Rule, len(e.rules))
+	copy(rules, e.rules)
+	return rules
+}
+
+func (e *RulesEngine) OnEventReceived(event dtos.Event) {
+	e.OnEventReceivedAt(event, time.Now())
+}
+
+func (e *RulesEngine) OnEventReceivedAt(event dtos.Event, receivedAt time.Time) {
+	for _, reading := range event.Readings {
+		e.evaluate(reading, receivedAt)
+	}
+}
+
+type ruleTransition struct {
+	raise   bool
+	source  string
+	message string
+}
+
+func (e *RulesEngine) evaluate(reading dtos.BaseReading, now time.Time) {
+	e.Lock()
+	transitions := make([]ruleTransition, 0)
+	for i, rule := range e.rules {
+		if !rule.Enabled || !rule.matches(reading) {
+			continue
+		}
+
+		e.lastMatch[i] = now
+		if e.missing[i] {
+			e.missing[i] = false
+			transitions = append(transitions, ruleTransition{
+				source:  rule.Name,
+				message: fmt.Sprintf("%v: readings of %v are received again", rule.Name, rule.selector()),
+			})
+		}
+		if rule.Condition == Missing {
+			continue
+		}
+
+		key := stateKey(i, reading.DeviceName, reading.ResourceName)
+		s, ok := e.states[key]
+		if !ok {
+			s = &ruleState{rule: i, device: reading.DeviceName, resource: reading.ResourceName}
+			e.states[key] = s
+		}
+
+		value := reading.Value
+		holds := rule.holds(value, s.previous)
+		s.previous = &value
+		s.value = value
+		if !holds {
+			s.since = time.Time{}
+			if s.firing {
+				s.firing = false
+				transitions = append(transitions, ruleTransition{
+					source:  s.source(rule),
+					message: fmt.Sprintf("%v: %v/%v is %v", rule.Name, s.device, s.resource, value),
+				})
+			}
+			continue
+		}
+		if s.since.IsZero() {
+			s.since = now
+		}
+		if t, ok := e.fire(rule, s, now); ok {
+			transitions = append(transitions, t)
+		}
+	}
+	e.Unlock()
+
+	e.apply(transitions, now)
+}
+
+// fire must be called holding the lock
+func (e *RulesEngine) fire(rule Rule, s *ruleState, now time.Time) (ruleTransition, bool) {
+	if s.firing || s.since.IsZero() || now.Sub(s.since) < rule.Hold() {
+		return ruleTransition{}, false
+	}
+	s.firing = true
+	return ruleTransition{
+		raise:   true,
+		source:  s.source(rule),
+		message: fmt.Sprintf("%v: %v/%v is %v (%v)", rule.Name, s.device, s.resource, s.value, rule.Description()),
+	}, true
+}
+
+// check fires the rules whose condition has been holding long enough meanwhile, and the ones whose readings are missing
+func (e *RulesEngine) check(now time.Time) {
+	e.Lock()
+	transitions := make([]ruleTransition, 0)
+	for _, s := range e.states {
+		if t, ok := e.fire(e.rules[s.rule], s, now); ok {
+			transitions = append(transitions, t)
+		}
+	}
+	for i, rule := range e.rules {
+		if !rule.Enabled || rule.Condition != Missing || e.missing[i] || now.Sub(e.lastMatch[i]) < rule.Hold() {
+			continue
+		}
+		e.missing[i] = true
+		transitions = append(transitions, ruleTransition{
+			raise:   true,
+			source:  rule.Name,
+			message: fmt.Sprintf("%v: no readings of %v since %v", rule.Name, rule.selector(), e.lastMatch[i].Format("15:04:05")),
+		})
+	}
+	e.Unlock()
+
+	e.apply(transitions, now)
+}
+
+// apply changes the alerts outside of the lock, the subscribers might call back into the engine
+func (e *RulesEngine) apply(transitions []ruleTransition, now time.Time) {
+	for _, t := range transitions {
+		if t.raise {
+			e.alerts.Raise(RuleAlert, t.source, t.message, now)
+		} else {
+			e.alerts.Recover(RuleAlert, t.source, t.message, now)
+		}
+	}
+}
+
+// Run checks the rules that can fire without a reading being received, until ctx is done
+func (e *RulesEngine) Run(ctx context.Context) {
+	ticker := time.NewTicker(ruleCheckInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-ticker.C:
+			e.check(now)
+		}
+	}
+}
@@ -0,0 +1,163 @@
+// Copyright 2021 Alessandro De Blasis <alex@deblasis.net>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+package services
+
+import (
+	"testing"
+	"time"
+
+	"github.com/edgexfoundry/go-mod-core-contracts/v2/dtos"
+	"github.com/stretchr/testify/require"
+)
+
+func readingEvent(device, resource, value string) dtos.Event {
+	event := dummyEvent()
+	event.DeviceName = device
+	event.Readings[0].DeviceName = device
+	event.Readings[0].ResourceName = resource
+	event.Readings[0].Value = value
+	return event
+}
+
+func Test_RuleValidation(t *testing.T) {
+	require.NoError(t, Rule{Name: "hot", Condition: GreaterThan, Value: "30"}.Validate())
+	require.NoError(t, Rule{Name: "on", Condition: EqualTo, Value: "true"}.Validate())
+	require.NoError(t, Rule{Name: "ok", Condition: Between, Value: "1", Upper: "2"}.Validate())
+
+	require.Error(t, Rule{Condition: GreaterThan, Value: "30"}.Validate())
+	require.Error(t, Rule{Name: "hot", Condition: GreaterThan, Value: "warm"}.Validate())
+	require.Error(t, Rule{Name: "ok", Condition: Between, Value: "2", Upper: "1"}.Validate())
+	require.Error(t, Rule{Name: "gone", Condition: Missing}.Validate())
+	require.Error(t, Rule{Name: "hot", Condition: ">=", Value: "30"}.Validate())
+	require.Error(t, Rule{Name: "hot", Device: "[", Condition: GreaterThan, Value: "30"}.Validate())
+
+	rules := []Rule{{Name: "hot", Enabled: true, Device: "thermo-*", Condition: GreaterThan, Value: "30", HoldSeconds: 10}}
+	parsed, err := ParseRules(FormatRules(rules))
+	require.NoError(t, err)
+	require.Equal(t, rules, parsed)
+
+	parsed, err = ParseRules("")
+	require.NoError(t, err)
+	require.Empty(t, parsed)
+}
+
+func Test_RuleFiresAfterHoldAndRecovers(t *testing.T) {
+	alerts := NewAlertLog()
+	e := NewRulesEngine(alerts)
+	now := time.Now()
+	e.SetRules([]Rule{{Name: "hot", Enabled: true, Device: "thermo-*", Resource: "temperature", Condition: GreaterThan, Value: "30", HoldSeconds: 10}}, now)
+
+	// other devices and resources are ignored
+	e.OnEventReceivedAt(readingEvent("other", "temperature", "50"), now)
+	e.OnEventReceivedAt(readingEvent("thermo-1", "humidity", "50"), now)
+	e.OnEventReceivedAt(readingEvent("thermo-1", "temperature", "31.5"), now)
+	require.Equal(t, 0, alerts.ActiveCount())
+
+	// the condition must hold for 10s, even without new readings
+	e.OnEventReceivedAt(readingEvent("thermo-1", "temperature", "32"), now.Add(5*time.Second))
+	require.Equal(t, 0, alerts.ActiveCount())
+	e.check(now.Add(10 * time.Second))
+	require.Equal(t, 1, alerts.ActiveCount())
+	require.Equal(t, RuleAlert, alerts.Get()[0].Kind)
+	require.Equal(t, "hot (thermo-1/temperature)", alerts.Get()[0].Source)
+
+	e.OnEventReceivedAt(readingEvent("thermo-1", "temperature", "29"), now.Add(11*time.Second))
+	require.Equal(t, 0, alerts.ActiveCount())
+	require.False(t, alerts.Get()[0].Active())
+
+	// a value dropping back resets the hold
+	e.OnEventReceivedAt(readingEvent("thermo-1", "temperature", "31"), now.Add(12*time.Second))
+	e.OnEventReceivedAt(readingEvent("thermo-1", "temperature", "29"), now.Add(20*time.Second))
+	e.OnEventReceivedAt(readingEvent("thermo-1", "temperature", "31"), now.Add(21*time.Second))
+	e.check(now.Add(25 * time.Second))
+	require.Equal(t, 0, alerts.ActiveCount())
+	e.check(now.Add(31 * time.Second))
+	require.Equal(t, 1, alerts.ActiveCount())
+
+	// replacing the rules recovers their alerts
+	e.SetRules([]Rule{}, now.Add(time.Minute))
+	require.Equal(t, 0, alerts.ActiveCount())
+}
+
+func Test_EditingARuleKeepsTheOthersFiring(t *testing.T) {
+	alerts := NewAlertLog()
+	e := NewRulesEngine(alerts)
+	now := time.Now()
+	hot := Rule{Name: "hot", Enabled: true, Resource: "temperature", Condition: GreaterThan, Value: "30"}
+	cold := Rule{Name: "cold", Enabled: true, Resource: "temperature", Condition: LessThan, Value: "0"}
+	gone := Rule{Name: "gone", Enabled: true, Resource: "humidity", Condition: Missing, HoldSeconds: 30}
+	e.SetRules([]Rule{hot, cold, gone}, now)
+
+	e.OnEventReceivedAt(readingEvent("thermo-1", "temperature", "31"), now)
+	e.check(now.Add(30 * time.Second))
+	require.Equal(t, 2, alerts.ActiveCount())
+	require.Len(t, alerts.Get(), 2)
+
+	// editing cold, and moving it first, leaves hot and gone alone
+	cold.Value = "-5"
+	e.SetRules([]Rule{cold, hot, gone}, now.Add(40*time.Second))
+	require.Equal(t, 2, alerts.ActiveCount())
+	require.Len(t, alerts.Get(), 2)
+
+	// hot still knows it's firing, it neither raises again nor forgets to recover
+	e.OnEventReceivedAt(readingEvent("thermo-1", "temperature", "32"), now.Add(41*time.Second))
+	require.Len(t, alerts.Get(), 2)
+	e.OnEventReceivedAt(readingEvent("thermo-1", "temperature", "20"), now.Add(42*time.Second))
+	require.Equal(t, 1, alerts.ActiveCount())
+	require.Equal(t, "hot (thermo-1/temperature)", alerts.Get()[1].Source)
+	require.False(t, alerts.Get()[1].Active())
+
+	// changing a firing rule recovers it
+	hot.Value = "10"
+	gone.HoldSeconds = 60
+	e.SetRules([]Rule{cold, hot, gone}, now.Add(time.Minute))
+	require.Equal(t, 0, alerts.ActiveCount())
+}
+
+func Test_RuleConditions(t *testing.T) {
+	previous := "1"
+	require.True(t, Rule{Condition: LessThan, Value: "0"}.holds("-1.5e+00", nil))
+	require.False(t, Rule{Condition: LessThan, Value: "0"}.holds("text", nil))
+	require.True(t, Rule{Condition: EqualTo, Value: "1"}.holds("1.0", nil))
+	require.True(t, Rule{Condition: EqualTo, Value: "true"}.holds("true", nil))
+	require.True(t, Rule{Condition: Between, Value: "1", Upper: "2"}.holds("2", nil))
+	require.False(t, Rule{Condition: Between, Value: "1", Upper: "2"}.holds("2.1", nil))
+	require.False(t, Rule{Condition: Changed}.holds("1", nil))
+	require.False(t, Rule{Condition: Changed}.holds("1", &previous))
+	require.True(t, Rule{Condition: Changed}.holds("2", &previous))
+}
+
+func Test_RuleFiresWhenReadingsAreMissing(t *testing.T) {
+	alerts := NewAlertLog()
+	e := NewRulesEngine(alerts)
+	now := time.Now()
+	e.SetRules([]Rule{{Name: "gone", Enabled: true, Resource: "temperature", Condition: Missing, HoldSeconds: 30}}, now)
+
+	e.check(now.Add(29 * time.Second))
+	require.Equal(t, 0, alerts.ActiveCount())
+	e.check(now.Add(30 * time.Second))
+	require.Equal(t, 1, alerts.ActiveCount())
+	require.Equal(t, "gone", alerts.Get()[0].Source)
+
+	e.OnEventReceivedAt(readingEvent("thermo-1", "temperature", "20"), now.Add(40*time.Second))
+	require.Equal(t, 0, alerts.ActiveCount())
+	e.check(now.Add(69 * time.Second))
+	require.Equal(t, 0, alerts.ActiveCount())
+
+	// disabled rules are not evaluated
+	e.SetRules([]Rule{{Name: "gone", Resource: "temperature", Condition: Missing, HoldSeconds: 30}}, now)
+	e.check(now.Add(time.Hour))
+	require.Equal(t, 0, alerts.ActiveCount())
+}
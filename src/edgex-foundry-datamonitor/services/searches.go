@@ -0,0 +1,171 @@
+// Copyright 2021 Alessandro De Blasis <alex@deblasis.net>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+package services
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/deblasis/edgex-foundry-datamonitor/config"
+)
+
+// DataFilter is what the Data page is filtered by, named when it's a saved search
+type DataFilter struct {
+	Name     string `json:"name,omitempty"`
+	Text     string `json:"text"`
+	DataType string `json:"dataType"`
+	// Device scopes the data to a single device, empty for every device
+	Device        string `json:"device,omitempty"`
+	PinnedOnly    bool   `json:"pinnedOnly,omitempty"`
+	AnomaliesOnly bool   `json:"anomaliesOnly,omitempty"`
+	// Fields are the keys of the fields the text is looked for in, empty for every field
+	Fields []string `json:"fields,omitempty"`
+	// Window keeps only what was received in the latest span of time, zero for any time
+	Window time.Duration `json:"window,omitempty"`
+}
+
+// IsEmpty is true when the filter lets every row through, the fields don't count without a text
+func (f DataFilter) IsEmpty() bool {
+	return f.Text == "" && f.Device == "" && !f.PinnedOnly && !f.AnomaliesOnly && f.Window == 0
+}
+
+// SameAs compares the filters regardless of their names, the fields regardless of their order
+func (f DataFilter) SameAs(other DataFilter) bool {
+	return f.Text == other.Text && f.DataType == other.DataType && f.Device == other.Device &&
+		f.PinnedOnly == other.PinnedOnly && f.AnomaliesOnly == other.AnomaliesOnly &&
+		f.Window == other.Window && sameKeys(f.Fields, other.Fields)
+}
+
+func sameKeys(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	seen := make(map[string]bool, len(a))
+	for _, k := range a {
+		seen[k] = true
+	}
+	for _, k := range b {
+		if !seen[k] {
+			return false
+		}
+	}
+	return true
+}
+
+// String reads like `"temp" in value of Readings from thermostat, received in the last 5m, anomalies only`
+func (f DataFilter) String() string {
+	s := fmt.Sprintf("%q in %v", f.Text, f.DataType)
+	if f.Text != "" && len(f.Fields) > 0 {
+		fields := ReadingFilterFields
+		if f.DataType != config.DataTypeReadings {
+			fields = EventFilterFields
+		}
+		s = fmt.Sprintf("%q in %v of %v", f.Text, FilterFieldNames(ScopeFilterFields(fields, f.Fields)), f.DataType)
+	}
+	if f.Text == "" {
+		s = fmt.Sprintf("All %v", f.DataType)
+	}
+	if f.Device != "" {
+		s = s + fmt.Sprintf(" from %v", f.Device)
+	}
+	if f.Window > 0 {
+		s = s + fmt.Sprintf(", received in the last %v", WindowText(f.Window))
+	}
+	if f.PinnedOnly {
+		s = s + ", pinned only"
+	}
+	if f.AnomaliesOnly {
+		s = s + ", anomalies only"
+	}
+	return s
+}
+
+// WindowText reads like 30s, 5m or 1h
+func WindowText(w time.Duration) string {
+	switch {
+	case w%time.Hour == 0:
+		return fmt.Sprintf("%dh", w/time.Hour)
+	case w%time.Minute == 0:
+		return fmt.Sprintf("%dm", w/time.Minute)
+	}
+	return fmt.Sprintf("%ds", w/time.Second)
+}
+
+// AddToFilterHistory puts the filter on top of the history, an older copy of it is dropped.
+// The history is capped to max filters
+func AddToFilterHistory(history []DataFilter, f DataFilter, max int) []DataFilter {
+	f.Name = ""
+	updated := make([]DataFilter, 0, len(history)+1)
+	updated = append(updated, f)
+	for _, h := range history {
+		if len(updated) == max {
+			break
+		}
+		if !h.SameAs(f) {
+			updated = append(updated, h)
+		}
+	}
+	return updated
+}
+
+// SaveSearch adds the named filter, replacing the one with the same name
+func SaveSearch(searches []DataFilter, f DataFilter) []DataFilter {
+	f.Name = strings.TrimSpace(f.Name)
+	updated := make([]DataFilter, 0, len(searches)+1)
+	replaced := false
+	for _, s := range searches {
+		if s.Name == f.Name {
+			updated = append(updated, f)
+			replaced = true
+			continue
+		}
+		updated = append(updated, s)
+	}
+	if !replaced {
+		updated = append(updated, f)
+	}
+	return updated
+}
+
+// DeleteSearch removes the saved search with the name
+func DeleteSearch(searches []DataFilter, name string) []DataFilter {
+	updated := make([]DataFilter, 0, len(searches))
+	for _, s := range searches {
+		if s.Name != name {
+			updated = append(updated, s)
+		}
+	}
+	return updated
+}
+
+// ParseDataFilters decodes the filters stored in the preferences
+func ParseDataFilters(s string) ([]DataFilter, error) {
+	filters := make([]DataFilter, 0)
+	if strings.TrimSpace(s) == "" {
+		return filters, nil
+	}
+	if err := json.Unmarshal([]byte(s), &filters); err != nil {
+		return nil, err
+	}
+	return filters, nil
+}
+
+// FormatDataFilters encodes the filters to be stored in the preferences
+func FormatDataFilters(filters []DataFilter) string {
+	j, _ := json.Marshal(filters)
+	return string(j)
+}
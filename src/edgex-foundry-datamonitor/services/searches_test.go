@@ -0,0 +1,154 @@
+// Copyright 2021 Alessandro De Blasis <alex@deblasis.net>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+package services
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func Test_FilterHistory(t *testing.T) {
+	temp := DataFilter{Text: "temp", DataType: "Readings"}
+	hum := DataFilter{Text: "hum", DataType: "Readings", Device: "sensor"}
+	history := AddToFilterHistory(nil, temp, 3)
+	history = AddToFilterHistory(history, hum, 3)
+	require.Equal(t, []DataFilter{hum, temp}, history)
+
+	// applying a filter again moves it on top without copies, names don't count
+	named := temp
+	named.Name = "temperatures"
+	history = AddToFilterHistory(history, named, 3)
+	require.Equal(t, []DataFilter{temp, hum}, history)
+
+	for _, text := range []string{"a", "b", "c"} {
+		history = AddToFilterHistory(history, DataFilter{Text: text, DataType: "Events"}, 3)
+	}
+	require.Len(t, history, 3)
+	require.Equal(t, "c", history[0].Text)
+	require.Equal(t, "a", history[2].Text)
+}
+
+func Test_SavedSearches(t *testing.T) {
+	searches := SaveSearch(nil, DataFilter{Name: " hot ", Text: "temp", DataType: "Readings"})
+	searches = SaveSearch(searches, DataFilter{Name: "cam", DataType: "Events", Device: "camera"})
+	require.Len(t, searches, 2)
+	require.Equal(t, "hot", searches[0].Name)
+
+	// the same name replaces the search in place
+	searches = SaveSearch(searches, DataFilter{Name: "hot", Text: "temperature", DataType: "Readings", AnomaliesOnly: true})
+	require.Len(t, searches, 2)
+	require.Equal(t, "temperature", searches[0].Text)
+
+	stored, err := ParseDataFilters(FormatDataFilters(searches))
+	require.NoError(t, err)
+	require.Equal(t, searches, stored)
+
+	searches = DeleteSearch(searches, "hot")
+	require.Len(t, searches, 1)
+	require.Equal(t, "cam", searches[0].Name)
+
+	empty, err := ParseDataFilters("")
+	require.NoError(t, err)
+	require.Empty(t, empty)
+	_, err = ParseDataFilters("{")
+	require.Error(t, err)
+}
+
+func Test_DataFilterString(t *testing.T) {
+	require.Equal(t, `"temp" in Readings from sensor, anomalies only`,
+		DataFilter{Text: "temp", DataType: "Readings", Device: "sensor", AnomaliesOnly: true}.String())
+	require.Equal(t, "All Events, pinned only", DataFilter{DataType: "Events", PinnedOnly: true}.String())
+	require.True(t, DataFilter{DataType: "Events"}.IsEmpty())
+	require.False(t, DataFilter{DataType: "Events", Device: "camera"}.IsEmpty())
+}
+
+func Test_DataFilterFieldsAndWindow(t *testing.T) {
+	f := DataFilter{Text: "21", DataType: "Readings", Fields: []string{"reading_value", "reading_resourceName"}, Window: 5 * time.Minute}
+	require.Equal(t, `"21" in resource name, value of Readings, received in the last 5m`, f.String())
+	require.Equal(t, "All Events, received in the last 1h", DataFilter{DataType: "Events", Window: time.Hour}.String())
+
+	// the order the fields were picked in doesn't count
+	require.True(t, f.SameAs(DataFilter{Text: "21", DataType: "Readings", Fields: []string{"reading_resourceName", "reading_value"}, Window: 5 * time.Minute}))
+	require.False(t, f.SameAs(DataFilter{Text: "21", DataType: "Readings", Fields: []string{"reading_value"}, Window: 5 * time.Minute}))
+	require.False(t, f.SameAs(DataFilter{Text: "21", DataType: "Readings", Fields: f.Fields, Window: time.Minute}))
+
+	require.False(t, DataFilter{DataType: "Events", Window: time.Minute}.IsEmpty())
+	require.True(t, DataFilter{DataType: "Events", Fields: []string{"event_tags"}}.IsEmpty())
+
+	stored, err := ParseDataFilters(FormatDataFilters([]DataFilter{f}))
+	require.NoError(t, err)
+	require.Equal(t, []DataFilter{f}, stored)
+}
+
+func Test_DBFilterByFieldsAndWindow(t *testing.T) {
+	db := NewDB(1000)
+	now := time.Now()
+
+	old := dummyEvent()
+	old.Id = "old"
+	old.DeviceName = "device-21"
+	old.Readings[0].Value = "5"
+	db.OnEventReceivedAt(old, now.Add(-2*time.Hour))
+
+	recent := dummyEvent()
+	recent.Id = "recent"
+	recent.Readings[0].Value = "21"
+	db.OnEventReceivedAt(recent, now)
+
+	// the readings are matched on the fields of their event too
+	db.UpdateFilter("21")
+	require.Len(t, db.GetEvents(), 1)
+	require.Len(t, db.GetReadings(), 2)
+
+	// only in the values, the events have none
+	db.UpdateFilterScope([]string{"reading_value"})
+	require.Empty(t, db.GetEvents())
+	readings := db.GetReadings()
+	require.Len(t, readings, 1)
+	require.Equal(t, "21", readings[0].Value)
+	require.Equal(t, "reading_value", matchedKeys(readings[0].MatchedIn))
+
+	// only in the device names, the one of the event included
+	db.UpdateFilterScope([]string{"event_deviceName"})
+	require.Len(t, db.GetEvents(), 1)
+	readings = db.GetReadings()
+	require.Len(t, readings, 1)
+	require.Equal(t, "5", readings[0].Value)
+
+	// received in the last hour, the filter applies as well
+	db.UpdateFilterWindow(time.Hour)
+	require.Empty(t, db.GetEvents())
+	require.Empty(t, db.GetReadings())
+	db.UpdateFilter("")
+	require.Len(t, db.GetEvents(), 1)
+	require.Equal(t, "recent", db.GetEvents()[0].Id)
+	require.Equal(t, int64(1), db.GetEventsCount())
+	require.Equal(t, int64(1), db.GetReadingsCount())
+	require.Len(t, db.Snapshot().Readings, 1)
+
+	db.UpdateFilterWindow(0)
+	require.Len(t, db.GetEvents(), 2)
+}
+
+func matchedKeys(fields []FilterField) string {
+	keys := make([]string, len(fields))
+	for i, f := range fields {
+		keys[i] = f.Key
+	}
+	return strings.Join(keys, ",")
+}
@@ -0,0 +1,177 @@
+// Copyright 2021 Alessandro De Blasis <alex@deblasis.net>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+package services
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/edgexfoundry/go-mod-core-contracts/v2/dtos"
+)
+
+// SeriesKey identifies the numeric readings of a resource of a device
+type SeriesKey struct {
+	Device   string
+	Resource string
+}
+
+// String names the series as device/resource, the names are quoted when either contains
+// a slash so that two series never share a name
+func (k SeriesKey) String() string {
+	if strings.Contains(k.Device, "/") || strings.Contains(k.Resource, "/") {
+		return strconv.Quote(k.Device) + "/" + strconv.Quote(k.Resource)
+	}
+	return k.Device + "/" + k.Resource
+}
+
+// SeriesPoint is a numeric reading, At is its origin or when it was received if the origin is unknown
+type SeriesPoint struct {
+	At    time.Time
+	Value float64
+}
+
+// seriesRing keeps the latest points of a series sorted by time, oldest first from head
+type seriesRing struct {
+	points []SeriesPoint
+	head   int
+	full   bool
+}
+
+// index returns where the i-th oldest point is stored
+func (r *seriesRing) index(i int) int {
+	return (r.head + i) % len(r.points)
+}
+
+func (r *seriesRing) add(p SeriesPoint) {
+	if !r.full {
+		r.points = append(r.points, p)
+		r.full = len(r.points) == cap(r.points)
+	} else {
+		r.points[r.head] = p
+		r.head = (r.head + 1) % len(r.points)
+	}
+
+	// the origins of a device are not guaranteed to be increasing, a late point is moved back to its place
+	for i := len(r.points) - 1; i > 0; i-- {
+		cur, prev := r.index(i), r.index(i-1)
+		if !r.points[cur].At.Before(r.points[prev].At) {
+			break
+		}
+		r.points[cur], r.points[prev] = r.points[prev], r.points[cur]
+	}
+}
+
+// between returns the points in [from, to], sorted by time
+func (r *seriesRing) between(from, to time.Time) []SeriesPoint {
+	start := sort.Search(len(r.points), func(i int) bool {
+		return !r.points[r.index(i)].At.Before(from)
+	})
+	end := sort.Search(len(r.points), func(i int) bool {
+		return r.points[r.index(i)].At.After(to)
+	})
+	if start >= end {
+		return []SeriesPoint{}
+	}
+	points := make([]SeriesPoint, 0, end-start)
+	for i := start; i < end; i++ {
+		points = append(points, r.points[r.index(i)])
+	}
+	return points
+}
+
+// SeriesStore is an EventListener that keeps the latest numeric readings of every device and resource
+// so that they can be plotted, capacity is the number of points per series
+type SeriesStore struct {
+	capacity  int
+	maxSeries int
+	series    map[SeriesKey]*seriesRing
+	sync.RWMutex
+}
+
+// NewSeriesStore keeps up to maxSeries series, the readings of the ones beyond are not kept
+func NewSeriesStore(capacity int, maxSeries int) *SeriesStore {
+	if capacity < 1 {
+		capacity = 1
+	}
+	return &SeriesStore{
+		capacity:  capacity,
+		maxSeries: maxSeries,
+		series:    map[SeriesKey]*seriesRing{},
+	}
+}
+
+func (s *SeriesStore) OnEventReceived(event dtos.Event) {
+	s.OnEventReceivedAt(event, time.Now())
+}
+
+func (s *SeriesStore) OnEventReceivedAt(event dtos.Event, receivedAt time.Time) {
+	s.Lock()
+	defer s.Unlock()
+	for _, r := range event.Readings {
+		value, ok := numericValue(r)
+		if !ok {
+			continue
+		}
+		device := r.DeviceName
+		if device == "" {
+			device = event.DeviceName
+		}
+		key := SeriesKey{Device: device, Resource: r.ResourceName}
+		ring, ok := s.series[key]
+		if !ok {
+			if len(s.series) >= s.maxSeries {
+				continue
+			}
+			ring = &seriesRing{points: make([]SeriesPoint, 0, s.capacity)}
+			s.series[key] = ring
+		}
+		at := receivedAt
+		if r.Origin != 0 {
+			at = time.Unix(0, r.Origin)
+		}
+		ring.add(SeriesPoint{At: at, Value: value})
+	}
+}
+
+// Keys returns the series seen so far, sorted by device and resource
+func (s *SeriesStore) Keys() []SeriesKey {
+	s.RLock()
+	defer s.RUnlock()
+	keys := make([]SeriesKey, 0, len(s.series))
+	for k := range s.series {
+		keys = append(keys, k)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if keys[i].Device != keys[j].Device {
+			return keys[i].Device < keys[j].Device
+		}
+		return keys[i].Resource < keys[j].Resource
+	})
+	return keys
+}
+
+// Points returns the points of the series between from and to, sorted by time
+func (s *SeriesStore) Points(key SeriesKey, from, to time.Time) []SeriesPoint {
+	s.RLock()
+	defer s.RUnlock()
+	ring, ok := s.series[key]
+	if !ok {
+		return []SeriesPoint{}
+	}
+	return ring.between(from, to)
+}
@@ -0,0 +1,90 @@
+// Copyright 2021 Alessandro De Blasis <alex@deblasis.net>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+package services
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func Test_SeriesStore(t *testing.T) {
+	s := NewSeriesStore(3, 2)
+	start := time.Unix(1000, 0)
+
+	send := func(device, resource, value string, at time.Time) {
+		event := readingEvent(device, resource, value)
+		event.Readings[0].Origin = at.UnixNano()
+		s.OnEventReceivedAt(event, start)
+	}
+	for i := 0; i < 5; i++ {
+		send("thermostat", "temp", "2"+string(rune('0'+i)), start.Add(time.Duration(i)*time.Second))
+	}
+	// not a number, nothing to plot
+	send("thermostat", "mode", "eco", start)
+	// arriving late, it's plotted at its origin
+	send("boiler", "pressure", "3", start.Add(2*time.Second))
+	send("boiler", "pressure", "2", start.Add(time.Second))
+	// beyond the maximum number of series
+	send("pump", "speed", "100", start)
+
+	require.Equal(t, []SeriesKey{{"boiler", "pressure"}, {"thermostat", "temp"}}, s.Keys())
+
+	// only the latest points are kept
+	points := s.Points(SeriesKey{"thermostat", "temp"}, start, start.Add(time.Hour))
+	require.Len(t, points, 3)
+	require.Equal(t, 22.0, points[0].Value)
+	require.Equal(t, 24.0, points[2].Value)
+
+	points = s.Points(SeriesKey{"thermostat", "temp"}, start.Add(3*time.Second), start.Add(3*time.Second))
+	require.Len(t, points, 1)
+	require.Equal(t, 23.0, points[0].Value)
+
+	points = s.Points(SeriesKey{"boiler", "pressure"}, start, start.Add(time.Hour))
+	require.Len(t, points, 2)
+	require.Equal(t, 2.0, points[0].Value)
+	require.Equal(t, start.Add(time.Second), points[0].At)
+
+	require.Empty(t, s.Points(SeriesKey{"pump", "speed"}, start, start.Add(time.Hour)))
+}
+
+func Test_SeriesRingStaysSorted(t *testing.T) {
+	r := &seriesRing{points: make([]SeriesPoint, 0, 4)}
+	at := func(s int64) time.Time {
+		return time.Unix(s, 0)
+	}
+	for _, s := range []int64{1, 3, 2, 5, 4, 7, 6} {
+		r.add(SeriesPoint{At: at(s), Value: float64(s)})
+	}
+
+	// the ring wrapped around, the late points found their place among the latest ones
+	values := func(points []SeriesPoint) []float64 {
+		v := make([]float64, 0, len(points))
+		for _, p := range points {
+			v = append(v, p.Value)
+		}
+		return v
+	}
+	require.Equal(t, []float64{4, 5, 6, 7}, values(r.between(at(0), at(10))))
+	require.Equal(t, []float64{5, 6}, values(r.between(at(5), at(6))))
+	require.Empty(t, r.between(at(8), at(10)))
+}
+
+func Test_SeriesKeyNamesAreUnambiguous(t *testing.T) {
+	require.Equal(t, "thermostat/temp", SeriesKey{"thermostat", "temp"}.String())
+	require.Equal(t, `"site/boiler"/"pressure"`, SeriesKey{"site/boiler", "pressure"}.String())
+	require.NotEqual(t, SeriesKey{"a/b", "c"}.String(), SeriesKey{"a", "b/c"}.String())
+}
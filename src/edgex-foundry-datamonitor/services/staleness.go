@@ -0,0 +1,234 @@
+// Copyright 2021 Alessandro De Blasis <alex@deblasis.net>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+package services
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+)
+
+const (
+	// a device's cadence is learned from the gaps between its latest events
+	learnedGapsCount = 16
+	// below this many gaps the cadence is not trusted and the device is never judged stale
+	minLearnedGaps = 2
+	// devices sending in bursts would look stale after a few milliseconds otherwise
+	minStaleThreshold = 2 * time.Second
+
+	staleCheckInterval = time.Second
+)
+
+// DeviceStatus tells when a device was last seen and how often it's expected to send events
+type DeviceStatus struct {
+	Name     string
+	LastSeen time.Time
+
+	// ExpectedInterval is zero until enough events have been seen to learn it
+	ExpectedInterval time.Duration
+	// Configured is true when ExpectedInterval comes from the settings rather than from the cadence
+	Configured bool
+
+	Stale bool
+}
+
+type deviceWatch struct {
+	lastSeen time.Time
+	gaps     []time.Duration
+	next     int
+	stale    bool
+}
+
+// seen must be called before clearing the stale flag, the outage of a device that comes back isn't part
+// of its cadence, it would make the device look stale much later the next time
+func (w *deviceWatch) seen(now time.Time) {
+	if !w.stale && !w.lastSeen.IsZero() && now.After(w.lastSeen) {
+		gap := now.Sub(w.lastSeen)
+		if len(w.gaps) < learnedGapsCount {
+			w.gaps = append(w.gaps, gap)
+		} else {
+			w.gaps[w.next] = gap
+			w.next = (w.next + 1) % learnedGapsCount
+		}
+	}
+	w.lastSeen = now
+}
+
+// learned is the longest of the latest gaps, so that a device sending in bursts is judged by the pause between them
+func (w *deviceWatch) learned() time.Duration {
+	if len(w.gaps) < minLearnedGaps {
+		return 0
+	}
+	var longest time.Duration
+	for _, g := range w.gaps {
+		if g > longest {
+			longest = g
+		}
+	}
+	return longest
+}
+
+// staleDetector raises an alert when a device stops sending events for longer than expected
+type staleDetector struct {
+	devices    map[string]*deviceWatch
+	configured map[string]time.Duration
+	factor     float64
+
+	sync.Mutex
+}
+
+func newStaleDetector(factor float64) *staleDetector {
+	return &staleDetector{
+		devices:    map[string]*deviceWatch{},
+		configured: map[string]time.Duration{},
+		factor:     factor,
+	}
+}
+
+func (d *staleDetector) configure(factor float64, intervals map[string]time.Duration) {
+	d.Lock()
+	defer d.Unlock()
+	d.factor = factor
+	d.configured = map[string]time.Duration{}
+	for name, interval := range intervals {
+		d.configured[name] = interval
+	}
+}
+
+// expected must be called holding the lock
+func (d *staleDetector) expected(name string, w *deviceWatch) (time.Duration, bool) {
+	if interval, ok := d.configured[name]; ok && interval > 0 {
+		return interval, true
+	}
+	return w.learned(), false
+}
+
+// threshold must be called holding the lock, it returns zero when the device can't be judged yet
+func (d *staleDetector) threshold(name string, w *deviceWatch) time.Duration {
+	expected, _ := d.expected(name, w)
+	if expected == 0 {
+		return 0
+	}
+	threshold := time.Duration(float64(expected) * d.factor)
+	if threshold < minStaleThreshold {
+		return minStaleThreshold
+	}
+	return threshold
+}
+
+// seen records an event of the device and returns true if the device was stale
+func (d *staleDetector) seen(name string, now time.Time) bool {
+	d.Lock()
+	defer d.Unlock()
+	w, ok := d.devices[name]
+	if !ok {
+		w = &deviceWatch{}
+		d.devices[name] = w
+	}
+	w.seen(now)
+	recovered := w.stale
+	w.stale = false
+	return recovered
+}
+
+// check returns the devices that just went stale
+func (d *staleDetector) check(now time.Time) []DeviceStatus {
+	d.Lock()
+	defer d.Unlock()
+	stale := make([]DeviceStatus, 0)
+	for name, w := range d.devices {
+		if w.stale {
+			continue
+		}
+		threshold := d.threshold(name, w)
+		if threshold == 0 || now.Sub(w.lastSeen) <= threshold {
+			continue
+		}
+		w.stale = true
+		stale = append(stale, d.status(name, w))
+	}
+	sort.Slice(stale, func(i, j int) bool {
+		return stale[i].Name < stale[j].Name
+	})
+	return stale
+}
+
+// resume forgives the devices the time spent paused, nothing could be received meanwhile
+func (d *staleDetector) resume(now time.Time) {
+	d.Lock()
+	defer d.Unlock()
+	for _, w := range d.devices {
+		if !w.stale {
+			w.lastSeen = now
+		}
+	}
+}
+
+// status must be called holding the lock
+func (d *staleDetector) status(name string, w *deviceWatch) DeviceStatus {
+	expected, configured := d.expected(name, w)
+	return DeviceStatus{
+		Name:             name,
+		LastSeen:         w.lastSeen,
+		ExpectedInterval: expected,
+		Configured:       configured,
+		Stale:            w.stale,
+	}
+}
+
+func (d *staleDetector) snapshot() []DeviceStatus {
+	d.Lock()
+	defer d.Unlock()
+	statuses := make([]DeviceStatus, 0, len(d.devices))
+	for name, w := range d.devices {
+		statuses = append(statuses, d.status(name, w))
+	}
+	sort.Slice(statuses, func(i, j int) bool {
+		return statuses[i].Name < statuses[j].Name
+	})
+	return statuses
+}
+
+// checkStaleDevices raises an alert for every device that went quiet
+func (ep *EventProcessor) checkStaleDevices(now time.Time) {
+	for _, s := range ep.stale.check(now) {
+		ep.alerts.Raise(StaleDeviceAlert, s.Name,
+			fmt.Sprintf("No events from %v since %v (expected every %v)", s.Name, s.LastSeen.Format("15:04:05"), s.ExpectedInterval), now)
+	}
+}
+
+// deviceSeen recovers the alert of the device, if it was stale
+func (ep *EventProcessor) deviceSeen(name string, now time.Time) {
+	if ep.stale.seen(name, now) {
+		ep.alerts.Recover(StaleDeviceAlert, name, fmt.Sprintf("%v is sending events again", name), now)
+	}
+}
+
+// SetStaleDetection sets how many expected intervals a device can miss before it's considered stale,
+// intervals overrides the learned cadence of some devices
+func (ep *EventProcessor) SetStaleDetection(factor float64, intervals map[string]time.Duration) {
+	ep.stale.configure(factor, intervals)
+}
+
+// DeviceStatuses returns the devices seen so far, by name
+func (ep *EventProcessor) DeviceStatuses() []DeviceStatus {
+	return ep.stale.snapshot()
+}
+
+// Alerts returns the alerts raised while processing events
+func (ep *EventProcessor) Alerts() *AlertLog {
+	return ep.alerts
+}
@@ -0,0 +1,125 @@
+// Copyright 2021 Alessandro De Blasis <alex@deblasis.net>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+package services
+
+import (
+	"testing"
+	"time"
+
+	"github.com/edgexfoundry/go-mod-core-contracts/v2/dtos"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_StaleDevicesRaiseAndRecoverAlerts(t *testing.T) {
+	ep := NewEventProcessor(make(chan *dtos.Event))
+	raised := make([]Alert, 0)
+	ep.Alerts().Subscribe(func(alert Alert) {
+		raised = append(raised, alert)
+	})
+
+	start := time.Now()
+	for i := 0; i < 3; i++ {
+		ep.deviceSeen("dev", start.Add(time.Duration(i)*10*time.Second))
+	}
+	lastSeen := start.Add(20 * time.Second)
+
+	// the cadence is 10s, with the default factor the device is stale after 30s
+	ep.checkStaleDevices(lastSeen.Add(30 * time.Second))
+	require.Empty(t, raised)
+	ep.checkStaleDevices(lastSeen.Add(31 * time.Second))
+	require.Len(t, raised, 1)
+	require.Equal(t, StaleDeviceAlert, raised[0].Kind)
+	require.Equal(t, "dev", raised[0].Source)
+	require.True(t, raised[0].Active())
+
+	// an alert is raised once while the device is stale
+	ep.checkStaleDevices(lastSeen.Add(time.Minute))
+	require.Len(t, raised, 1)
+	require.Equal(t, 1, ep.Alerts().ActiveCount())
+	require.True(t, ep.DeviceStatuses()[0].Stale)
+
+	ep.deviceSeen("dev", lastSeen.Add(2*time.Minute))
+	require.Len(t, raised, 2)
+	require.False(t, raised[1].Active())
+	require.Equal(t, raised[0].Id, raised[1].Id)
+	require.Equal(t, 0, ep.Alerts().ActiveCount())
+	require.False(t, ep.DeviceStatuses()[0].Stale)
+
+	alerts := ep.Alerts().Get()
+	require.Len(t, alerts, 1)
+	ep.Alerts().ClearRecovered()
+	require.Empty(t, ep.Alerts().Get())
+}
+
+func Test_StaleDetectionNeedsACadence(t *testing.T) {
+	ep := NewEventProcessor(make(chan *dtos.Event))
+	now := time.Now()
+
+	// a single gap isn't enough to learn the cadence
+	ep.deviceSeen("learning", now)
+	ep.deviceSeen("learning", now.Add(time.Second))
+	ep.checkStaleDevices(now.Add(time.Hour))
+	require.Equal(t, 0, ep.Alerts().ActiveCount())
+
+	// unless the interval is configured
+	ep.SetStaleDetection(2, map[string]time.Duration{"configured": time.Minute})
+	ep.deviceSeen("configured", now)
+	ep.checkStaleDevices(now.Add(2 * time.Minute))
+	require.Equal(t, 0, ep.Alerts().ActiveCount())
+	ep.checkStaleDevices(now.Add(2*time.Minute + time.Second))
+	require.Equal(t, 1, ep.Alerts().ActiveCount())
+
+	statuses := ep.DeviceStatuses()
+	require.Equal(t, "configured", statuses[0].Name)
+	require.True(t, statuses[0].Configured)
+	require.Equal(t, time.Minute, statuses[0].ExpectedInterval)
+	require.Equal(t, time.Duration(0), statuses[1].ExpectedInterval)
+}
+
+func Test_StaleDetectionIsPausedWithTheProcessor(t *testing.T) {
+	ep := NewEventProcessor(make(chan *dtos.Event))
+	now := time.Now()
+	for i := 0; i < 3; i++ {
+		ep.deviceSeen("dev", now.Add(time.Duration(i)*time.Second))
+	}
+
+	// nothing could be received while paused
+	ep.stale.resume(now.Add(time.Hour))
+	ep.checkStaleDevices(now.Add(time.Hour + time.Second))
+	require.Equal(t, 0, ep.Alerts().ActiveCount())
+}
+
+func Test_OutagesAreNotLearnedAsCadence(t *testing.T) {
+	ep := NewEventProcessor(make(chan *dtos.Event))
+	start := time.Now()
+	for i := 0; i < 3; i++ {
+		ep.deviceSeen("dev", start.Add(time.Duration(i)*10*time.Second))
+	}
+	lastSeen := start.Add(20 * time.Second)
+
+	// a 30 minutes outage, then the device is back
+	ep.checkStaleDevices(lastSeen.Add(31 * time.Second))
+	require.Equal(t, 1, ep.Alerts().ActiveCount())
+	back := lastSeen.Add(30 * time.Minute)
+	ep.deviceSeen("dev", back)
+	require.Equal(t, 0, ep.Alerts().ActiveCount())
+	require.Equal(t, 10*time.Second, ep.DeviceStatuses()[0].ExpectedInterval)
+
+	// the second outage is caught as soon as the first one
+	ep.checkStaleDevices(back.Add(30 * time.Second))
+	require.Equal(t, 0, ep.Alerts().ActiveCount())
+	ep.checkStaleDevices(back.Add(31 * time.Second))
+	require.Equal(t, 1, ep.Alerts().ActiveCount())
+}
@@ -0,0 +1,71 @@
+// Copyright 2021 Alessandro De Blasis <alex@deblasis.net>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+package services
+
+import (
+	"sync/atomic"
+	"time"
+)
+
+// Stats is a snapshot of what the events processor measured since Since
+type Stats struct {
+	TotalEvents   int64
+	TotalReadings int64
+
+	// ParseFailures counts the payloads that could not be turned into events
+	ParseFailures int64
+
+	// QueueDepth is how many events are waiting to be processed
+	QueueDepth    int
+	QueueCapacity int
+
+	// Since is when the measurement period started, at creation or at the last reset
+	Since time.Time
+
+	Rates            []RateStats
+	Latency          LatencyStats
+	LatencyHistogram LatencyHistogram
+}
+
+// Stats can be called from any goroutine while events are being processed
+func (ep *EventProcessor) Stats() Stats {
+	return Stats{
+		TotalEvents:      atomic.LoadInt64(&ep.totalEvents),
+		TotalReadings:    atomic.LoadInt64(&ep.totalReadings),
+		ParseFailures:    atomic.LoadInt64(&ep.parseFailures),
+		QueueDepth:       len(ep.eventsChannel),
+		QueueCapacity:    cap(ep.eventsChannel),
+		Since:            time.Unix(0, atomic.LoadInt64(&ep.since)),
+		Rates:            ep.Rates(),
+		Latency:          ep.Latency(),
+		LatencyHistogram: ep.latency.histogramSnapshot(),
+	}
+}
+
+// CountParseFailure accounts for a payload that could not be turned into an event and never reached the processor
+func (ep *EventProcessor) CountParseFailure() {
+	atomic.AddInt64(&ep.parseFailures, 1)
+}
+
+// ResetStats starts a new measurement period: totals, parse failures, rates, peaks, throughput and latency start over
+func (ep *EventProcessor) ResetStats() {
+	atomic.StoreInt64(&ep.since, time.Now().UnixNano())
+	atomic.StoreInt64(&ep.totalEvents, 0)
+	atomic.StoreInt64(&ep.totalReadings, 0)
+	atomic.StoreInt64(&ep.parseFailures, 0)
+	ep.rates.reset()
+	ep.throughput.reset()
+	ep.latency.reset()
+}
@@ -0,0 +1,89 @@
+// Copyright 2021 Alessandro De Blasis <alex@deblasis.net>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+package services
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/edgexfoundry/go-mod-core-contracts/v2/dtos"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_StatsAndReset(t *testing.T) {
+	ep := NewEventProcessor(make(chan *dtos.Event))
+	created := ep.Stats().Since
+
+	for i := 0; i < 3; i++ {
+		event := dummyEvent()
+		event.Origin = time.Now().UnixNano()
+		ep.processEvent(&event)
+	}
+	stats := ep.Stats()
+	require.Equal(t, int64(3), stats.TotalEvents)
+	require.Equal(t, int64(3), stats.TotalReadings)
+	require.Equal(t, 3, stats.Latency.Samples)
+	require.Len(t, stats.Rates, len(DefaultRateWindows))
+	require.Greater(t, stats.Rates[0].EventsPerSecond, 0.0)
+	require.Len(t, ep.Throughput(ByDevice), 1)
+
+	ep.ResetStats()
+	stats = ep.Stats()
+	require.Equal(t, int64(0), stats.TotalEvents)
+	require.Equal(t, int64(0), stats.TotalReadings)
+	require.Equal(t, 0, stats.Latency.Samples)
+	require.Equal(t, 0.0, stats.Rates[0].EventsPerSecond)
+	require.Equal(t, 0.0, stats.Rates[0].PeakEventsPerSecond)
+	require.Empty(t, ep.Throughput(ByDevice))
+	require.False(t, stats.Since.Before(created))
+
+	// the last events are not a measurement, they are kept
+	require.Len(t, ep.LastEvents.Get(), 3)
+}
+
+func Test_StatsCanBeReadWhileProcessing(t *testing.T) {
+	ep := NewEventProcessor(make(chan *dtos.Event))
+	const events = 500
+
+	var wg sync.WaitGroup
+	wg.Add(3)
+	go func() {
+		defer wg.Done()
+		for i := 0; i < events; i++ {
+			event := dummyEvent()
+			ep.processEvent(&event)
+		}
+	}()
+	go func() {
+		defer wg.Done()
+		for i := 0; i < events; i++ {
+			ep.Stats()
+			ep.LastEvents.Get()
+		}
+	}()
+	go func() {
+		defer wg.Done()
+		for i := 0; i < 10; i++ {
+			ep.ResetStats()
+		}
+	}()
+	wg.Wait()
+
+	ep.ResetStats()
+	event := dummyEvent()
+	ep.processEvent(&event)
+	require.Equal(t, int64(1), ep.Stats().TotalEvents)
+}
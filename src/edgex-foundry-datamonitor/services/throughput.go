@@ -0,0 +1,164 @@
+// Copyright 2021 Alessandro De Blasis <alex@deblasis.net>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+package services
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/edgexfoundry/go-mod-core-contracts/v2/dtos"
+)
+
+// throughputWindowSeconds is the span of the rolling rates
+const throughputWindowSeconds = 60
+
+// ThroughputStats is a snapshot of the traffic generated by a single device, profile or resource
+type ThroughputStats struct {
+	Dimension ThroughputDimension
+	Name      string
+
+	TotalEvents   int64
+	TotalReadings int64
+
+	// rates over the last minute
+	EventsPerSecond   float64
+	ReadingsPerSecond float64
+
+	LastSeen time.Time
+
+	Latency          LatencyStats
+	LatencyHistogram LatencyHistogram
+}
+
+type throughputCounter struct {
+	totalEvents   int64
+	totalReadings int64
+	events        *rollingCounter
+	readings      *rollingCounter
+	lastSeen      time.Time
+	latency       *latencySamples
+	histogram     *latencyHistogram
+}
+
+func (c *throughputCounter) add(now time.Time, readings int64, origin int64) {
+	c.totalEvents++
+	c.totalReadings += readings
+	c.events.add(now, 1)
+	c.readings.add(now, readings)
+	c.lastSeen = now
+	if latency, ok := Latency(origin, now.UnixNano()); ok {
+		c.latency.add(latency)
+		c.histogram.add(latency)
+	}
+}
+
+// throughputTracker breaks down the traffic by device, profile and resource
+type throughputTracker struct {
+	counters map[ThroughputDimension]map[string]*throughputCounter
+	sync.Mutex
+}
+
+func newThroughputTracker() *throughputTracker {
+	return &throughputTracker{
+		counters: map[ThroughputDimension]map[string]*throughputCounter{
+			ByDevice:   {},
+			ByProfile:  {},
+			ByResource: {},
+		},
+	}
+}
+
+func (t *throughputTracker) reset() {
+	t.Lock()
+	defer t.Unlock()
+	for dimension := range t.counters {
+		t.counters[dimension] = map[string]*throughputCounter{}
+	}
+}
+
+func (t *throughputTracker) counter(dimension ThroughputDimension, name string) *throughputCounter {
+	c, ok := t.counters[dimension][name]
+	if !ok {
+		c = &throughputCounter{
+			events:    newRollingCounter(throughputWindowSeconds),
+			readings:  newRollingCounter(throughputWindowSeconds),
+			latency:   newLatencySamples(latencySampleSizePerKey),
+			histogram: newLatencyHistogram(),
+		}
+		t.counters[dimension][name] = c
+	}
+	return c
+}
+
+func (t *throughputTracker) track(event *dtos.Event, now time.Time) {
+	t.Lock()
+	defer t.Unlock()
+
+	readings := int64(len(event.Readings))
+	t.counter(ByDevice, event.DeviceName).add(now, readings, event.Origin)
+	t.counter(ByProfile, event.ProfileName).add(now, readings, event.Origin)
+
+	// an event counts once for every resource it carries readings of,
+	// the latency of a resource is the one of its oldest reading in the event
+	byResource := map[string]int64{}
+	origins := map[string]int64{}
+	for _, r := range event.Readings {
+		byResource[r.ResourceName]++
+		if o, ok := origins[r.ResourceName]; !ok || o == 0 || (r.Origin != 0 && r.Origin < o) {
+			origins[r.ResourceName] = r.Origin
+		}
+	}
+	for name, n := range byResource {
+		t.counter(ByResource, name).add(now, n, origins[name])
+	}
+}
+
+// snapshot returns the stats of the dimension, busiest first
+func (t *throughputTracker) snapshot(dimension ThroughputDimension, now time.Time) []ThroughputStats {
+	t.Lock()
+	defer t.Unlock()
+
+	stats := make([]ThroughputStats, 0, len(t.counters[dimension]))
+	for name, c := range t.counters[dimension] {
+		stats = append(stats, ThroughputStats{
+			Dimension:         dimension,
+			Name:              name,
+			TotalEvents:       c.totalEvents,
+			TotalReadings:     c.totalReadings,
+			EventsPerSecond:   c.events.perSecond(now, throughputWindowSeconds),
+			ReadingsPerSecond: c.readings.perSecond(now, throughputWindowSeconds),
+			LastSeen:          c.lastSeen,
+			Latency:           c.latency.stats(),
+			LatencyHistogram:  c.histogram.snapshot(),
+		})
+	}
+	sort.Slice(stats, func(i, j int) bool {
+		if stats[i].EventsPerSecond != stats[j].EventsPerSecond {
+			return stats[i].EventsPerSecond > stats[j].EventsPerSecond
+		}
+		if stats[i].ReadingsPerSecond != stats[j].ReadingsPerSecond {
+			return stats[i].ReadingsPerSecond > stats[j].ReadingsPerSecond
+		}
+		return stats[i].Name < stats[j].Name
+	})
+	return stats
+}
+
+// Throughput returns the rolling rates and totals of every device, profile or resource seen so far,
+// the busiest ones first
+func (ep *EventProcessor) Throughput(dimension ThroughputDimension) []ThroughputStats {
+	return ep.throughput.snapshot(dimension, time.Now())
+}
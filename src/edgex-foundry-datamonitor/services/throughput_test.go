@@ -0,0 +1,55 @@
+// Copyright 2021 Alessandro De Blasis <alex@deblasis.net>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+package services
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func Test_ThroughputByDimension(t *testing.T) {
+	tracker := newThroughputTracker()
+	now := time.Unix(1000, 0)
+
+	for i := 0; i < 6; i++ {
+		e := dummyEvent()
+		tracker.track(&e, now)
+	}
+	interesting := interestingEvent()
+	tracker.track(&interesting, now)
+
+	devices := tracker.snapshot(ByDevice, now)
+	require.Len(t, devices, 2)
+	require.Equal(t, "device", devices[0].Name)
+	require.Equal(t, int64(6), devices[0].TotalEvents)
+	require.Equal(t, int64(6), devices[0].TotalReadings)
+	require.Equal(t, 0.1, devices[0].EventsPerSecond)
+	require.Equal(t, "interesting_device", devices[1].Name)
+	require.Equal(t, int64(2), devices[1].TotalReadings)
+
+	resources := tracker.snapshot(ByResource, now)
+	require.Len(t, resources, 2)
+	require.Equal(t, "resource", resources[0].Name)
+	require.Equal(t, ByResource, resources[0].Dimension)
+
+	// the rates roll over, the totals stay
+	later := now.Add(throughputWindowSeconds * time.Second)
+	devices = tracker.snapshot(ByDevice, later)
+	require.Equal(t, 0.0, devices[0].EventsPerSecond)
+	require.Equal(t, int64(6), devices[0].TotalEvents)
+	require.Equal(t, now, devices[0].LastSeen)
+}